@@ -0,0 +1,115 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenDuration     = 30 * time.Second
+)
+
+// ErrCircuitOpen returned by requests of Client with circuit breaker, when
+// the circuit is open because of repeated failures.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerOptions configures circuit breaker of Client, see
+// WithCircuitBreaker.
+type CircuitBreakerOptions struct {
+	// FailureThreshold is number of consecutive failed requests, which opens
+	// the circuit. Default is 5.
+	FailureThreshold int
+	// OpenDuration is how long the circuit stays open before trial request.
+	// Default is 30 seconds.
+	OpenDuration time.Duration
+}
+
+// WithCircuitBreaker configures Client to stop sending requests after
+// opts.FailureThreshold consecutive failures. Every failed request and any
+// response with 5xx status counts as failure. When the circuit is open, all
+// requests return ErrCircuitOpen immediately during opts.OpenDuration. After
+// that one trial request is allowed and it closes the circuit on success or
+// opens it again on failure.
+func WithCircuitBreaker(opts CircuitBreakerOptions) ClientOption {
+	return func(c *Client) { c.breaker = newCircuitBreaker(opts) }
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func newCircuitBreaker(opts CircuitBreakerOptions) *circuitBreaker {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = defaultFailureThreshold
+	}
+	if opts.OpenDuration <= 0 {
+		opts.OpenDuration = defaultOpenDuration
+	}
+	return &circuitBreaker{opts: opts, now: time.Now}
+}
+
+type circuitBreaker struct {
+	opts CircuitBreakerOptions
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// Allow returns ErrCircuitOpen if request isn't allowed. Every allowed request
+// must be followed by Done.
+func (self *circuitBreaker) Allow() error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	switch self.state {
+	case circuitOpen:
+		if self.now().Sub(self.openedAt) < self.opts.OpenDuration {
+			return ErrCircuitOpen
+		}
+		self.state = circuitHalfOpen
+	case circuitHalfOpen:
+		return ErrCircuitOpen // trial request is in progress
+	}
+	return nil
+}
+
+func (self *circuitBreaker) Done(success bool) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if success {
+		self.state = circuitClosed
+		self.failures = 0
+		return
+	}
+
+	self.failures++
+	if self.state == circuitHalfOpen || self.failures >= self.opts.FailureThreshold {
+		self.state = circuitOpen
+		self.openedAt = self.now()
+	}
+}
+
+// Release is like Done, but for requests, which weren't completed because of
+// caller, like cancelled context. It doesn't change number of failures.
+func (self *circuitBreaker) Release() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	if self.state == circuitHalfOpen {
+		self.state = circuitOpen // next request will be trial again
+	}
+}
+
+func succeeded(resp *http.Response, err error) bool {
+	return err == nil && resp.StatusCode < http.StatusInternalServerError
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dsh2dsh/edgar/internal/mocks/client"
+)
+
+func TestNewCircuitBreaker(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerOptions{})
+	assert.Equal(t, CircuitBreakerOptions{
+		FailureThreshold: defaultFailureThreshold,
+		OpenDuration:     defaultOpenDuration,
+	}, b.opts)
+
+	opts := CircuitBreakerOptions{FailureThreshold: 2, OpenDuration: time.Minute}
+	assert.Equal(t, opts, newCircuitBreaker(opts).opts)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(CircuitBreakerOptions{
+		FailureThreshold: 2,
+		OpenDuration:     time.Minute,
+	})
+	b.now = func() time.Time { return now }
+
+	require.NoError(t, b.Allow())
+	b.Done(false)
+	require.NoError(t, b.Allow())
+	b.Done(true)
+
+	require.NoError(t, b.Allow())
+	b.Done(false)
+	require.NoError(t, b.Allow())
+	b.Done(false)
+	require.ErrorIs(t, b.Allow(), ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, b.Allow(), "half-open")
+	require.ErrorIs(t, b.Allow(), ErrCircuitOpen, "trial in progress")
+	b.Done(false)
+	require.ErrorIs(t, b.Allow(), ErrCircuitOpen, "open again")
+
+	now = now.Add(time.Minute)
+	require.NoError(t, b.Allow())
+	b.Release()
+	require.NoError(t, b.Allow(), "trial again after release")
+	b.Done(true)
+
+	require.NoError(t, b.Allow(), "closed")
+	require.NoError(t, b.Allow())
+	b.Done(false)
+	b.Done(true)
+}
+
+func TestClient_WithCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	testErr := errors.New("test error")
+
+	httpClient := client.NewMockHttpRequestDoer(t)
+	c := New(WithHttpClient(httpClient), WithRateLimiter(nil),
+		WithCircuitBreaker(CircuitBreakerOptions{
+			FailureThreshold: 2,
+			OpenDuration:     time.Minute,
+		}))
+	now := time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)
+	c.breaker.now = func() time.Time { return now }
+
+	recorder := httptest.NewRecorder()
+	recorder.WriteHeader(http.StatusGatewayTimeout)
+	httpClient.EXPECT().Do(mock.Anything).Return(recorder.Result(), nil).Once()
+	resp, err := c.Get(ctx, "https://localhost")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	httpClient.EXPECT().Do(mock.Anything).Return(nil, testErr).Once()
+	_, err = c.Get(ctx, "https://localhost")
+	require.ErrorIs(t, err, testErr)
+
+	_, err = c.Get(ctx, "https://localhost")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	httpClient.EXPECT().Do(mock.Anything).Return(
+		httptest.NewRecorder().Result(), nil).Once()
+	resp, err = c.Get(ctx, "https://localhost")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	httpClient.EXPECT().Do(mock.Anything).Return(
+		httptest.NewRecorder().Result(), nil).Once()
+	resp, err = c.Get(ctx, "https://localhost")
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestClient_WithCircuitBreaker_cancelled(t *testing.T) {
+	httpClient := client.NewMockHttpRequestDoer(t)
+	c := New(WithHttpClient(httpClient), WithRateLimiter(nil),
+		WithCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			cancel()
+			return nil, context.Canceled
+		}).Once()
+	_, err := c.Get(ctx, "https://localhost")
+	require.ErrorIs(t, err, context.Canceled)
+
+	httpClient.EXPECT().Do(mock.Anything).Return(
+		httptest.NewRecorder().Result(), nil).Once()
+	resp, err := c.Get(context.Background(), "https://localhost")
+	require.NoError(t, err, "cancelled request isn't failure")
+	resp.Body.Close()
+}
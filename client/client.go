@@ -11,21 +11,25 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"golang.org/x/time/rate"
 )
 
 const (
-	apiBaseURL            = "https://data.sec.gov"
-	archivesBaseURL       = "https://www.sec.gov/Archives"
-	companyFactsURI       = "/api/xbrl/companyfacts/CIK%010d.json"
-	companyTickersJsonURL = "https://www.sec.gov/files/company_tickers.json"
-	indexJsonName         = "index.json"
+	apiBaseURL                    = "https://data.sec.gov"
+	archivesBaseURL               = "https://www.sec.gov/Archives"
+	companyFactsURI               = "/api/xbrl/companyfacts/CIK%010d.json"
+	companyTickersJsonURL         = "https://www.sec.gov/files/company_tickers.json"
+	companyTickersExchangeJsonURL = "https://www.sec.gov/files/company_tickers_exchange.json"
+	indexJsonName                 = "index.json"
 
 	// Default access rate for EDGAR, see
 	// https://www.sec.gov/os/webmaster-faq#code-support
@@ -34,6 +38,10 @@ const (
 	limitRate = 10
 
 	httpTimeout = 30 // seconds
+
+	// Default max size of response body in GetJSON. Real company facts files
+	// are much smaller, like 5MB for Apple.
+	maxResponseSize = 100 << 20
 )
 
 // Doer performs HTTP requests.
@@ -46,7 +54,7 @@ type HttpRequestDoer interface {
 type Limiter interface{ Wait(context.Context) error }
 
 func New(opts ...ClientOption) *Client {
-	c := &Client{apiBaseURL: apiBaseURL}
+	c := &Client{apiBaseURL: apiBaseURL, maxResponseSize: maxResponseSize}
 	return c.applyOptions(opts...)
 }
 
@@ -56,17 +64,107 @@ func WithHttpClient(client HttpRequestDoer) ClientOption {
 	return func(c *Client) { c.client = client }
 }
 
+// WithTransport sets transport of default http.Client, like http.Transport
+// with custom MaxIdleConns, MaxConnsPerHost or HTTP/2 settings, for reusing
+// connections between requests. It's ignored if WithHttpClient is used, because
+// WithHttpClient takes priority.
+func WithTransport(t http.RoundTripper) ClientOption {
+	return func(c *Client) { c.transport = t }
+}
+
 func WithRateLimiter(l Limiter) ClientOption {
 	return func(c *Client) { c.limiter = l }
 }
 
+func WithMaxResponseSize(bytes int64) ClientOption {
+	return func(c *Client) { c.maxResponseSize = bytes }
+}
+
+func WithLogger(l *slog.Logger) ClientOption {
+	return func(c *Client) { c.logger = l }
+}
+
+// WithBaseURLs sets base URLs of API and archives in one call. Empty string
+// keeps default base URL.
+func WithBaseURLs(apiURL, archivesURL string) ClientOption {
+	return func(c *Client) {
+		if apiURL != "" {
+			c.apiBaseURL = apiURL
+		}
+		if archivesURL != "" {
+			c.archrivesBaseUrl = archivesURL
+		}
+	}
+}
+
+// WithAuthHeader sets Authorization header of every request to scheme and token,
+// like "Bearer <token>".
+func WithAuthHeader(scheme, token string) ClientOption {
+	return func(c *Client) { c.auth = scheme + " " + token }
+}
+
+// WithDefaultHeaders adds headers to every request. Names of headers are
+// canonicalized by http.CanonicalHeaderKey and multiple calls accumulate
+// headers. User-Agent header can't be overridden by it.
+func WithDefaultHeaders(headers map[string]string) ClientOption {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(map[string]string, len(headers))
+		}
+		for name, value := range headers {
+			c.headers[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+}
+
+// WithBearerToken is a shortcut for WithAuthHeader("Bearer", token).
+func WithBearerToken(token string) ClientOption {
+	return WithAuthHeader("Bearer", token)
+}
+
+// ErrInvalidUserAgent returned by ValidateUserAgent.
+var ErrInvalidUserAgent = errors.New("invalid User-Agent")
+
+// ValidateUserAgent checks ua looks like User-Agent required by SEC, like
+// "Company Name admin@company.com". It's not empty and contains contact email,
+// or at least "@" of it.
+func ValidateUserAgent(ua string) error {
+	if strings.TrimSpace(ua) == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidUserAgent)
+	} else if !strings.Contains(ua, "@") {
+		return fmt.Errorf("%w %q: no contact email", ErrInvalidUserAgent, ua)
+	}
+	return nil
+}
+
+// WithValidatedUserAgent is like WithUserAgent, but it validates ua using
+// ValidateUserAgent first.
+func WithValidatedUserAgent(ua string) (ClientOption, error) {
+	if err := ValidateUserAgent(ua); err != nil {
+		return nil, err
+	}
+	return func(c *Client) { c.ua = ua }, nil
+}
+
 type Client struct {
-	client  HttpRequestDoer
-	limiter Limiter
-	ua      string
+	client          HttpRequestDoer
+	transport       http.RoundTripper
+	limiter         Limiter
+	logger          *slog.Logger
+	ua              string
+	auth            string
+	headers         map[string]string
+	maxResponseSize int64
+	breaker         *circuitBreaker
+	responseHooks   []func(resp *http.Response)
+	requestID       func() string
+
+	requestCallbacks  []func()
+	statusErrorMapper func(resp *http.Response) error
 
 	apiBaseURL       string
 	archrivesBaseUrl string
+	searchBaseURL    string
 }
 
 func (self *Client) applyOptions(opts ...ClientOption) *Client {
@@ -75,7 +173,10 @@ func (self *Client) applyOptions(opts ...ClientOption) *Client {
 	}
 
 	if self.client == nil {
-		self.client = &http.Client{Timeout: httpTimeout * time.Second}
+		self.client = &http.Client{
+			Transport: self.transport,
+			Timeout:   httpTimeout * time.Second,
+		}
 	}
 
 	if self.limiter == nil {
@@ -85,6 +186,13 @@ func (self *Client) applyOptions(opts ...ClientOption) *Client {
 	return self
 }
 
+func (self *Client) log() *slog.Logger {
+	if self.logger == nil {
+		return slog.Default()
+	}
+	return self.logger
+}
+
 func (self *Client) WithApiBaseURL(u string) *Client {
 	self.apiBaseURL = u
 	return self
@@ -108,24 +216,66 @@ func (self *Client) WithUserAgent(ua string) *Client {
 }
 
 func (self *Client) Get(ctx context.Context, url string) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return self.do(ctx, http.MethodGet, url)
+}
+
+func (self *Client) Head(ctx context.Context, url string) (*http.Response, error) {
+	return self.do(ctx, http.MethodHead, url)
+}
+
+func (self *Client) do(ctx context.Context, method, url string,
+) (*http.Response, error) {
+	defer self.countRequest()
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("create new GET request for %q: %w", url, err)
+		return nil, fmt.Errorf("create new %v request for %q: %w", method, url, err)
 	}
 	req.Header.Add("User-Agent", self.ua)
+	for name, value := range self.headers {
+		if name != "User-Agent" {
+			req.Header.Set(name, value)
+		}
+	}
+	if self.auth != "" {
+		req.Header.Set("Authorization", self.auth)
+	}
+	self.setRequestID(req)
+
+	if self.breaker != nil {
+		if err := self.breaker.Allow(); err != nil {
+			return nil, fmt.Errorf("%v %s: %w", method, url, err)
+		}
+	}
 
 	if err := self.limitRate(ctx); err != nil {
-		return nil, fmt.Errorf("rate limit GET %s: %w", url, err)
+		if self.breaker != nil {
+			self.breaker.Release()
+		}
+		return nil, fmt.Errorf("rate limit %v %s: %w", method, url, err)
 	}
 
 	resp, err := self.client.Do(req)
+	self.breakerDone(ctx, resp, err)
 	if err != nil {
-		return nil, fmt.Errorf("GET %s: %w", url, err)
+		return nil, fmt.Errorf("%v %s: %w", method, url, err)
 	}
 
+	self.callResponseHooks(req, resp)
 	return resp, nil
 }
 
+func (self *Client) breakerDone(ctx context.Context, resp *http.Response,
+	err error,
+) {
+	switch {
+	case self.breaker == nil:
+	case err != nil && ctx.Err() != nil:
+		self.breaker.Release()
+	default:
+		self.breaker.Done(succeeded(resp, err))
+	}
+}
+
 func (self *Client) limitRate(ctx context.Context) error {
 	if self.limiter != nil {
 		if err := self.limiter.Wait(ctx); err != nil {
@@ -141,18 +291,29 @@ func (self *Client) GetJSON(ctx context.Context, url string, value any) error {
 		return err
 	}
 	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if resp.StatusCode > maxExpectedStatusCode {
-		return fmt.Errorf("GET %s: %w", url, newUnexpectedStatusError(resp))
-	}
-	if err != nil {
-		return fmt.Errorf("read body from GET %s: %w", url, err)
-	}
 
-	if err := json.Unmarshal(body, value); err != nil {
-		return fmt.Errorf("unmarshal GET %s: %w", url, err)
+	if resp.StatusCode > MaxExpectedStatusCode {
+		return fmt.Errorf("GET %s: %w", url, self.newUnexpectedStatusError(resp))
+	} else if err := self.decodeJSON(resp.Body, value); err != nil {
+		return fmt.Errorf("decode GET %s: %w", url, err)
 	}
+	return nil
+}
 
+// decodeJSON decodes value from r by json.Decoder. It stops reading after
+// maxResponseSize bytes, so too big value fails with error wrapped
+// io.ErrUnexpectedEOF. Empty r fails with ErrEmptyResponse.
+func (self *Client) decodeJSON(r io.Reader, value any) error {
+	lr := &io.LimitedReader{R: r, N: self.maxResponseSize}
+	if err := json.NewDecoder(lr).Decode(value); err != nil {
+		if lr.N <= 0 {
+			return fmt.Errorf("response body exceeds size limit of %v bytes: %w",
+				self.maxResponseSize, io.ErrUnexpectedEOF)
+		} else if errors.Is(err, io.EOF) {
+			return ErrEmptyResponse
+		}
+		return err //nolint:wrapcheck // wrapped by caller
+	}
 	return nil
 }
 
@@ -166,6 +327,22 @@ func (self *Client) IndexArchive(ctx context.Context, path string,
 	return
 }
 
+// IndexArchiveNames is like IndexArchive, but returns items with Name and
+// Type only.
+func (self *Client) IndexArchiveNames(ctx context.Context, path string,
+) ([]ArchiveItem, error) {
+	url, err := self.indexJsonURL(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var index archiveIndexNames
+	if err := self.GetJSON(ctx, url, &index); err != nil {
+		return nil, err
+	}
+	return index.Items(), nil
+}
+
 func (self *Client) indexJsonURL(path string) (string, error) {
 	url, err := url.JoinPath(self.ArchivesBaseURL(), path, indexJsonName)
 	if err != nil {
@@ -183,6 +360,28 @@ func (self *Client) GetArchiveFile(ctx context.Context, path string,
 	return self.Get(ctx, url)
 }
 
+// GetArchiveFileBytes fetches file from EDGAR archives and returns its
+// content. It reads whole file into memory, so don't use it for files larger
+// than a few hundred MB, use GetArchiveFile instead.
+func (self *Client) GetArchiveFileBytes(ctx context.Context, path string,
+) ([]byte, error) {
+	resp, err := self.GetArchiveFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > MaxExpectedStatusCode {
+		return nil, fmt.Errorf("GET %s: %w", path, self.newUnexpectedStatusError(resp))
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read body of %s: %w", path, err)
+	}
+	return b, nil
+}
+
 func (self *Client) CompanyTickers(ctx context.Context) ([]CompanyTicker, error) {
 	var tickersMap companyTickers
 	if err := self.GetJSON(ctx, companyTickersJsonURL, &tickersMap); err != nil {
@@ -191,11 +390,27 @@ func (self *Client) CompanyTickers(ctx context.Context) ([]CompanyTicker, error)
 
 	allTickers := make([]CompanyTicker, 0, len(tickersMap))
 	for _, v := range tickersMap {
+		if err := v.Validate(); err != nil {
+			self.log().LogAttrs(ctx, slog.LevelWarn, "skip invalid company ticker",
+				slog.Any("cause", err), slog.Uint64("CIK", uint64(v.CIK)),
+				slog.String("ticker", v.Ticker), slog.String("title", v.Title))
+			continue
+		}
 		allTickers = append(allTickers, v)
 	}
 	return allTickers, nil
 }
 
+func (self *Client) CompanyTickersExchange(ctx context.Context,
+) ([]CompanyTickerExchange, error) {
+	var tickers companyTickersExchange
+	err := self.GetJSON(ctx, companyTickersExchangeJsonURL, &tickers)
+	if err != nil {
+		return nil, err
+	}
+	return tickers.Tickers()
+}
+
 func (self *Client) CompanyFacts(ctx context.Context, cik uint32,
 ) (facts CompanyFacts, err error) {
 	jsonName := fmt.Sprintf(companyFactsURI, cik)
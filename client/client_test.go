@@ -1,11 +1,13 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -41,18 +43,188 @@ func TestNew_WithHttpClient(t *testing.T) {
 	assert.Same(t, client, c.client)
 }
 
+func TestNew_WithTransport(t *testing.T) {
+	transport := &http.Transport{MaxIdleConns: 10, MaxConnsPerHost: 10}
+	c := testNew(t, WithTransport(transport))
+	require.IsType(t, new(http.Client), c.client)
+	assert.Same(t, transport, c.client.(*http.Client).Transport)
+
+	client := &http.Client{}
+	c = testNew(t, WithTransport(transport), WithHttpClient(client))
+	assert.Same(t, client, c.client, "WithHttpClient takes priority")
+	assert.Nil(t, client.Transport)
+
+	c = testNew(t)
+	require.IsType(t, new(http.Client), c.client)
+	assert.Nil(t, c.client.(*http.Client).Transport)
+}
+
 func TestNew_WithRateLimiter(t *testing.T) {
 	l := rate.NewLimiter(limitRate, limitRate)
 	c := testNew(t, WithRateLimiter(l))
 	assert.Same(t, l, c.limiter)
 }
 
+func TestNew_WithLogger(t *testing.T) {
+	c := testNew(t)
+	assert.Same(t, slog.Default(), c.log())
+
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	c = testNew(t, WithLogger(l))
+	assert.Same(t, l, c.log())
+}
+
+func TestNew_WithMaxResponseSize(t *testing.T) {
+	c := testNew(t)
+	assert.Equal(t, int64(maxResponseSize), c.maxResponseSize)
+
+	c = testNew(t, WithMaxResponseSize(1024))
+	assert.Equal(t, int64(1024), c.maxResponseSize)
+}
+
+func TestNew_WithBaseURLs(t *testing.T) {
+	c := testNew(t, WithBaseURLs("", ""))
+	assert.Equal(t, apiBaseURL, c.apiBaseURL)
+	assert.Equal(t, archivesBaseURL, c.ArchivesBaseURL())
+
+	c = testNew(t, WithBaseURLs("http://api.localhost", ""))
+	assert.Equal(t, "http://api.localhost", c.apiBaseURL)
+	assert.Equal(t, archivesBaseURL, c.ArchivesBaseURL())
+
+	c = testNew(t, WithBaseURLs("", "http://archives.localhost"))
+	assert.Equal(t, apiBaseURL, c.apiBaseURL)
+	assert.Equal(t, "http://archives.localhost", c.ArchivesBaseURL())
+
+	c = testNew(t,
+		WithBaseURLs("http://api.localhost", "http://archives.localhost"))
+	assert.Equal(t, "http://api.localhost", c.apiBaseURL)
+	assert.Equal(t, "http://archives.localhost", c.ArchivesBaseURL())
+}
+
+func TestValidateUserAgent(t *testing.T) {
+	tests := []struct {
+		name    string
+		ua      string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			ua:   "Acme admin@acme.com",
+		},
+		{
+			name:    "missing @",
+			ua:      "Acme admin",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+		{
+			name:    "spaces",
+			ua:      "  ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUserAgent(tt.ua)
+			opt, optErr := WithValidatedUserAgent(tt.ua)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidUserAgent)
+				require.ErrorIs(t, optErr, ErrInvalidUserAgent)
+				assert.Nil(t, opt)
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, optErr)
+				assert.Equal(t, tt.ua, testNew(t, opt).ua)
+			}
+		})
+	}
+}
+
 func TestClient_WithUserAgent(t *testing.T) {
 	c := testNew(t)
 	assert.Same(t, c, c.WithUserAgent("foobar"))
 	assert.Equal(t, "foobar", c.ua)
 }
 
+func TestClient_WithAuthHeader(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		opts []ClientOption
+		want string
+	}{
+		{
+			name: "without auth",
+		},
+		{
+			name: "WithAuthHeader",
+			opts: []ClientOption{WithAuthHeader("ApiKey", "secret")},
+			want: "ApiKey secret",
+		},
+		{
+			name: "WithBearerToken",
+			opts: []ClientOption{WithBearerToken("secret")},
+			want: "Bearer secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient := client.NewMockHttpRequestDoer(t)
+			c := testNew(t, append(tt.opts, WithHttpClient(httpClient),
+				WithRateLimiter(nil))...)
+			httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+				func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, tt.want, req.Header.Get("Authorization"))
+					return httptest.NewRecorder().Result(), nil
+				}).Twice()
+
+			resp, err := c.Get(ctx, "https://localhost")
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			resp, err = c.Head(ctx, "https://localhost")
+			require.NoError(t, err)
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestClient_WithDefaultHeaders(t *testing.T) {
+	const ua = "Acme admin@acme.com"
+	httpClient := client.NewMockHttpRequestDoer(t)
+	c := New(WithHttpClient(httpClient), WithRateLimiter(nil),
+		WithDefaultHeaders(map[string]string{
+			"x-proxy-tenant": "acme",
+			"user-agent":     "proxy",
+		}),
+		WithDefaultHeaders(map[string]string{"X-Trace": "42"})).
+		WithUserAgent(ua)
+
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, []string{ua}, req.Header.Values("User-Agent"))
+			assert.Equal(t, "acme", req.Header.Get("X-Proxy-Tenant"))
+			assert.Equal(t, "42", req.Header.Get("X-Trace"))
+			return httptest.NewRecorder().Result(), nil
+		}).Twice()
+
+	for range 2 {
+		resp, err := c.Get(context.Background(), "https://localhost")
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, map[string]string{
+		"X-Proxy-Tenant": "acme",
+		"User-Agent":     "proxy",
+		"X-Trace":        "42",
+	}, c.headers)
+}
+
 func TestClient_Get(t *testing.T) {
 	const ua = "Acme admin@acme.com"
 	const url = "https://localhost"
@@ -155,6 +327,36 @@ func TestClient_Get(t *testing.T) {
 	}
 }
 
+func TestClient_Head(t *testing.T) {
+	const ua = "Acme admin@acme.com"
+	const url = "https://localhost"
+	ctx := context.Background()
+
+	httpClient := client.NewMockHttpRequestDoer(t)
+	limiter := client.NewMockLimiter(t)
+	c := testNew(t, WithHttpClient(httpClient), WithRateLimiter(limiter)).
+		WithUserAgent(ua)
+
+	limiter.EXPECT().Wait(ctx).Return(nil).Once()
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, http.MethodHead, req.Method)
+			assert.Equal(t, url, req.URL.String())
+			assert.Equal(t, ua, req.Header.Get("User-Agent"))
+			return httptest.NewRecorder().Result(), nil
+		}).Once()
+
+	resp, err := c.Head(ctx, url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	testErr := errors.New("test error")
+	limiter.EXPECT().Wait(ctx).Return(testErr).Once()
+	_, err = c.Head(ctx, url)
+	require.ErrorIs(t, err, testErr)
+}
+
 func TestClient_GetJSON(t *testing.T) {
 	const testJson = `{
   "directory": {
@@ -201,6 +403,16 @@ func TestClient_GetJSON(t *testing.T) {
 			json:    "{ foo: bar }",
 			wantErr: true,
 		},
+		{
+			name: "empty body",
+			mockDo: func(req *http.Request) (*http.Response, error) {
+				return httptest.NewRecorder().Result(), nil
+			},
+			assertError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrEmptyResponse)
+				require.ErrorContains(t, err, "https://localhost")
+			},
+		},
 		{
 			name: "Read error",
 			mockDo: func(req *http.Request) (*http.Response, error) {
@@ -212,6 +424,23 @@ func TestClient_GetJSON(t *testing.T) {
 			},
 			errorIs: testErr,
 		},
+		{
+			name: "size limit",
+			mockDo: func(req *http.Request) (*http.Response, error) {
+				resp := httptest.NewRecorder().Result()
+				reader := mocksIo.NewMockReader(t)
+				reader.EXPECT().Read(mock.Anything).RunAndReturn(
+					func(p []byte) (int, error) {
+						for i := range p {
+							p[i] = ' '
+						}
+						return len(p), nil
+					})
+				resp.Body = io.NopCloser(reader)
+				return resp, nil
+			},
+			errorIs: io.ErrUnexpectedEOF,
+		},
 	}
 
 	for _, tt := range tests {
@@ -229,7 +458,7 @@ func TestClient_GetJSON(t *testing.T) {
 					})
 			}
 
-			c := testNew(t, WithHttpClient(httpClient))
+			c := testNew(t, WithHttpClient(httpClient), WithMaxResponseSize(1024))
 			var wantIndex ArchiveIndex
 			wantIndex.Directory.Name = "foobar"
 			var gotIndex ArchiveIndex
@@ -250,6 +479,148 @@ func TestClient_GetJSON(t *testing.T) {
 	}
 }
 
+func TestDefaultStatusErrorMapper(t *testing.T) {
+	tests := []struct {
+		status  int
+		errorIs error
+	}{
+		{status: http.StatusNotFound, errorIs: ErrNotFound},
+		{status: http.StatusTooManyRequests, errorIs: ErrRateLimited},
+		{status: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			recorder.WriteHeader(tt.status)
+			err := DefaultStatusErrorMapper(recorder.Result())
+			require.ErrorIs(t, err, ErrUnexpectedStatus)
+			var statusErr *UnexpectedStatusError
+			require.ErrorAs(t, err, &statusErr)
+			assert.Equal(t, tt.status, statusErr.StatusCode())
+
+			for _, target := range [...]error{ErrNotFound, ErrRateLimited} {
+				if target == tt.errorIs {
+					require.ErrorIs(t, err, target)
+				} else {
+					require.NotErrorIs(t, err, target)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_WithStatusErrorMapper(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+	httpClient := client.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			recorder := httptest.NewRecorder()
+			recorder.WriteHeader(http.StatusTooManyRequests)
+			return recorder.Result(), nil
+		})
+
+	c := New(WithHttpClient(httpClient), WithRateLimiter(nil))
+	var value any
+	err := c.GetJSON(ctx, "https://localhost/foo.json", &value)
+	require.ErrorIs(t, err, ErrRateLimited)
+	_, err = c.GetArchiveFileBytes(ctx, "foo.txt")
+	require.ErrorIs(t, err, ErrRateLimited)
+
+	var gotStatus int
+	c = New(WithHttpClient(httpClient), WithRateLimiter(nil),
+		WithStatusErrorMapper(func(resp *http.Response) error {
+			gotStatus = resp.StatusCode
+			return wantErr
+		}))
+	err = c.GetJSON(ctx, "https://localhost/foo.json", &value)
+	require.ErrorIs(t, err, wantErr)
+	require.NotErrorIs(t, err, ErrUnexpectedStatus)
+	assert.Equal(t, http.StatusTooManyRequests, gotStatus)
+	_, err = c.GetArchiveFileBytes(ctx, "foo.txt")
+	require.ErrorIs(t, err, wantErr)
+}
+
+func TestClient_decodeJSON(t *testing.T) {
+	facts := benchCompanyFacts(10, 10)
+	b, err := json.Marshal(&facts)
+	require.NoError(t, err)
+
+	c := testNew(t, WithMaxResponseSize(int64(len(b))))
+	var gotFacts CompanyFacts
+	require.NoError(t, c.decodeJSON(bytes.NewReader(b), &gotFacts))
+	assert.Equal(t, facts, gotFacts)
+
+	c = testNew(t, WithMaxResponseSize(int64(len(b)-1)))
+	require.ErrorIs(t, c.decodeJSON(bytes.NewReader(b), &gotFacts),
+		io.ErrUnexpectedEOF)
+
+	require.ErrorIs(t, c.decodeJSON(bytes.NewReader(nil), &gotFacts),
+		ErrEmptyResponse)
+	require.ErrorIs(t, c.decodeJSON(bytes.NewReader([]byte(" \n")), &gotFacts),
+		ErrEmptyResponse)
+}
+
+func benchCompanyFacts(numFacts, numUnits int) CompanyFacts {
+	facts := CompanyFacts{
+		CIK:        appleCIK,
+		EntityName: "Apple Inc.",
+		Facts:      map[string]map[string]CompanyFact{"us-gaap": {}},
+	}
+	for i := range numFacts {
+		units := make([]FactUnit, numUnits)
+		for j := range units {
+			units[j] = FactUnit{
+				End:   "2008-09-27",
+				Val:   float64(j),
+				Accn:  "0001193125-09-153165",
+				FY:    2009,
+				FP:    "Q3",
+				Form:  "10-Q",
+				Filed: "2009-07-22",
+			}
+		}
+		facts.Facts["us-gaap"][fmt.Sprintf("Fact%v", i)] = CompanyFact{
+			Label: "Label", Description: "Description",
+			Units: map[string][]FactUnit{"USD": units},
+		}
+	}
+	return facts
+}
+
+func BenchmarkClient_GetJSON(b *testing.B) {
+	facts := benchCompanyFacts(1000, 50)
+	body, err := json.Marshal(&facts)
+	require.NoError(b, err)
+	c := New()
+	b.Logf("JSON size: %v bytes", len(body))
+
+	b.Run("ReadAll", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			var v CompanyFacts
+			r := &io.LimitedReader{R: bytes.NewReader(body), N: c.maxResponseSize + 1}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				panic(err)
+			} else if err := json.Unmarshal(data, &v); err != nil {
+				panic(err)
+			}
+		}
+	})
+
+	b.Run("Decoder", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			var v CompanyFacts
+			if err := c.decodeJSON(bytes.NewReader(body), &v); err != nil {
+				panic(err)
+			}
+		}
+	})
+}
+
 func TestClient_indexJsonURL(t *testing.T) {
 	c := testNew(t)
 	url, err := c.indexJsonURL("full-index")
@@ -294,6 +665,47 @@ func TestClient_IndexArchive(t *testing.T) {
 	assert.Equal(t, &emptyIndex, &gotIndex)
 }
 
+func TestClient_IndexArchiveNames(t *testing.T) {
+	const testPath = "full-index"
+
+	fakeIndex := fakeArchiveIndex()
+	jsonIndex, err := json.Marshal(&fakeIndex)
+	require.NoError(t, err)
+
+	httpClient := client.NewMockHttpRequestDoer(t)
+	c := testNew(t, WithHttpClient(httpClient))
+
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			wantUrl, err := c.indexJsonURL(testPath)
+			require.NoError(t, err)
+			assert.Equal(t, wantUrl, req.URL.String())
+			recorder := httptest.NewRecorder()
+			_, err = recorder.Write(jsonIndex)
+			require.NoError(t, err)
+			return recorder.Result(), nil
+		}).Once()
+
+	gotItems, err := c.IndexArchiveNames(context.Background(), testPath)
+	require.NoError(t, err)
+	wantItems := make([]ArchiveItem, len(fakeIndex.Items()))
+	for i, item := range fakeIndex.Items() {
+		wantItems[i] = ArchiveItem{Name: item.Name, Type: item.Type}
+	}
+	assert.Equal(t, wantItems, gotItems)
+
+	testErr := errors.New("test error")
+	httpClient.EXPECT().Do(mock.Anything).Return(nil, testErr).Once()
+	gotItems, err = c.IndexArchiveNames(context.Background(), testPath)
+	require.ErrorIs(t, err, testErr)
+	assert.Nil(t, gotItems)
+
+	gotItems, err = c.WithArchivesBaseURL(":localhost").
+		IndexArchiveNames(context.Background(), testPath)
+	require.Error(t, err)
+	assert.Nil(t, gotItems)
+}
+
 func TestClient_GetArchiveFile_ok(t *testing.T) {
 	httpClient := client.NewMockHttpRequestDoer(t)
 	c := testNew(t, WithHttpClient(httpClient))
@@ -318,18 +730,101 @@ func TestClient_GetArchiveFile_ok(t *testing.T) {
 
 func TestClient_GetArchiveFile_error(t *testing.T) {
 	httpClient := client.NewMockHttpRequestDoer(t)
-	c := testNew(t, WithHttpClient(httpClient)).WithArchivesBaseURL(":localhost")
+	c := testNew(t, WithHttpClient(httpClient), WithBaseURLs("", ":localhost"))
 	_, err := c.GetArchiveFile(context.Background(), "")
 	require.Error(t, err)
 }
 
+func TestClient_GetArchiveFileBytes(t *testing.T) {
+	testErr := errors.New("test error")
+
+	tests := []struct {
+		name        string
+		mockDo      func(req *http.Request) (*http.Response, error)
+		baseURL     string
+		wantErr     bool
+		errorIs     error
+		wantContent []byte
+	}{
+		{
+			name: "ok",
+			mockDo: func(req *http.Request) (*http.Response, error) {
+				recorder := httptest.NewRecorder()
+				_, err := recorder.WriteString("foobar")
+				require.NoError(t, err)
+				return recorder.Result(), nil
+			},
+			wantContent: []byte("foobar"),
+		},
+		{
+			name:    "JoinPath error",
+			baseURL: ":localhost",
+			wantErr: true,
+		},
+		{
+			name: "Do error",
+			mockDo: func(req *http.Request) (*http.Response, error) {
+				return nil, testErr
+			},
+			errorIs: testErr,
+		},
+		{
+			name: "unexpected StatusCode",
+			mockDo: func(req *http.Request) (*http.Response, error) {
+				recorder := httptest.NewRecorder()
+				recorder.WriteHeader(http.StatusNotFound)
+				return recorder.Result(), nil
+			},
+			errorIs: ErrUnexpectedStatus,
+		},
+		{
+			name: "Read error",
+			mockDo: func(req *http.Request) (*http.Response, error) {
+				resp := httptest.NewRecorder().Result()
+				reader := mocksIo.NewMockReader(t)
+				reader.EXPECT().Read(mock.Anything).Return(0, testErr)
+				resp.Body = io.NopCloser(reader)
+				return resp, nil
+			},
+			errorIs: testErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient := client.NewMockHttpRequestDoer(t)
+			if tt.mockDo != nil {
+				httpClient.EXPECT().Do(mock.Anything).RunAndReturn(tt.mockDo)
+			}
+			c := testNew(t, WithHttpClient(httpClient))
+			if tt.baseURL != "" {
+				c.WithArchivesBaseURL(tt.baseURL)
+			}
+
+			content, err := c.GetArchiveFileBytes(context.Background(), "foobar.txt")
+			switch {
+			case tt.errorIs != nil:
+				require.ErrorIs(t, err, tt.errorIs)
+			case tt.wantErr:
+				require.Error(t, err)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantContent, content)
+			}
+		})
+	}
+}
+
 func TestClient_CompanyTickers(t *testing.T) {
 	appleTicker := CompanyTicker{
 		CIK:    320193,
 		Ticker: "AAPL",
 		Title:  "Apple Inc.",
 	}
-	tickersBytes, err := json.Marshal(companyTickers{"0": appleTicker})
+	tickersBytes, err := json.Marshal(companyTickers{
+		"0": appleTicker,
+		"1": {Ticker: "FOO", Title: "Zero CIK Inc."},
+	})
 	require.NoError(t, err)
 
 	httpClient := client.NewMockHttpRequestDoer(t)
@@ -406,7 +901,7 @@ func TestClient_CompanyFacts(t *testing.T) {
 
 func TestClient_CompanyFacts_error(t *testing.T) {
 	httpClient := client.NewMockHttpRequestDoer(t)
-	c := testNew(t, WithHttpClient(httpClient)).WithApiBaseURL(":localhost")
+	c := testNew(t, WithHttpClient(httpClient), WithBaseURLs(":localhost", ""))
 
 	_, err := c.CompanyFacts(context.Background(), appleCIK)
 	require.Error(t, err)
@@ -441,3 +936,55 @@ func TestClient_CompanyFacts_strCIK(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, wantFacts, gotFacts)
 }
+
+func TestClient_CompanyTickersExchange(t *testing.T) {
+	const tickersJson = `{
+  "fields": ["cik", "name", "ticker", "exchange"],
+  "data": [
+    [320193, "Apple Inc.", "AAPL", "Nasdaq"],
+    [1067983, "BERKSHIRE HATHAWAY INC", "BRK-B", "NYSE"],
+    [1895262, "Noble Corp", "NE", null]
+  ]
+}`
+
+	httpClient := client.NewMockHttpRequestDoer(t)
+	c := testNew(t, WithHttpClient(httpClient))
+
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, companyTickersExchangeJsonURL, req.URL.String())
+			recorder := httptest.NewRecorder()
+			_, err := recorder.WriteString(tickersJson)
+			require.NoError(t, err)
+			return recorder.Result(), nil
+		}).Once()
+
+	gotTickers, err := c.CompanyTickersExchange(context.Background())
+	require.NoError(t, err)
+	wantTickers := []CompanyTickerExchange{
+		{
+			CompanyTicker: CompanyTicker{
+				CIK: appleCIK, Ticker: "AAPL", Title: "Apple Inc.",
+			},
+			Exchange: "Nasdaq",
+		},
+		{
+			CompanyTicker: CompanyTicker{
+				CIK: 1067983, Ticker: "BRK-B", Title: "BERKSHIRE HATHAWAY INC",
+			},
+			Exchange: "NYSE",
+		},
+		{
+			CompanyTicker: CompanyTicker{
+				CIK: 1895262, Ticker: "NE", Title: "Noble Corp",
+			},
+		},
+	}
+	assert.Equal(t, wantTickers, gotTickers)
+
+	testErr := errors.New("test error")
+	httpClient.EXPECT().Do(mock.Anything).Return(nil, testErr).Once()
+	gotTickers, err = c.CompanyTickersExchange(context.Background())
+	require.ErrorIs(t, err, testErr)
+	assert.Nil(t, gotTickers)
+}
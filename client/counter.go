@@ -0,0 +1,61 @@
+package client
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithRequestCounter atomically increments counter after every request made by
+// Client, successful or not.
+func WithRequestCounter(counter *atomic.Int64) ClientOption {
+	return withRequestCallback(func() { counter.Add(1) })
+}
+
+// WithWindowedRequestCounter calls fn after every request made by Client,
+// successful or not, with number of requests made during last window,
+// including this one.
+func WithWindowedRequestCounter(window time.Duration, fn func(n int64),
+) ClientOption {
+	counter := newWindowCounter(window)
+	return withRequestCallback(func() { fn(counter.Add()) })
+}
+
+func withRequestCallback(fn func()) ClientOption {
+	return func(c *Client) { c.requestCallbacks = append(c.requestCallbacks, fn) }
+}
+
+func (self *Client) countRequest() {
+	for _, fn := range self.requestCallbacks {
+		fn()
+	}
+}
+
+func newWindowCounter(window time.Duration) *windowCounter {
+	return &windowCounter{window: window, now: time.Now}
+}
+
+// windowCounter counts events during sliding window.
+type windowCounter struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu     sync.Mutex
+	events []time.Time
+}
+
+// Add registers new event and returns number of events during last window,
+// including this one.
+func (self *windowCounter) Add() int64 {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	now := self.now()
+	since := now.Add(-self.window)
+	var expired int
+	for expired < len(self.events) && !self.events[expired].After(since) {
+		expired++
+	}
+	self.events = append(self.events[expired:], now)
+	return int64(len(self.events))
+}
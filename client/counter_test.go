@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dsh2dsh/edgar/internal/mocks/client"
+)
+
+func TestClient_WithRequestCounter(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+	var counter atomic.Int64
+	var windowed []int64
+
+	httpClient := client.NewMockHttpRequestDoer(t)
+	c := New(WithHttpClient(httpClient), WithRateLimiter(nil),
+		WithRequestCounter(&counter),
+		WithWindowedRequestCounter(time.Hour,
+			func(n int64) { windowed = append(windowed, n) }))
+
+	httpClient.EXPECT().Do(mock.Anything).Return(
+		httptest.NewRecorder().Result(), nil).Once()
+	resp, err := c.Get(ctx, "https://localhost/foo")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int64(1), counter.Load())
+
+	httpClient.EXPECT().Do(mock.Anything).Return(nil, wantErr).Once()
+	_, err = c.Get(ctx, "https://localhost/foo")
+	require.ErrorIs(t, err, wantErr)
+	assert.Equal(t, int64(2), counter.Load())
+
+	_, err = c.Get(ctx, "\x00")
+	require.Error(t, err)
+	assert.Equal(t, int64(3), counter.Load())
+
+	assert.Equal(t, []int64{1, 2, 3}, windowed)
+}
+
+func TestWindowCounter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	counter := newWindowCounter(time.Second)
+	counter.now = func() time.Time { return now }
+
+	assert.Equal(t, int64(1), counter.Add())
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, int64(2), counter.Add())
+	now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, int64(2), counter.Add())
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, int64(1), counter.Add())
+}
+
+func TestNew_WithRequestCounter_head(t *testing.T) {
+	var counter atomic.Int64
+	httpClient := client.NewMockHttpRequestDoer(t)
+	c := New(WithHttpClient(httpClient), WithRateLimiter(nil),
+		WithRequestCounter(&counter))
+
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, http.MethodHead, req.Method)
+			return httptest.NewRecorder().Result(), nil
+		})
+	resp, err := c.Head(context.Background(), "https://localhost/foo")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int64(1), counter.Load())
+}
@@ -1,15 +1,58 @@
 package client
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 )
 
-const maxExpectedStatusCode = 299
+// MaxExpectedStatusCode is max status code of successful response. Any status
+// above it is unexpected, see UnexpectedStatusError.
+const MaxExpectedStatusCode = 299
 
 var ErrUnexpectedStatus = &UnexpectedStatusError{}
 
-func newUnexpectedStatusError(resp *http.Response) error {
+// ErrEmptyResponse returned by GetJSON when response body is empty or contains
+// whitespaces only, which EDGAR returns sometimes with 200 status.
+var ErrEmptyResponse = errors.New("empty response body")
+
+var (
+	// ErrNotFound returned by DefaultStatusErrorMapper for 404 status.
+	ErrNotFound = errors.New("not found")
+
+	// ErrRateLimited returned by DefaultStatusErrorMapper for 429 status.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// WithStatusErrorMapper sets fn, which returns error for response with
+// unexpected status code, instead of DefaultStatusErrorMapper.
+func WithStatusErrorMapper(fn func(resp *http.Response) error) ClientOption {
+	return func(c *Client) { c.statusErrorMapper = fn }
+}
+
+// DefaultStatusErrorMapper returns UnexpectedStatusError for resp, which also
+// wraps ErrNotFound for 404 status and ErrRateLimited for 429 status.
+func DefaultStatusErrorMapper(resp *http.Response) error {
+	err := NewUnexpectedStatusError(resp)
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+	return err
+}
+
+func (self *Client) newUnexpectedStatusError(resp *http.Response) error {
+	if self.statusErrorMapper == nil {
+		return DefaultStatusErrorMapper(resp)
+	}
+	return self.statusErrorMapper(resp)
+}
+
+// NewUnexpectedStatusError returns UnexpectedStatusError with status of resp,
+// for callers, which check status of responses themselves.
+func NewUnexpectedStatusError(resp *http.Response) *UnexpectedStatusError {
 	return &UnexpectedStatusError{
 		httpStatus:     resp.Status,
 		httpStatusCode: resp.StatusCode,
@@ -23,7 +66,7 @@ type UnexpectedStatusError struct {
 
 func (self *UnexpectedStatusError) Error() string {
 	return fmt.Sprintf("unexpected status code (>%v): %v",
-		maxExpectedStatusCode, self.Status())
+		MaxExpectedStatusCode, self.Status())
 }
 
 func (self *UnexpectedStatusError) Is(target error) bool {
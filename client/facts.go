@@ -1,9 +1,13 @@
 package client
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,6 +21,110 @@ func (self *CompanyFacts) Id() uint32 {
 	return uint32(self.CIK)
 }
 
+func (self *CompanyFacts) FactsByTaxonomy(taxonomy string,
+) (facts map[string]CompanyFact, ok bool) {
+	facts, ok = self.Facts[taxonomy]
+	return
+}
+
+func (self *CompanyFacts) Taxonomies() []string {
+	taxonomies := make([]string, 0, len(self.Facts))
+	for tax := range self.Facts {
+		taxonomies = append(taxonomies, tax)
+	}
+	slices.Sort(taxonomies)
+	return taxonomies
+}
+
+// FactCount returns number of facts across all taxonomies.
+func (self *CompanyFacts) FactCount() (n int) {
+	for _, facts := range self.Facts {
+		n += len(facts)
+	}
+	return
+}
+
+// FactUnitCount returns number of FactUnit across all facts and their units.
+func (self *CompanyFacts) FactUnitCount() (n int) {
+	for _, facts := range self.Facts {
+		for _, fact := range facts {
+			for _, units := range fact.Units {
+				n += len(units)
+			}
+		}
+	}
+	return
+}
+
+// FactUnitsForConcept returns all fact units of concept from taxonomy, reported
+// in unit, like "USD". It returns false, if any of them doesn't exist.
+func (self *CompanyFacts) FactUnitsForConcept(taxonomy, concept, unit string,
+) ([]FactUnit, bool) {
+	fact, ok := self.Facts[taxonomy][concept]
+	if !ok {
+		return nil, false
+	}
+	units, ok := fact.Units[unit]
+	return units, ok
+}
+
+// FactUnit is like FactUnitsForConcept, but returns first fact unit with
+// accession number accn. It returns false, if there is no such fact unit.
+func (self *CompanyFacts) FactUnit(taxonomy, concept, unit, accn string,
+) (FactUnit, bool) {
+	units, _ := self.FactUnitsForConcept(taxonomy, concept, unit)
+	i := slices.IndexFunc(units, func(u FactUnit) bool { return u.Accn == accn })
+	if i < 0 {
+		return FactUnit{}, false
+	}
+	return units[i], true
+}
+
+// ErrCIKMismatch returned by TryMerge, when CIKs of merging company facts are
+// different.
+var ErrCIKMismatch = errors.New("CIK mismatch")
+
+// MergeWith returns new CompanyFacts with union of facts from self and other,
+// like merging results of CompanyFacts and CompanyConcept calls. Facts are
+// merged by taxonomy and name, and fact units of every unit are merged by Accn:
+// all fact units of self are kept, and fact units of other are added only if
+// self has no fact units with the same Accn. Everything else, like CIK or
+// labels, is taken from self, unless it's empty. CIK of self and other must
+// match, see TryMerge, which checks it. self and other are not modified.
+func (self *CompanyFacts) MergeWith(other CompanyFacts) CompanyFacts {
+	merged := CompanyFacts{
+		CIK:        cmp.Or(self.CIK, other.CIK),
+		EntityName: cmp.Or(self.EntityName, other.EntityName),
+		Facts:      make(map[string]map[string]CompanyFact, len(self.Facts)),
+	}
+
+	for _, from := range [...]map[string]map[string]CompanyFact{
+		self.Facts, other.Facts,
+	} {
+		for tax, facts := range from {
+			mergedFacts, ok := merged.Facts[tax]
+			if !ok {
+				mergedFacts = make(map[string]CompanyFact, len(facts))
+				merged.Facts[tax] = mergedFacts
+			}
+			for name, fact := range facts {
+				mergedFacts[name] = mergedFacts[name].mergeWith(&fact)
+			}
+		}
+	}
+	return merged
+}
+
+// TryMerge is like MergeWith, but returns error wrapped ErrCIKMismatch, if CIK
+// of self and other are different.
+func (self *CompanyFacts) TryMerge(other CompanyFacts) (CompanyFacts, error) {
+	if self.CIK != other.CIK {
+		return CompanyFacts{}, fmt.Errorf("merge company facts of CIK=%v and %v: %w",
+			self.CIK, other.CIK, ErrCIKMismatch)
+	}
+	return self.MergeWith(other), nil
+}
+
 type CIK uint32
 
 func (self *CIK) UnmarshalJSON(b []byte) error {
@@ -26,7 +134,7 @@ func (self *CIK) UnmarshalJSON(b []byte) error {
 	}
 
 	if s, ok := value.(string); ok {
-		v, err := strconv.ParseUint(s, 10, 32)
+		v, err := ParseCIK(s)
 		if err != nil {
 			return fmt.Errorf("client.Uint32String: %w", err)
 		}
@@ -43,12 +151,84 @@ func (self *CIK) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// ParseCIK parses CIK both zero-padded, like "0000320193", and plain, like
+// "320193".
+func ParseCIK(s string) (uint32, error) {
+	if s == "" {
+		return 0, errors.New("parse CIK: empty string")
+	}
+
+	digits := strings.TrimLeft(s, "0")
+	if digits == "" {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseUint(digits, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("parse CIK %q: %w", s, err)
+	}
+	return uint32(v), nil
+}
+
+// MustParseCIK is like ParseCIK, but panics on error. Use it in tests only.
+func MustParseCIK(s string) uint32 {
+	cik, err := ParseCIK(s)
+	if err != nil {
+		panic(err)
+	}
+	return cik
+}
+
+// String returns zero-padded 10-digit CIK, as SEC documents use it.
+func (self CIK) String() string {
+	return fmt.Sprintf("%010d", uint32(self))
+}
+
+func (self CIK) MarshalText() ([]byte, error) {
+	return []byte(self.String()), nil
+}
+
 type CompanyFact struct {
 	Label       string                `json:"label"`
 	Description string                `json:"description"`
 	Units       map[string][]FactUnit `json:"units"`
 }
 
+// mergeWith returns copy of self with fact units of other added, see
+// CompanyFacts.MergeWith.
+func (self CompanyFact) mergeWith(other *CompanyFact) CompanyFact {
+	self.Label = cmp.Or(self.Label, other.Label)
+	self.Description = cmp.Or(self.Description, other.Description)
+
+	units := make(map[string][]FactUnit, max(len(self.Units), len(other.Units)))
+	for name, factUnits := range self.Units {
+		units[name] = slices.Clone(factUnits)
+	}
+
+	for name, factUnits := range other.Units {
+		known := make(map[string]struct{}, len(units[name]))
+		for i := range units[name] {
+			known[units[name][i].Accn] = struct{}{}
+		}
+		for i := range factUnits {
+			if _, ok := known[factUnits[i].Accn]; !ok {
+				units[name] = append(units[name], factUnits[i])
+			}
+		}
+	}
+	self.Units = units
+	return self
+}
+
+func (self *CompanyFact) HasUnit(name string) bool {
+	_, ok := self.Units[name]
+	return ok
+}
+
+func (self *CompanyFact) HasFactUnits(name string) bool {
+	return len(self.Units[name]) > 0
+}
+
 type FactUnit struct {
 	Start string  `json:"start"`
 	End   string  `json:"end"`
@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -14,6 +15,242 @@ func TestCompanyFacts_Id(t *testing.T) {
 	assert.Equal(t, uint32(1895262), facts.Id())
 }
 
+func TestCompanyFacts_FactsByTaxonomy(t *testing.T) {
+	usGaap := map[string]CompanyFact{
+		"AccountsPayable": {Label: "Accounts Payable (Deprecated 2009-01-31)"},
+	}
+	facts := CompanyFacts{
+		Facts: map[string]map[string]CompanyFact{
+			"us-gaap": usGaap,
+			"dei":     {},
+		},
+	}
+
+	gotFacts, ok := facts.FactsByTaxonomy("us-gaap")
+	assert.True(t, ok)
+	assert.Equal(t, usGaap, gotFacts)
+
+	gotFacts, ok = facts.FactsByTaxonomy("ifrs-full")
+	assert.False(t, ok)
+	assert.Nil(t, gotFacts)
+
+	assert.Equal(t, []string{"dei", "us-gaap"}, facts.Taxonomies())
+
+	var emptyFacts CompanyFacts
+	_, ok = emptyFacts.FactsByTaxonomy("us-gaap")
+	assert.False(t, ok)
+	assert.Empty(t, emptyFacts.Taxonomies())
+}
+
+func TestCompanyFacts_MergeWith(t *testing.T) {
+	unit := func(accn, end string) FactUnit {
+		return FactUnit{Accn: accn, End: end, Filed: "2024-01-10"}
+	}
+
+	facts := CompanyFacts{
+		CIK:        320193,
+		EntityName: "Apple Inc.",
+		Facts: map[string]map[string]CompanyFact{
+			"us-gaap": {
+				"Assets": {
+					Label: "Assets",
+					Units: map[string][]FactUnit{
+						"USD": {unit("1", "2022-12-31"), unit("1", "2023-12-31")},
+					},
+				},
+			},
+		},
+	}
+	other := CompanyFacts{
+		CIK: 320193,
+		Facts: map[string]map[string]CompanyFact{
+			"us-gaap": {
+				"Assets": {
+					Label:       "Other Assets",
+					Description: "Description",
+					Units: map[string][]FactUnit{
+						"USD": {unit("1", "2023-12-31"), unit("2", "2024-03-31")},
+						"EUR": {unit("3", "2024-03-31")},
+					},
+				},
+				"Liabilities": {Label: "Liabilities"},
+			},
+			"dei": {
+				"EntityPublicFloat": {Label: "Public Float"},
+			},
+		},
+	}
+
+	wantFacts := CompanyFacts{
+		CIK:        320193,
+		EntityName: "Apple Inc.",
+		Facts: map[string]map[string]CompanyFact{
+			"us-gaap": {
+				"Assets": {
+					Label:       "Assets",
+					Description: "Description",
+					Units: map[string][]FactUnit{
+						"USD": {
+							unit("1", "2022-12-31"), unit("1", "2023-12-31"),
+							unit("2", "2024-03-31"),
+						},
+						"EUR": {unit("3", "2024-03-31")},
+					},
+				},
+				"Liabilities": {Label: "Liabilities", Units: map[string][]FactUnit{}},
+			},
+			"dei": {
+				"EntityPublicFloat": {
+					Label: "Public Float", Units: map[string][]FactUnit{},
+				},
+			},
+		},
+	}
+
+	merged := facts.MergeWith(other)
+	assert.Equal(t, wantFacts, merged)
+	assert.Len(t, facts.Facts["us-gaap"], 1, "self modified")
+	assert.Len(t, facts.Facts["us-gaap"]["Assets"].Units["USD"], 2,
+		"self modified")
+
+	merged.Facts["us-gaap"]["Assets"].Units["USD"][0].Val = 1
+	assert.Zero(t, facts.Facts["us-gaap"]["Assets"].Units["USD"][0].Val,
+		"merged shares fact units with self")
+
+	merged, err := facts.TryMerge(other)
+	require.NoError(t, err)
+	assert.Equal(t, wantFacts, merged)
+
+	other.CIK = 1
+	_, err = facts.TryMerge(other)
+	require.ErrorIs(t, err, ErrCIKMismatch)
+}
+
+func TestCompanyFacts_FactCount(t *testing.T) {
+	var facts CompanyFacts
+	assert.Zero(t, facts.FactCount())
+	assert.Zero(t, facts.FactUnitCount())
+
+	facts.Facts = map[string]map[string]CompanyFact{
+		"dei": {
+			"EntityCommonStockSharesOutstanding": {
+				Units: map[string][]FactUnit{"shares": {{}, {}}},
+			},
+		},
+		"us-gaap": {
+			"AccountsPayable": {
+				Units: map[string][]FactUnit{"USD": {{}}},
+			},
+			"EarningsPerShareBasic": {
+				Units: map[string][]FactUnit{
+					"USD/shares": {{}, {}, {}},
+					"EUR/shares": {{}},
+				},
+			},
+		},
+	}
+	assert.Equal(t, 3, facts.FactCount())
+	assert.Equal(t, 7, facts.FactUnitCount())
+}
+
+func TestCompanyFacts_FactUnit(t *testing.T) {
+	var facts CompanyFacts
+	_, ok := facts.FactUnitsForConcept("us-gaap", "AccountsPayable", "USD")
+	assert.False(t, ok, "nil facts")
+	_, ok = facts.FactUnit("us-gaap", "AccountsPayable", "USD", "accn-1")
+	assert.False(t, ok, "nil facts")
+
+	units := []FactUnit{{Accn: "accn-1", Val: 1}, {Accn: "accn-2", Val: 2}}
+	facts.Facts = map[string]map[string]CompanyFact{
+		"dei": nil,
+		"us-gaap": {
+			"AccountsPayable": {Units: map[string][]FactUnit{"USD": units}},
+			"Assets":          {},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		tax      string
+		concept  string
+		unit     string
+		accn     string
+		want     FactUnit
+		wantUnit bool
+		wantOk   bool
+	}{
+		{
+			name:     "found",
+			tax:      "us-gaap",
+			concept:  "AccountsPayable",
+			unit:     "USD",
+			accn:     "accn-2",
+			want:     units[1],
+			wantUnit: true,
+			wantOk:   true,
+		},
+		{
+			name:     "unknown accn",
+			tax:      "us-gaap",
+			concept:  "AccountsPayable",
+			unit:     "USD",
+			accn:     "accn-3",
+			wantUnit: true,
+		},
+		{
+			name:    "unknown unit",
+			tax:     "us-gaap",
+			concept: "AccountsPayable",
+			unit:    "EUR",
+			accn:    "accn-1",
+		},
+		{
+			name:    "nil units",
+			tax:     "us-gaap",
+			concept: "Assets",
+			unit:    "USD",
+			accn:    "accn-1",
+		},
+		{
+			name:    "unknown concept",
+			tax:     "us-gaap",
+			concept: "Liabilities",
+			unit:    "USD",
+			accn:    "accn-1",
+		},
+		{
+			name:    "nil taxonomy",
+			tax:     "dei",
+			concept: "AccountsPayable",
+			unit:    "USD",
+			accn:    "accn-1",
+		},
+		{
+			name:    "unknown taxonomy",
+			tax:     "ifrs-full",
+			concept: "AccountsPayable",
+			unit:    "USD",
+			accn:    "accn-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := facts.FactUnit(tt.tax, tt.concept, tt.unit, tt.accn)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+
+			gotUnits, ok := facts.FactUnitsForConcept(tt.tax, tt.concept, tt.unit)
+			assert.Equal(t, tt.wantUnit, ok)
+			if tt.wantUnit {
+				assert.Equal(t, units, gotUnits)
+			} else {
+				assert.Nil(t, gotUnits)
+			}
+		})
+	}
+}
+
 func TestCompanyFacts_UnmarshalJSON(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -62,6 +299,71 @@ func TestUint32String_UnmarshalJSON_error(t *testing.T) {
 	require.Error(t, cik.UnmarshalJSON([]byte{}))
 }
 
+func TestParseCIK(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    uint32
+		wantErr bool
+	}{
+		{name: "plain", s: "320193", want: 320193},
+		{name: "zero-padded", s: "0000320193", want: 320193},
+		{name: "zero", s: "0000000000", want: 0},
+		{name: "empty", s: "", wantErr: true},
+		{name: "not a number", s: "00003201.93", wantErr: true},
+		{name: "overflow", s: "4294967296", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cik, err := ParseCIK(tt.s)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Panics(t, func() { MustParseCIK(tt.s) })
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, cik)
+				assert.Equal(t, tt.want, MustParseCIK(tt.s))
+			}
+		})
+	}
+}
+
+func TestCIK_String(t *testing.T) {
+	cik := CIK(320193)
+	assert.Equal(t, "0000320193", cik.String())
+	assert.Equal(t, "0000320193", fmt.Sprint(cik))
+
+	b, err := cik.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("0000320193"), b)
+
+	b, err = json.Marshal(CompanyFacts{CIK: cik})
+	require.NoError(t, err)
+	var facts CompanyFacts
+	require.NoError(t, json.Unmarshal(b, &facts))
+	assert.Equal(t, cik, facts.CIK)
+}
+
+func TestCompanyFact_HasUnit(t *testing.T) {
+	fact := CompanyFact{
+		Units: map[string][]FactUnit{
+			"USD": {{}},
+			"EUR": {},
+		},
+	}
+	assert.True(t, fact.HasUnit("USD"))
+	assert.True(t, fact.HasFactUnits("USD"))
+	assert.True(t, fact.HasUnit("EUR"))
+	assert.False(t, fact.HasFactUnits("EUR"))
+	assert.False(t, fact.HasUnit("shares"))
+	assert.False(t, fact.HasFactUnits("shares"))
+
+	var emptyFact CompanyFact
+	assert.False(t, emptyFact.HasUnit("USD"))
+	assert.False(t, emptyFact.HasFactUnits("USD"))
+}
+
 func TestFactUnit_ParseTimes(t *testing.T) {
 	const unparseableTime = "unparseable time"
 	testFact := FactUnit{
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// WithResponseHook adds fn, which is called with every response received by
+// Client, before it returned to caller. It's called for any status code, but
+// not for failed requests. fn can replace resp.Body, but must not read it.
+// Multiple hooks are called in order of adding.
+func WithResponseHook(fn func(resp *http.Response)) ClientOption {
+	return func(c *Client) { c.responseHooks = append(c.responseHooks, fn) }
+}
+
+// WithSizeTracking calls fn with URL and size of every response body. It uses
+// Content-Length header, if it exists. Otherwise it counts bytes read from the
+// body and calls fn when the body is closed.
+func WithSizeTracking(fn func(url string, bytes int64)) ClientOption {
+	return WithResponseHook(func(resp *http.Response) {
+		url := resp.Request.URL.String()
+		if resp.ContentLength >= 0 {
+			fn(url, resp.ContentLength)
+			return
+		}
+		resp.Body = &countingBody{
+			ReadCloser: resp.Body,
+			onClose:    func(n int64) { fn(url, n) },
+		}
+	})
+}
+
+func (self *Client) callResponseHooks(req *http.Request, resp *http.Response) {
+	if len(self.responseHooks) == 0 {
+		return
+	} else if resp.Request == nil {
+		resp.Request = req // http.Client sets it, but other doers may not
+	}
+
+	for _, fn := range self.responseHooks {
+		fn(resp)
+	}
+}
+
+type countingBody struct {
+	io.ReadCloser
+	n       int64
+	onClose func(n int64)
+	once    sync.Once
+}
+
+func (self *countingBody) Read(p []byte) (int, error) {
+	n, err := self.ReadCloser.Read(p)
+	self.n += int64(n)
+	return n, err //nolint:wrapcheck // must return io.EOF as is
+}
+
+func (self *countingBody) Close() error {
+	self.once.Do(func() { self.onClose(self.n) })
+	return self.ReadCloser.Close() //nolint:wrapcheck // it's a wrapper
+}
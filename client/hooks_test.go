@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dsh2dsh/edgar/internal/mocks/client"
+)
+
+func TestClient_WithResponseHook(t *testing.T) {
+	ctx := context.Background()
+	httpClient := client.NewMockHttpRequestDoer(t)
+	var got []string
+	c := New(WithHttpClient(httpClient), WithRateLimiter(nil),
+		WithResponseHook(func(resp *http.Response) {
+			got = append(got, "first "+resp.Request.URL.String())
+		}),
+		WithResponseHook(func(resp *http.Response) {
+			got = append(got, "second "+resp.Status)
+		}))
+
+	recorder := httptest.NewRecorder()
+	recorder.WriteHeader(http.StatusNotFound)
+	httpClient.EXPECT().Do(mock.Anything).Return(recorder.Result(), nil).Once()
+	resp, err := c.Get(ctx, "https://localhost/foo")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, []string{"first https://localhost/foo", "second 404 Not Found"},
+		got)
+
+	httpClient.EXPECT().Do(mock.Anything).Return(nil, io.ErrUnexpectedEOF).Once()
+	_, err = c.Get(ctx, "https://localhost/foo")
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Len(t, got, 2, "hooks called for failed request")
+}
+
+func TestClient_WithSizeTracking(t *testing.T) {
+	const body = `{"foo": "bar"}`
+	ctx := context.Background()
+
+	type tracked struct {
+		url   string
+		bytes int64
+	}
+	var got []tracked
+
+	httpClient := client.NewMockHttpRequestDoer(t)
+	c := New(WithHttpClient(httpClient), WithRateLimiter(nil),
+		WithSizeTracking(func(url string, bytes int64) {
+			got = append(got, tracked{url: url, bytes: bytes})
+		}))
+
+	resp := &http.Response{
+		StatusCode:    http.StatusOK,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: 100,
+	}
+	httpClient.EXPECT().Do(mock.Anything).Return(resp, nil).Once()
+	resp, err := c.Get(ctx, "https://localhost/foo")
+	require.NoError(t, err)
+	assert.Equal(t, []tracked{{url: "https://localhost/foo", bytes: 100}}, got)
+	resp.Body.Close()
+
+	got = nil
+	resp = &http.Response{
+		StatusCode:    http.StatusOK,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: -1,
+	}
+	httpClient.EXPECT().Do(mock.Anything).Return(resp, nil).Once()
+	var value map[string]string
+	require.NoError(t, c.GetJSON(ctx, "https://localhost/bar", &value))
+	assert.Equal(t, map[string]string{"foo": "bar"}, value)
+	assert.Equal(t,
+		[]tracked{{url: "https://localhost/bar", bytes: int64(len(body))}}, got)
+
+	resp.Body.Close()
+	assert.Len(t, got, 1, "second Close")
+}
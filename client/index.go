@@ -1,6 +1,10 @@
 package client
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
 
 type ArchiveIndex struct {
 	Directory struct {
@@ -30,6 +34,25 @@ func (self *ArchiveIndex) Parent() string {
 	return self.Directory.ParentDir
 }
 
+// archiveIndexNames is a lightweight variant of ArchiveIndex, which decodes
+// names and types of items only and skips everything else.
+type archiveIndexNames struct {
+	Directory struct {
+		Item []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"item"`
+	} `json:"directory"`
+}
+
+func (self *archiveIndexNames) Items() []ArchiveItem {
+	items := make([]ArchiveItem, len(self.Directory.Item))
+	for i, item := range self.Directory.Item {
+		items[i] = ArchiveItem{Name: item.Name, Type: item.Type}
+	}
+	return items
+}
+
 // --------------------------------------------------
 
 type companyTickers map[string]CompanyTicker
@@ -40,6 +63,57 @@ type CompanyTicker struct {
 	Title  string `json:"title"`
 }
 
+func (self *CompanyTicker) Validate() error {
+	switch {
+	case self.CIK == 0:
+		return errors.New("zero CIK")
+	case self.Ticker == "":
+		return errors.New("empty ticker")
+	case self.Title == "":
+		return errors.New("empty title")
+	}
+	return nil
+}
+
 func (self *CompanyTicker) URI() string {
 	return fmt.Sprintf("%010d", self.CIK)
 }
+
+// --------------------------------------------------
+
+type companyTickersExchange struct {
+	Fields []string `json:"fields"`
+	Data   [][]any  `json:"data"`
+}
+
+func (self *companyTickersExchange) Tickers() ([]CompanyTickerExchange, error) {
+	idxCIK := slices.Index(self.Fields, "cik")
+	idxName := slices.Index(self.Fields, "name")
+	idxTicker := slices.Index(self.Fields, "ticker")
+	idxExchange := slices.Index(self.Fields, "exchange")
+	if idxCIK < 0 || idxName < 0 || idxTicker < 0 || idxExchange < 0 {
+		return nil, fmt.Errorf("unexpected company tickers fields: %v", self.Fields)
+	}
+
+	tickers := make([]CompanyTickerExchange, len(self.Data))
+	for i, row := range self.Data {
+		if len(row) != len(self.Fields) {
+			return nil, fmt.Errorf("unexpected company tickers row: %v", row)
+		}
+		cik, ok := row[idxCIK].(float64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected CIK in company tickers row: %v", row)
+		}
+		t := &tickers[i]
+		t.CIK = uint32(cik)
+		t.Title, _ = row[idxName].(string)
+		t.Ticker, _ = row[idxTicker].(string)
+		t.Exchange, _ = row[idxExchange].(string)
+	}
+	return tickers, nil
+}
+
+type CompanyTickerExchange struct {
+	CompanyTicker
+	Exchange string
+}
@@ -2,12 +2,15 @@ package index
 
 import (
 	"bufio"
+	"compress/gzip"
+	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
 	"maps"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -18,8 +21,19 @@ const (
 	lastFiledLayout = "January 2, 2006"
 	numHeaders      = 5
 	numFields       = 5
+	headerWidth     = 23 // width of header name with padding
+	headerDivider   = "--------------------------------------------------------------------------------"
+
+	cancelCheckEvery = 1000 // how often IterateWithCancel checks ctx
 )
 
+// ErrIterateCancelled returned by [File.IterateWithCancel] when its context is
+// done. It wraps context error too.
+var ErrIterateCancelled = errors.New("index iteration cancelled")
+
+// Fields of index record. Their positions in records are detected by
+// parseRowHeader, because they differ between index files, like master.idx and
+// company.idx.
 const (
 	idxCIK = iota
 	idxCompanyName
@@ -28,16 +42,68 @@ const (
 	idxFilename
 )
 
-func NewFile(r io.Reader) File {
+// masterFieldIdx is positions of fields in master.idx, used when row header
+// wasn't parsed.
+var masterFieldIdx = [numFields]int{
+	idxCIK:         0,
+	idxCompanyName: 1,
+	idxFormType:    2,
+	idxDateFiled:   3,
+	idxFilename:    4,
+}
+
+// masterFieldNames is names of fields in master.idx.
+var masterFieldNames = [numFields]string{
+	idxCIK:         "CIK",
+	idxCompanyName: "Company Name",
+	idxFormType:    "Form Type",
+	idxDateFiled:   "Date Filed",
+	idxFilename:    "Filename",
+}
+
+// fieldByName maps lower cased names of row header to fields.
+var fieldByName = map[string]int{
+	"cik":          idxCIK,
+	"company name": idxCompanyName,
+	"form type":    idxFormType,
+	"date filed":   idxDateFiled,
+	"filename":     idxFilename,
+	"file name":    idxFilename,
+}
+
+// NewRawFile returns File, which reads plain text index from r, like EDGAR's
+// .idx files.
+func NewRawFile(r io.Reader) File {
 	return File{
 		buf: bufio.NewReader(r),
 	}
 }
 
+// NewGzipFile returns File, which reads gzip compressed index from r, like
+// EDGAR's .gz files. It returns error if r doesn't have valid gzip header.
+func NewGzipFile(r io.Reader) (File, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return File{}, fmt.Errorf("gunzip index: %w", err)
+	}
+	return NewRawFile(gz), nil
+}
+
+// NewFile returns File, which reads already gunzipped index from r.
+//
+// Deprecated: use NewRawFile or NewGzipFile.
+func NewFile(r io.Reader) File {
+	return NewRawFile(r)
+}
+
 type File struct {
-	buf        *bufio.Reader
-	headers    map[string]string
-	fieldNames []string
+	buf         *bufio.Reader
+	headers     map[string]string
+	headerNames []string // in order of appearance
+	fieldNames  []string
+	fieldIdx    *[numFields]int // positions of fields, if row header parsed
+	records     *csv.Reader
+	position    int
 
 	lastFiled time.Time
 }
@@ -57,11 +123,13 @@ func (self *File) ReadHeaders() error {
 	if err != nil {
 		return err
 	}
-	self.parseRowHeader(rowHeader)
+	if err := self.parseRowHeader(rowHeader); err != nil {
+		return err
+	}
 
 	if s, err := self.readLine(); err != nil {
 		return fmt.Errorf("skipping header divider: %w", err)
-	} else if !strings.HasPrefix(s, "---") {
+	} else if !strings.HasPrefix(s, headerDivider[:3]) {
 		return fmt.Errorf("got unexpected line %q after row header", s)
 	}
 	return nil
@@ -69,6 +137,7 @@ func (self *File) ReadHeaders() error {
 
 func (self *File) readIndexHeader() error {
 	headers := make(map[string]string, numHeaders)
+	names := make([]string, 0, numHeaders)
 	for {
 		s, err := self.readLine()
 		if err != nil {
@@ -83,11 +152,12 @@ func (self *File) readIndexHeader() error {
 			return fmt.Errorf("invalid header line %q: %w", s, err)
 		}
 		headers[h] = v
+		names = append(names, h)
 	}
 	if len(headers) == 0 {
 		return errors.New("headers not found")
 	}
-	self.headers = headers
+	self.headers, self.headerNames = headers, names
 	return nil
 }
 
@@ -133,8 +203,36 @@ func (self *File) skipEmptyLines() (string, error) {
 	}
 }
 
-func (self *File) parseRowHeader(s string) {
-	self.fieldNames = strings.Split(s, string(fieldDelimiter))
+// parseRowHeader parses names of record fields and detects their positions,
+// so records can be parsed regardless of the order of fields.
+func (self *File) parseRowHeader(s string) error {
+	names := strings.Split(s, string(fieldDelimiter))
+	var fieldIdx [numFields]int
+	var found [numFields]bool
+	for i, name := range names {
+		field, ok := fieldByName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			continue
+		} else if found[field] {
+			return fmt.Errorf("duplicated field %q in row header %q", name, s)
+		}
+		fieldIdx[field], found[field] = i, true
+	}
+
+	if i := slices.Index(found[:], false); i >= 0 {
+		return fmt.Errorf("field %q not found in row header %q",
+			masterFieldNames[i], s)
+	}
+	self.fieldNames, self.fieldIdx = names, &fieldIdx
+	return nil
+}
+
+// fieldPositions returns positions of fields in records.
+func (self *File) fieldPositions() *[numFields]int {
+	if self.fieldIdx == nil {
+		return &masterFieldIdx
+	}
+	return self.fieldIdx
 }
 
 func (self *File) Headers() map[string]string {
@@ -145,42 +243,197 @@ func (self *File) LastFiled() time.Time {
 	return self.lastFiled
 }
 
+// EarliestFiled iterates all records and returns minimal Date Filed. Together
+// with LastFiled it gives date coverage of the index file. Like Iterate, it
+// consumes all records.
+func (self *File) EarliestFiled() (time.Time, error) {
+	var earliest time.Time
+	err := self.Iterate(func(item *Item) error {
+		if earliest.IsZero() || item.Filed.Before(earliest) {
+			earliest = item.Filed
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return earliest, nil
+}
+
 func (self *File) FieldNames() []string {
 	return slices.Clone(self.fieldNames)
 }
 
 func (self *File) Iterate(fn func(*Item) error) error {
-	r := csv.NewReader(self.buf)
-	r.Comma = rune(fieldDelimiter)
-	r.ReuseRecord = true
+	r := self.recordReader()
 	for {
 		records, err := r.Read()
 		if errors.Is(err, io.EOF) {
 			break
 		} else if err != nil {
 			return fmt.Errorf("iterating edgar index file: %w", err)
-		} else if err := callIterFunc(fn, records); err != nil {
+		}
+		self.position++
+		if err := callIterFunc(fn, self.fieldPositions(), records); err != nil {
 			return fmt.Errorf("failed iterate: %w", err)
 		}
 	}
 	return nil
 }
 
-func callIterFunc(fn func(*Item) error, r []string) error {
-	if len(r) < numFields {
+// recordReader returns CSV reader of records. It's created once and shared by
+// all methods, because it reads ahead and another reader would miss records.
+func (self *File) recordReader() *csv.Reader {
+	if self.records == nil {
+		r := csv.NewReader(self.buf)
+		r.Comma = rune(fieldDelimiter)
+		r.ReuseRecord = true
+		self.records = r
+	}
+	return self.records
+}
+
+// SkipN reads and discards next n records without parsing them, so following
+// Iterate starts from record Position() + n. It returns error wrapped io.EOF,
+// if there are less than n records left. Records can't be skipped backwards,
+// so create another File for reading records again.
+func (self *File) SkipN(n int) error {
+	if n < 0 {
+		return fmt.Errorf("skip %v records: negative number", n)
+	}
+
+	r := self.recordReader()
+	for i := range n {
+		if _, err := r.Read(); errors.Is(err, io.EOF) {
+			return fmt.Errorf("skip %v records: EOF after %v records: %w", n, i, err)
+		} else if err != nil {
+			return fmt.Errorf("skip %v records: %w", n, err)
+		}
+		self.position++
+	}
+	return nil
+}
+
+// Position returns number of records read so far by SkipN and any kind of
+// iteration, not including headers.
+func (self *File) Position() int {
+	return self.position
+}
+
+// IterateByForm is like Iterate, but calls fn only for items with FormType
+// from forms. Empty forms means all items.
+func (self *File) IterateByForm(forms []string, fn func(*Item) error) error {
+	if len(forms) == 0 {
+		return self.Iterate(fn)
+	}
+	return self.Iterate(func(item *Item) error {
+		if slices.Contains(forms, item.FormType) {
+			return fn(item)
+		}
+		return nil
+	})
+}
+
+// IterateWithCancel is like Iterate, but it checks ctx every cancelCheckEvery
+// records and stops iteration if ctx is done. In this case it returns error
+// wrapped both ErrIterateCancelled and ctx.Err(), which can be distinguished
+// from errors of fn or reading.
+func (self *File) IterateWithCancel(ctx context.Context, fn func(*Item) error,
+) error {
+	var cnt int
+	var cancelErr error
+	err := self.Iterate(func(item *Item) error {
+		if cnt%cancelCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				cancelErr = fmt.Errorf("%w: %w", ErrIterateCancelled, err)
+				return cancelErr
+			}
+		}
+		cnt++
+		return fn(item)
+	})
+	if cancelErr != nil {
+		return cancelErr
+	}
+	return err
+}
+
+// BatchIterate is like Iterate, but calls fn with batches of up to batchSize
+// items. Every batch is a new slice, so fn can pass it to another goroutine
+// and process it concurrently with reading of next batch. Last batch can be
+// shorter.
+func (self *File) BatchIterate(batchSize int, fn func(batch []*Item) error,
+) error {
+	if batchSize < 1 {
+		return fmt.Errorf("batch iterate: invalid batch size %v", batchSize)
+	}
+
+	batch := make([]*Item, 0, batchSize)
+	err := self.Iterate(func(item *Item) error {
+		if batch = append(batch, item); len(batch) == batchSize {
+			err := fn(batch)
+			batch = make([]*Item, 0, batchSize)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	} else if len(batch) > 0 {
+		if err := fn(batch); err != nil {
+			return fmt.Errorf("failed iterate: %w", err)
+		}
+	}
+	return nil
+}
+
+func callIterFunc(fn func(*Item) error, idx *[numFields]int, r []string) error {
+	if len(r) <= slices.Max(idx[:]) {
 		return fmt.Errorf("unexpected num of fields in record: %#v", r)
 	}
 	item := Item{
-		CompanyName: r[idxCompanyName],
-		FormType:    r[idxFormType],
-		Filename:    r[idxFilename],
+		CompanyName: r[idx[idxCompanyName]],
+		FormType:    r[idx[idxFormType]],
+		Filename:    r[idx[idxFilename]],
 	}
-	if err := item.parseCIK(r[idxCIK]); err != nil {
+	if err := item.parseCIK(r[idx[idxCIK]]); err != nil {
 		return err
-	} else if err := item.parseFiled(r[idxDateFiled]); err != nil {
+	} else if err := item.parseFiled(r[idx[idxDateFiled]]); err != nil {
 		return err
+	} else if err := fn(&item); err != nil {
+		return fmt.Errorf("%v: %w", &item, err)
+	}
+	return nil
+}
+
+// CompanyCount iterates all records and returns exact number of distinct
+// companies. It keeps every CIK in memory, see ApproxCompanyCount for O(1)
+// space alternative.
+func (self *File) CompanyCount() (int, error) {
+	companies := map[uint32]struct{}{}
+	err := self.Iterate(func(item *Item) error {
+		companies[item.CIK] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(companies), nil
+}
+
+// ApproxCompanyCount is like CompanyCount, but estimates number of distinct
+// companies using HyperLogLog with fixed 1KB of memory. The standard error of
+// estimation is about 3%, so use CompanyCount if exact number required.
+func (self *File) ApproxCompanyCount() (int, error) {
+	var hll hyperLogLog
+	err := self.Iterate(func(item *Item) error {
+		hll.Add(item.CIK)
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
-	return fn(&item)
+	return hll.Count(), nil
 }
 
 func (self *File) CompaniesLastFiled() (map[uint32]time.Time, error) {
@@ -196,3 +449,79 @@ func (self *File) CompaniesLastFiled() (map[uint32]time.Time, error) {
 	}
 	return lastFiled, nil
 }
+
+// WriteTo writes headers and all remaining records in EDGAR master index
+// format. It compresses output by gzip, unless w is [*gzip.Writer] already. Like
+// Iterate, it consumes all records, so parse another File for reading records
+// after it. It returns number of bytes written into w.
+func (self *File) WriteTo(w io.Writer) (int64, error) {
+	cw := countWriter{w: w}
+	if _, ok := w.(*gzip.Writer); ok {
+		err := self.writeIndex(&cw)
+		return cw.n, err
+	}
+
+	gz := gzip.NewWriter(&cw)
+	if err := self.writeIndex(gz); err != nil {
+		return cw.n, err
+	} else if err := gz.Close(); err != nil {
+		return cw.n, fmt.Errorf("close gzip writer: %w", err)
+	}
+	return cw.n, nil
+}
+
+func (self *File) writeIndex(w io.Writer) error {
+	if err := self.writeHeaders(w); err != nil {
+		return err
+	}
+
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Comma = rune(fieldDelimiter)
+	idx := self.fieldPositions()
+	record := make([]string, max(len(self.fieldNames), numFields))
+	err := self.Iterate(func(item *Item) error {
+		record[idx[idxCIK]] = strconv.FormatUint(uint64(item.CIK), 10)
+		record[idx[idxCompanyName]] = item.CompanyName
+		record[idx[idxFormType]] = item.FormType
+		record[idx[idxDateFiled]] = item.Filed.Format(dateFiledLayout)
+		record[idx[idxFilename]] = item.Filename
+		return csvWriter.Write(record) //nolint:wrapcheck // wrapped by Iterate
+	})
+	if err != nil {
+		return fmt.Errorf("write records: %w", err)
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("write records: %w", err)
+	}
+	return nil
+}
+
+func (self *File) writeHeaders(w io.Writer) error {
+	var b strings.Builder
+	for _, name := range self.headerNames {
+		fmt.Fprintf(&b, "%-*s%s\n", headerWidth, name+":", self.headers[name])
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.Join(self.fieldNames, string(fieldDelimiter)))
+	b.WriteString("\n")
+	b.WriteString(headerDivider)
+	b.WriteString("\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write headers: %w", err)
+	}
+	return nil
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (self *countWriter) Write(p []byte) (int, error) {
+	n, err := self.w.Write(p)
+	self.n += int64(n)
+	return n, err //nolint:wrapcheck // it's a transparent wrapper
+}
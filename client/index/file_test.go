@@ -1,13 +1,22 @@
 package index
 
 import (
+	"bytes"
 	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
 )
 
 func TestFile_Headers(t *testing.T) {
@@ -30,21 +39,44 @@ func newTestFile(t *testing.T) File {
 	file, err := os.Open("testdata/master.gz")
 	require.NoError(t, err)
 
-	zr, err := gzip.NewReader(file)
+	indexFile, err := NewGzipFile(file)
 	require.NoError(t, err)
-
-	indexFile := NewFile(zr)
 	require.NoError(t, indexFile.ReadHeaders())
 
 	return indexFile
 }
 
+func TestNewGzipFile(t *testing.T) {
+	_, err := NewGzipFile(strings.NewReader("not gzip compressed"))
+	require.ErrorIs(t, err, gzip.ErrHeader)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = io.WriteString(gz, "Description: test\n")
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	f, err := NewGzipFile(&buf)
+	require.NoError(t, err)
+	s, err := f.readLine()
+	require.NoError(t, err)
+	assert.Equal(t, "Description: test", s)
+}
+
 func TestFile_LastFiled(t *testing.T) {
 	indexFile := newTestFile(t)
 	assert.Equal(t, time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC),
 		indexFile.LastFiled())
 }
 
+func TestFile_EarliestFiled(t *testing.T) {
+	indexFile := newTestFile(t)
+	earliest, err := indexFile.EarliestFiled()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC),
+		earliest)
+}
+
 func TestFile_FieldNames(t *testing.T) {
 	wantNames := []string{"CIK", "Company Name", "Form Type", "Date Filed", "Filename"}
 	indexFile := newTestFile(t)
@@ -55,6 +87,56 @@ func TestFile_FieldNames(t *testing.T) {
 	assert.Equal(t, wantNames, indexFile.FieldNames())
 }
 
+func TestFile_companyIndex(t *testing.T) {
+	const companyIdx = `Description:           Company Index of EDGAR Dissemination Feed
+Last Data Received:    January 11, 2024
+
+Company Name|Form Type|CIK|Date Filed|File Name
+--------------------------------------------------------------------------------
+NICHOLAS FINANCIAL INC|S-4/A|1000045|2024-01-10|edgar/data/1000045/0000950170-24-003542.txt
+ROYAL BANK OF CANADA|424B2|1000275|2024-01-02|edgar/data/1000275/0001140361-24-000195.txt
+`
+	indexFile := NewRawFile(strings.NewReader(companyIdx))
+	require.NoError(t, indexFile.ReadHeaders())
+
+	var items []Item
+	require.NoError(t, indexFile.Iterate(func(item *Item) error {
+		items = append(items, *item)
+		return nil
+	}))
+	require.Len(t, items, 2)
+	assert.Equal(t, Item{
+		CIK:         1000045,
+		CompanyName: "NICHOLAS FINANCIAL INC",
+		FormType:    "S-4/A",
+		Filed:       time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC),
+		Filename:    "edgar/data/1000045/0000950170-24-003542.txt",
+	}, items[0])
+	assert.Equal(t, uint32(1000275), items[1].CIK)
+
+	indexFile = NewRawFile(strings.NewReader(companyIdx))
+	require.NoError(t, indexFile.ReadHeaders())
+	var buf bytes.Buffer
+	_, err := indexFile.WriteTo(&buf)
+	require.NoError(t, err)
+	gz, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	b, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "\nCompany Name|Form Type|CIK|Date Filed|File Name\n")
+	assert.True(t, strings.HasSuffix(string(b), "ROYAL BANK OF CANADA|424B2|1000275|2024-01-02|edgar/data/1000275/0001140361-24-000195.txt\n"))
+}
+
+func TestFile_parseRowHeader_error(t *testing.T) {
+	var f File
+	require.ErrorContains(t, f.parseRowHeader("CIK|Company Name|Form Type|Filename"),
+		`"Date Filed" not found`)
+	require.ErrorContains(t, f.parseRowHeader(
+		"CIK|Company Name|Form Type|Date Filed|Filename|File Name"), "duplicated")
+	require.NoError(t, f.parseRowHeader("Form Type|Company Name|CIK|Date Filed|File Name"))
+	assert.Equal(t, &[numFields]int{2, 1, 0, 3, 4}, f.fieldIdx)
+}
+
 func TestFile_Iterate(t *testing.T) {
 	indexFile := newTestFile(t)
 	var minFiled, maxFiled time.Time
@@ -78,6 +160,225 @@ func TestFile_Iterate(t *testing.T) {
 	assert.Equal(t, wantMax, maxFiled)
 }
 
+func TestFile_Iterate_error(t *testing.T) {
+	indexFile := newTestFile(t)
+	wantErr := errors.New("test error")
+	var firstItem string
+	err := indexFile.Iterate(func(item *Item) error {
+		firstItem = item.String()
+		return wantErr
+	})
+	require.ErrorIs(t, err, wantErr)
+	assert.Contains(t, err.Error(), firstItem)
+}
+
+func TestFile_SkipN(t *testing.T) {
+	const numRecords = 38824
+	indexFile := newTestFile(t)
+	assert.Zero(t, indexFile.Position())
+
+	var items []string
+	require.NoError(t, indexFile.Iterate(func(item *Item) error {
+		items = append(items, item.String())
+		return nil
+	}))
+	require.Len(t, items, numRecords)
+	assert.Equal(t, numRecords, indexFile.Position())
+
+	indexFile = newTestFile(t)
+	require.NoError(t, indexFile.SkipN(0))
+	assert.Zero(t, indexFile.Position())
+	require.NoError(t, indexFile.SkipN(100))
+	assert.Equal(t, 100, indexFile.Position())
+	require.NoError(t, indexFile.SkipN(900))
+	assert.Equal(t, 1000, indexFile.Position())
+
+	var got []string
+	require.NoError(t, indexFile.Iterate(func(item *Item) error {
+		got = append(got, item.String())
+		return nil
+	}))
+	assert.Equal(t, items[1000:], got)
+	assert.Equal(t, numRecords, indexFile.Position())
+
+	indexFile = newTestFile(t)
+	require.ErrorIs(t, indexFile.SkipN(numRecords+1), io.EOF)
+	assert.Equal(t, numRecords, indexFile.Position())
+	require.Error(t, indexFile.SkipN(-1))
+}
+
+func TestFile_BatchIterate(t *testing.T) {
+	newFile := func() *File {
+		f := newTestFile(t)
+		return &f
+	}
+
+	var want []Item
+	require.NoError(t, newFile().Iterate(func(item *Item) error {
+		want = append(want, *item)
+		return nil
+	}))
+
+	for _, batchSize := range []int{1, 1000, len(want), len(want) + 1} {
+		var got []Item
+		var batches int
+		err := newFile().BatchIterate(batchSize, func(batch []*Item) error {
+			require.LessOrEqual(t, len(batch), batchSize)
+			for _, item := range batch {
+				got = append(got, *item)
+			}
+			batches++
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "batchSize=%v", batchSize)
+		assert.Equal(t, (len(want)+batchSize-1)/batchSize, batches,
+			"batchSize=%v", batchSize)
+	}
+
+	wantErr := errors.New("test error")
+	for _, batchSize := range []int{1000, len(want) + 1} {
+		err := newFile().BatchIterate(batchSize,
+			func(batch []*Item) error { return wantErr })
+		require.ErrorIs(t, err, wantErr)
+	}
+	require.Error(t, newFile().BatchIterate(0,
+		func(batch []*Item) error { return nil }))
+}
+
+func BenchmarkFile_BatchIterate(b *testing.B) {
+	data, err := os.ReadFile("testdata/master.gz")
+	require.NoError(b, err)
+
+	newFile := func(b *testing.B) File {
+		f, err := NewGzipFile(bytes.NewReader(data))
+		require.NoError(b, err)
+		require.NoError(b, f.ReadHeaders())
+		return f
+	}
+
+	// process simulates some work per item, like parsing of filename.
+	process := func(item *Item) int {
+		return len(strings.Split(item.Filename, "/"))
+	}
+
+	b.Run("Iterate", func(b *testing.B) {
+		for range b.N {
+			f := newFile(b)
+			var n int
+			require.NoError(b, f.Iterate(func(item *Item) error {
+				n += process(item)
+				return nil
+			}))
+		}
+	})
+
+	for _, batchSize := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("BatchIterate-%v", batchSize), func(b *testing.B) {
+			for range b.N {
+				f := newFile(b)
+				var g errgroup.Group
+				g.SetLimit(runtime.GOMAXPROCS(0))
+				var n atomic.Int64
+				require.NoError(b, f.BatchIterate(batchSize,
+					func(batch []*Item) error {
+						g.Go(func() error {
+							var cnt int
+							for _, item := range batch {
+								cnt += process(item)
+							}
+							n.Add(int64(cnt))
+							return nil
+						})
+						return nil
+					}))
+				require.NoError(b, g.Wait())
+			}
+		})
+	}
+}
+
+func TestFile_IterateByForm(t *testing.T) {
+	countForms := func(t *testing.T, forms []string) map[string]int {
+		indexFile := newTestFile(t)
+		counts := make(map[string]int)
+		require.NoError(t, indexFile.IterateByForm(forms, func(item *Item) error {
+			counts[item.FormType]++
+			return nil
+		}))
+		return counts
+	}
+
+	allCounts := countForms(t, nil)
+	require.Greater(t, len(allCounts), 2)
+	require.NotZero(t, allCounts["10-K"])
+	require.NotZero(t, allCounts["10-Q"])
+
+	assert.Equal(t, map[string]int{"10-K": allCounts["10-K"]},
+		countForms(t, []string{"10-K"}))
+	assert.Equal(t,
+		map[string]int{"10-K": allCounts["10-K"], "10-Q": allCounts["10-Q"]},
+		countForms(t, []string{"10-K", "10-Q"}))
+	assert.Empty(t, countForms(t, []string{"not a form"}))
+
+	indexFile := newTestFile(t)
+	wantErr := errors.New("test error")
+	err := indexFile.IterateByForm([]string{"10-K"},
+		func(item *Item) error { return wantErr })
+	require.ErrorIs(t, err, wantErr)
+}
+
+func TestFile_IterateWithCancel(t *testing.T) {
+	indexFile := newTestFile(t)
+	var cnt int
+	err := indexFile.IterateWithCancel(context.Background(),
+		func(item *Item) error {
+			cnt++
+			return nil
+		})
+	require.NoError(t, err)
+
+	indexFile = newTestFile(t)
+	var wantCnt int
+	require.NoError(t, indexFile.Iterate(func(item *Item) error {
+		wantCnt++
+		return nil
+	}))
+	assert.Equal(t, wantCnt, cnt)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	indexFile = newTestFile(t)
+	cnt = 0
+	err = indexFile.IterateWithCancel(ctx, func(item *Item) error {
+		cnt++
+		if cnt == cancelCheckEvery+cancelCheckEvery/2 {
+			cancel()
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, ErrIterateCancelled)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 2*cancelCheckEvery, cnt)
+
+	indexFile = newTestFile(t)
+	cnt = 0
+	err = indexFile.IterateWithCancel(ctx, func(item *Item) error {
+		cnt++
+		return nil
+	})
+	require.ErrorIs(t, err, ErrIterateCancelled)
+	assert.Zero(t, cnt)
+}
+
+func TestFile_IterateWithCancel_error(t *testing.T) {
+	indexFile := newTestFile(t)
+	wantErr := errors.New("test error")
+	err := indexFile.IterateWithCancel(context.Background(),
+		func(item *Item) error { return wantErr })
+	require.ErrorIs(t, err, wantErr)
+	assert.NotErrorIs(t, err, ErrIterateCancelled)
+}
+
 func TestFile_CompaniesLastFiled(t *testing.T) {
 	indexFile := newTestFile(t)
 	lastFiled, err := indexFile.CompaniesLastFiled()
@@ -88,3 +389,84 @@ func TestFile_CompaniesLastFiled(t *testing.T) {
 	assert.Equal(t, time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC),
 		lastFiled[9984])
 }
+
+func TestFile_CompanyCount(t *testing.T) {
+	indexFile := newTestFile(t)
+	cnt, err := indexFile.CompanyCount()
+	require.NoError(t, err)
+	assert.Equal(t, 17318, cnt)
+
+	indexFile = newTestFile(t)
+	approxCnt, err := indexFile.ApproxCompanyCount()
+	require.NoError(t, err)
+	assert.InEpsilon(t, cnt, approxCnt, 0.05)
+}
+
+func TestFile_WriteTo(t *testing.T) {
+	var buf bytes.Buffer
+	indexFile := newTestFile(t)
+	n, err := indexFile.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	gotFile, err := NewGzipFile(&buf)
+	require.NoError(t, err)
+	require.NoError(t, gotFile.ReadHeaders())
+
+	wantFile := newTestFile(t)
+	assert.Equal(t, wantFile.Headers(), gotFile.Headers())
+	assert.Equal(t, wantFile.LastFiled(), gotFile.LastFiled())
+	assert.Equal(t, wantFile.FieldNames(), gotFile.FieldNames())
+	assert.Equal(t, collectItems(t, &wantFile), collectItems(t, &gotFile))
+}
+
+func collectItems(t *testing.T, f *File) []Item {
+	var items []Item
+	require.NoError(t, f.Iterate(func(item *Item) error {
+		items = append(items, *item)
+		return nil
+	}))
+	return items
+}
+
+func TestFile_WriteTo_gzipWriter(t *testing.T) {
+	const index = `Description:           Master Index of EDGAR Dissemination Feed
+Last Data Received:    December 16, 2023
+Comments:              webmaster@sec.gov
+
+CIK|Company Name|Form Type|Date Filed|Filename
+--------------------------------------------------------------------------------
+9984|BARNES GROUP INC|10-Q|2023-11-08|edgar/data/9984/0000009984-23-000196.txt
+936528|"WAFD ""INC"""|10-K|2023-11-17|edgar/data/936528/0000936528-23-000207.txt
+`
+	indexFile := NewRawFile(strings.NewReader(index))
+	require.NoError(t, indexFile.ReadHeaders())
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	n, err := indexFile.WriteTo(gz)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	assert.Equal(t, int64(len(index)), n)
+
+	zr, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	b, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, index, string(b))
+}
+
+func TestFile_WriteTo_error(t *testing.T) {
+	indexFile := newTestFile(t)
+	wantErr := errors.New("test error")
+	_, err := indexFile.WriteTo(&errWriter{err: wantErr})
+	require.ErrorIs(t, err, wantErr)
+}
+
+type errWriter struct {
+	err error
+}
+
+func (self *errWriter) Write(p []byte) (int, error) {
+	return 0, self.err
+}
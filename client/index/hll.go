@@ -0,0 +1,53 @@
+package index
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	hllPrecision = 10
+	hllRegisters = 1 << hllPrecision
+)
+
+// hyperLogLog estimates number of distinct CIKs, see
+// https://en.wikipedia.org/wiki/HyperLogLog
+type hyperLogLog struct {
+	registers [hllRegisters]uint8
+}
+
+func (self *hyperLogLog) Add(cik uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], cik)
+	h := xxhash.Sum64(b[:])
+
+	idx := h >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(h<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > self.registers[idx] {
+		self.registers[idx] = rank
+	}
+}
+
+func (self *hyperLogLog) Count() int {
+	const m = float64(hllRegisters)
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	var sum float64
+	var zeros int
+	for _, r := range self.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// small range correction
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(estimate))
+}
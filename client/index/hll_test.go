@@ -0,0 +1,23 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	var hll hyperLogLog
+	assert.Zero(t, hll.Count())
+
+	for cik := uint32(1); cik <= 100; cik++ {
+		hll.Add(cik)
+		hll.Add(cik)
+	}
+	assert.InEpsilon(t, 100, hll.Count(), 0.05)
+
+	for cik := uint32(1); cik <= 100_000; cik++ {
+		hll.Add(cik)
+	}
+	assert.InEpsilon(t, 100_000, hll.Count(), 0.1)
+}
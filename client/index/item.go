@@ -1,12 +1,20 @@
 package index
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 )
 
-const dateFiledLayout = "2006-01-02"
+const (
+	dateFiledLayout = "2006-01-02"
+	filingExt       = ".txt"
+	indexPageSuffix = "-index.htm"
+)
 
 type Item struct {
 	CIK         uint32
@@ -33,3 +41,48 @@ func (self *Item) parseFiled(s string) error {
 	self.Filed = filed
 	return nil
 }
+
+// String returns item in human-readable form, like
+//
+//	CIK=320193 Apple Inc. 10-K 2024-01-15 edgar/data/320193/0000320193-24-000006.txt
+func (self *Item) String() string {
+	return fmt.Sprintf("CIK=%v %v %v %v %v", self.CIK, self.CompanyName,
+		self.FormType, self.Filed.Format(dateFiledLayout), self.Filename)
+}
+
+// FilingURL returns full URL of filing, like
+//
+//	https://www.sec.gov/Archives/edgar/data/320193/0001193125-09-153165.txt
+//
+// for archivesBaseURL "https://www.sec.gov/Archives".
+func (self *Item) FilingURL(archivesBaseURL string) (string, error) {
+	if self.Filename == "" {
+		return "", errors.New("empty filename")
+	}
+
+	u, err := url.JoinPath(archivesBaseURL, self.Filename)
+	if err != nil {
+		return "", fmt.Errorf("filing URL of %q: %w", self.Filename, err)
+	}
+	return u, nil
+}
+
+// IndexURL returns full URL of filing's index page, like
+//
+//	https://www.sec.gov/Archives/edgar/data/320193/000119312509153165/0001193125-09-153165-index.htm
+//
+// for archivesBaseURL "https://www.sec.gov/Archives".
+func (self *Item) IndexURL(archivesBaseURL string) (string, error) {
+	dir, fname := path.Split(self.Filename)
+	accn, ok := strings.CutSuffix(fname, filingExt)
+	if !ok || accn == "" {
+		return "", fmt.Errorf("unexpected filename %q", self.Filename)
+	}
+
+	u, err := url.JoinPath(archivesBaseURL, dir,
+		strings.ReplaceAll(accn, "-", ""), accn+indexPageSuffix)
+	if err != nil {
+		return "", fmt.Errorf("index URL of %q: %w", self.Filename, err)
+	}
+	return u, nil
+}
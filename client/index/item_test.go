@@ -1,6 +1,7 @@
 package index
 
 import (
+	"fmt"
 	"strconv"
 	"testing"
 	"time"
@@ -22,3 +23,56 @@ func TestItem_parseFiled(t *testing.T) {
 	assert.Equal(t, time.Date(2023, time.January, 2, 0, 0, 0, 0, time.UTC), item.Filed)
 	require.Error(t, item.parseFiled("2023"))
 }
+
+func TestItem_String(t *testing.T) {
+	item := Item{
+		CIK:         320193,
+		Filed:       time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC),
+		CompanyName: "Apple Inc.",
+		FormType:    "10-K",
+		Filename:    "edgar/data/320193/0000320193-24-000006.txt",
+	}
+	want := "CIK=320193 Apple Inc. 10-K 2024-01-15 edgar/data/320193/0000320193-24-000006.txt"
+	assert.Equal(t, want, item.String())
+	assert.Equal(t, want, fmt.Sprint(&item))
+}
+
+func TestItem_FilingURL(t *testing.T) {
+	const baseURL = "https://www.sec.gov/Archives"
+	item := Item{Filename: "edgar/data/320193/0001193125-09-153165.txt"}
+
+	u, err := item.FilingURL(baseURL)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"https://www.sec.gov/Archives/edgar/data/320193/0001193125-09-153165.txt", u)
+
+	u, err = item.FilingURL(baseURL + "/")
+	require.NoError(t, err)
+	assert.Equal(t,
+		"https://www.sec.gov/Archives/edgar/data/320193/0001193125-09-153165.txt", u)
+
+	_, err = item.FilingURL(":localhost")
+	require.Error(t, err)
+
+	_, err = (&Item{}).FilingURL(baseURL)
+	require.Error(t, err)
+}
+
+func TestItem_IndexURL(t *testing.T) {
+	const baseURL = "https://www.sec.gov/Archives"
+	item := Item{Filename: "edgar/data/320193/0001193125-09-153165.txt"}
+
+	u, err := item.IndexURL(baseURL)
+	require.NoError(t, err)
+	assert.Equal(t,
+		"https://www.sec.gov/Archives/edgar/data/320193/000119312509153165/0001193125-09-153165-index.htm",
+		u)
+
+	_, err = item.IndexURL(":localhost")
+	require.Error(t, err)
+
+	for _, fname := range []string{"", "edgar/data/320193/", "edgar/data/320193/foo.htm"} {
+		_, err = (&Item{Filename: fname}).IndexURL(baseURL)
+		require.Error(t, err, fname)
+	}
+}
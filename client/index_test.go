@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestArchiveIndex(t *testing.T) {
@@ -42,7 +43,84 @@ func fakeArchiveIndex() (index ArchiveIndex) {
 	return
 }
 
+func TestCompanyTicker_Validate(t *testing.T) {
+	ticker := CompanyTicker{CIK: 320193, Ticker: "AAPL", Title: "Apple Inc."}
+	require.NoError(t, ticker.Validate())
+
+	invalid := ticker
+	invalid.CIK = 0
+	require.Error(t, invalid.Validate())
+
+	invalid = ticker
+	invalid.Ticker = ""
+	require.Error(t, invalid.Validate())
+
+	invalid = ticker
+	invalid.Title = ""
+	require.Error(t, invalid.Validate())
+}
+
 func TestCompanyTicker_URI(t *testing.T) {
 	ticker := CompanyTicker{CIK: 100}
 	assert.Equal(t, "0000000100", ticker.URI())
 }
+
+func TestCompanyTickersExchange_Tickers(t *testing.T) {
+	tests := []struct {
+		name    string
+		tickers companyTickersExchange
+		wantErr bool
+		want    []CompanyTickerExchange
+	}{
+		{
+			name: "ok",
+			tickers: companyTickersExchange{
+				Fields: []string{"exchange", "ticker", "name", "cik"},
+				Data:   [][]any{{"NYSE", "BRK-B", "BERKSHIRE HATHAWAY INC", 1067983.0}},
+			},
+			want: []CompanyTickerExchange{
+				{
+					CompanyTicker: CompanyTicker{
+						CIK: 1067983, Ticker: "BRK-B", Title: "BERKSHIRE HATHAWAY INC",
+					},
+					Exchange: "NYSE",
+				},
+			},
+		},
+		{
+			name: "unknown fields",
+			tickers: companyTickersExchange{
+				Fields: []string{"cik", "name", "ticker"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "short row",
+			tickers: companyTickersExchange{
+				Fields: []string{"cik", "name", "ticker", "exchange"},
+				Data:   [][]any{{1067983.0, "BERKSHIRE HATHAWAY INC"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "CIK not a number",
+			tickers: companyTickersExchange{
+				Fields: []string{"cik", "name", "ticker", "exchange"},
+				Data:   [][]any{{"1067983", "BERKSHIRE HATHAWAY INC", "BRK-B", "NYSE"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tickers.Tickers()
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -1,11 +1,16 @@
 package client
 
 import (
-	"path/filepath"
+	"encoding/json"
+	"fmt"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 )
 
+const qtrPrefix = "QTR"
+
 func NewQtr(date time.Time) Qtr {
 	y, m, _ := date.Date()
 	return Qtr{year: y, qtr: monthQtr(int(m))}
@@ -23,11 +28,11 @@ func monthQtr(month int) int {
 }
 
 func (self *Qtr) Path() string {
-	return filepath.Join(strconv.Itoa(self.year), self.QTR())
+	return path.Join(strconv.Itoa(self.year), self.QTR())
 }
 
 func (self *Qtr) QTR() string {
-	return "QTR" + strconv.Itoa(self.qtr)
+	return qtrPrefix + strconv.Itoa(self.qtr)
 }
 
 func (self *Qtr) Next() string {
@@ -39,3 +44,60 @@ func (self *Qtr) Next() string {
 	}
 	return self.Path()
 }
+
+// After reports whether quarter is after other quarter.
+func (self Qtr) After(other Qtr) bool {
+	if self.year != other.year {
+		return self.year > other.year
+	}
+	return self.qtr > other.qtr
+}
+
+// String returns quarter like "2023/QTR4", same as Path.
+func (self Qtr) String() string {
+	return self.Path()
+}
+
+// MarshalText implements [encoding.TextMarshaler]. It returns quarter like
+// "2023/QTR4".
+func (self Qtr) MarshalText() ([]byte, error) {
+	return []byte(self.Path()), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler]. It parses quarter like
+// "2023/QTR4".
+func (self *Qtr) UnmarshalText(b []byte) error {
+	s := string(b)
+	year, qtr, ok := strings.Cut(s, "/"+qtrPrefix)
+	if !ok || len(year) != 4 || len(qtr) != 1 {
+		return fmt.Errorf("invalid quarter %q, expected format like 2023/QTR4", s)
+	}
+
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return fmt.Errorf("invalid year of quarter %q: %w", s, err)
+	}
+
+	q, err := strconv.Atoi(qtr)
+	if err != nil {
+		return fmt.Errorf("invalid quarter %q: %w", s, err)
+	} else if q < 1 || q > 4 {
+		return fmt.Errorf("invalid quarter %q: %v out of range 1..4", s, q)
+	}
+
+	self.year, self.qtr = y, q
+	return nil
+}
+
+func (self Qtr) MarshalJSON() ([]byte, error) {
+	b, _ := self.MarshalText()
+	return json.Marshal(string(b)) //nolint:wrapcheck // string can't fail
+}
+
+func (self *Qtr) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("unmarshal quarter: %w", err)
+	}
+	return self.UnmarshalText([]byte(s))
+}
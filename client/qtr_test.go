@@ -1,10 +1,13 @@
 package client
 
 import (
+	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewQtr(t *testing.T) {
@@ -31,3 +34,80 @@ func TestQtr_Next(t *testing.T) {
 	wantPaths := [...]string{"2023/QTR2", "2023/QTR3", "2023/QTR4", "2024/QTR1"}
 	assert.Equal(t, wantPaths, paths)
 }
+
+func TestQtr_After(t *testing.T) {
+	qtr := NewQtr(time.Date(2023, time.October, 25, 0, 0, 0, 0, time.UTC))
+	assert.False(t, qtr.After(qtr))
+	assert.True(t, qtr.After(NewQtr(time.Date(2023, time.July, 1, 0, 0, 0, 0,
+		time.UTC))))
+	assert.True(t, qtr.After(NewQtr(time.Date(2022, time.December, 1, 0, 0, 0, 0,
+		time.UTC))))
+	assert.False(t, qtr.After(NewQtr(time.Date(2024, time.January, 1, 0, 0, 0, 0,
+		time.UTC))))
+	assert.False(t, qtr.After(NewQtr(time.Date(2023, time.November, 1, 0, 0, 0,
+		0, time.UTC))))
+}
+
+func TestQtr_MarshalText(t *testing.T) {
+	qtr := NewQtr(time.Date(2023, time.October, 25, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, "2023/QTR4", qtr.String())
+	assert.Equal(t, "2023/QTR4", fmt.Sprint(qtr))
+
+	b, err := qtr.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "2023/QTR4", string(b))
+
+	var got Qtr
+	require.NoError(t, got.UnmarshalText(b))
+	assert.Equal(t, qtr, got)
+}
+
+func TestQtr_UnmarshalText_invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"2023",
+		"2023/QTR",
+		"2023/QTR0",
+		"2023/QTR5",
+		"2023/QTR12",
+		"2023/QTRx",
+		"2023-QTR1",
+		"23/QTR1",
+		"abcd/QTR1",
+		"2023/qtr1",
+	}
+
+	for _, s := range tests {
+		t.Run(s, func(t *testing.T) {
+			qtr := NewQtr(time.Date(2023, time.October, 25, 0, 0, 0, 0, time.UTC))
+			require.Error(t, qtr.UnmarshalText([]byte(s)))
+			assert.Equal(t, "2023/QTR4", qtr.String(), "changed by error")
+		})
+	}
+}
+
+func TestQtr_MarshalJSON(t *testing.T) {
+	type manifest struct {
+		Qtr  Qtr   `json:"qtr"`
+		Qtrs []Qtr `json:"qtrs"`
+	}
+
+	want := manifest{
+		Qtr: NewQtr(time.Date(2023, time.October, 25, 0, 0, 0, 0, time.UTC)),
+		Qtrs: []Qtr{
+			NewQtr(time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)),
+			NewQtr(time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)),
+		},
+	}
+	b, err := json.Marshal(&want)
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`{"qtr": "2023/QTR4", "qtrs": ["2023/QTR1", "2024/QTR2"]}`, string(b))
+
+	var got manifest
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, want, got)
+
+	require.Error(t, json.Unmarshal([]byte(`{"qtr": 2023}`), &got))
+	require.Error(t, json.Unmarshal([]byte(`{"qtr": "2023/QTR5"}`), &got))
+}
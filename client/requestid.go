@@ -0,0 +1,50 @@
+package client
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// WithRequestID sets X-Request-ID header of every request to ID returned by fn,
+// like UUID, for correlating requests with EDGAR's logs. Empty ID isn't set.
+// Every request with ID is logged at DEBUG level together with its ID, see
+// WithLogger.
+func WithRequestID(fn func() string) ClientOption {
+	return func(c *Client) { c.requestID = fn }
+}
+
+// WithRandomRequestID is like WithRequestID, but generates random UUIDs.
+func WithRandomRequestID() ClientOption {
+	return WithRequestID(newUUID)
+}
+
+// newUUID returns random UUID version 4, see RFC 9562, or empty string if
+// crypto/rand fails.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func (self *Client) setRequestID(req *http.Request) {
+	if self.requestID == nil {
+		return
+	}
+
+	id := self.requestID()
+	if id == "" {
+		return
+	}
+	req.Header.Set(requestIDHeader, id)
+	self.log().LogAttrs(req.Context(), slog.LevelDebug, "EDGAR request",
+		slog.String("method", req.Method), slog.String("url", req.URL.String()),
+		slog.String("request_id", id))
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dsh2dsh/edgar/internal/mocks/client"
+)
+
+func TestClient_WithRequestID(t *testing.T) {
+	ctx := context.Background()
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs,
+		&slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	ids := []string{"id-1", ""}
+	httpClient := client.NewMockHttpRequestDoer(t)
+	c := New(WithHttpClient(httpClient), WithRateLimiter(nil), WithLogger(logger),
+		WithRequestID(func() string {
+			id := ids[0]
+			ids = ids[1:]
+			return id
+		}))
+
+	var gotIds []string
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			gotIds = append(gotIds, req.Header.Get(requestIDHeader))
+			return httptest.NewRecorder().Result(), nil
+		})
+
+	for range 2 {
+		resp, err := c.Get(ctx, "https://localhost/foo")
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, []string{"id-1", ""}, gotIds)
+	assert.Contains(t, logs.String(), "request_id=id-1")
+	assert.Contains(t, logs.String(), "url=https://localhost/foo")
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("request_id=")))
+}
+
+func TestClient_WithRandomRequestID(t *testing.T) {
+	httpClient := client.NewMockHttpRequestDoer(t)
+	c := New(WithHttpClient(httpClient), WithRateLimiter(nil),
+		WithRandomRequestID())
+
+	var gotIds []string
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			gotIds = append(gotIds, req.Header.Get(requestIDHeader))
+			return httptest.NewRecorder().Result(), nil
+		})
+
+	for range 2 {
+		resp, err := c.Get(context.Background(), "https://localhost/foo")
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	uuidRe := regexp.MustCompile(
+		`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	require.Len(t, gotIds, 2)
+	for _, id := range gotIds {
+		assert.Regexp(t, uuidRe, id)
+	}
+	assert.NotEqual(t, gotIds[0], gotIds[1])
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	searchBaseURL  = "https://efts.sec.gov/LATEST"
+	searchURI      = "/search-index"
+	searchPageSize = 100 // EDGAR full-text search returns 100 hits per page
+	searchDate     = time.DateOnly
+)
+
+// SearchOptions configures CompanySearch.
+type SearchOptions struct {
+	// FormType limits results to filings of this form type, like "10-K". Empty
+	// means any form.
+	FormType string
+	// DateRange limits results by filing date. Zero Start or End means
+	// unbounded.
+	DateRange DateRange
+	// MaxResults limits number of returned results. Zero means first page of
+	// results only, which is 100 results.
+	MaxResults int
+}
+
+// DateRange is a range of dates, both ends inclusive.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SearchResult is one filing found by CompanySearch.
+type SearchResult struct {
+	EntityName  string
+	CIK         uint32
+	AccessionNo string
+	FiledAt     time.Time
+	FormType    string
+}
+
+// WithSearchBaseURL sets base URL of EDGAR full-text search, which is
+// https://efts.sec.gov/LATEST by default.
+func WithSearchBaseURL(u string) ClientOption {
+	return func(c *Client) { c.searchBaseURL = u }
+}
+
+// CompanySearch searches filings by query using EDGAR full-text search, see
+// https://efts.sec.gov/LATEST/search-index. query can be company name, CIK or
+// any text of filings. Filings with more than one filer returned as one
+// result per filer.
+func (self *Client) CompanySearch(ctx context.Context, query string,
+	opts SearchOptions,
+) ([]SearchResult, error) {
+	maxResults := opts.MaxResults
+	if maxResults <= 0 {
+		maxResults = searchPageSize
+	}
+
+	results := make([]SearchResult, 0, min(maxResults, searchPageSize))
+	for from := 0; len(results) < maxResults; from += searchPageSize {
+		url, err := self.searchURL(query, &opts, from)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp searchResponse
+		if err := self.GetJSON(ctx, url, &resp); err != nil {
+			return nil, err
+		}
+
+		for i := range resp.Hits.Hits {
+			source := &resp.Hits.Hits[i].Source
+			r, err := source.Results()
+			if err != nil {
+				return nil, fmt.Errorf("search %q: %w", query, err)
+			}
+			results = append(results, r...)
+		}
+
+		if len(resp.Hits.Hits) < searchPageSize ||
+			from+searchPageSize >= resp.Hits.Total.Value {
+			break
+		}
+	}
+
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return results, nil
+}
+
+func (self *Client) searchURL(query string, opts *SearchOptions, from int,
+) (string, error) {
+	baseURL := self.searchBaseURL
+	if baseURL == "" {
+		baseURL = searchBaseURL
+	}
+
+	u, err := url.Parse(baseURL + searchURI)
+	if err != nil {
+		return "", fmt.Errorf("parse search URL %q: %w", baseURL, err)
+	}
+
+	q := url.Values{}
+	q.Set("q", query)
+	if opts.FormType != "" {
+		q.Set("forms", opts.FormType)
+	}
+
+	if r := &opts.DateRange; !r.Start.IsZero() || !r.End.IsZero() {
+		q.Set("dateRange", "custom")
+		if !r.Start.IsZero() {
+			q.Set("startdt", r.Start.Format(searchDate))
+		}
+		if !r.End.IsZero() {
+			q.Set("enddt", r.End.Format(searchDate))
+		}
+	}
+
+	if from > 0 {
+		q.Set("from", strconv.Itoa(from))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+type searchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			Source searchSource `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+type searchSource struct {
+	CIKs         []string `json:"ciks"`
+	DisplayNames []string `json:"display_names"`
+	AccessionNo  string   `json:"adsh"`
+	FileDate     string   `json:"file_date"`
+	Form         string   `json:"form"`
+}
+
+func (self *searchSource) Results() ([]SearchResult, error) {
+	filedAt, err := time.Parse(searchDate, self.FileDate)
+	if err != nil {
+		return nil, fmt.Errorf("parse file_date of %v: %w", self.AccessionNo, err)
+	}
+
+	results := make([]SearchResult, len(self.CIKs))
+	for i, s := range self.CIKs {
+		cik, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parse cik of %v: %w", self.AccessionNo, err)
+		}
+		r := &results[i]
+		r.CIK = uint32(cik)
+		r.AccessionNo = self.AccessionNo
+		r.FiledAt = filedAt
+		r.FormType = self.Form
+		if i < len(self.DisplayNames) {
+			r.EntityName = searchEntityName(self.DisplayNames[i])
+		}
+	}
+	return results, nil
+}
+
+// searchEntityName returns name of company from display name like
+// "Apple Inc.  (AAPL)  (CIK 0000320193)".
+func searchEntityName(displayName string) string {
+	name, _, _ := strings.Cut(displayName, "  (")
+	name, _, _ = strings.Cut(name, " (CIK ")
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSearchServer(t *testing.T, total int,
+	checkQuery func(q url.Values),
+) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, searchURI, r.URL.Path)
+			q := r.URL.Query()
+			if checkQuery != nil {
+				checkQuery(q)
+			}
+
+			var from int
+			if s := q.Get("from"); s != "" {
+				n, err := strconv.Atoi(s)
+				require.NoError(t, err)
+				from = n
+			}
+
+			var resp searchResponse
+			resp.Hits.Total.Value = total
+			for i := from; i < min(from+searchPageSize, total); i++ {
+				var hit struct {
+					Source searchSource `json:"_source"`
+				}
+				hit.Source = searchSource{
+					CIKs:         []string{"0000320193"},
+					DisplayNames: []string{"Apple Inc.  (AAPL)  (CIK 0000320193)"},
+					AccessionNo:  fmt.Sprintf("0000320193-23-%06d", i),
+					FileDate:     "2023-11-03",
+					Form:         "10-K",
+				}
+				resp.Hits.Hits = append(resp.Hits.Hits, hit)
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(&resp))
+		}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_CompanySearch(t *testing.T) {
+	ctx := context.Background()
+	srv := newSearchServer(t, 250, func(q url.Values) {
+		assert.Equal(t, "Apple", q.Get("q"))
+		assert.Equal(t, "10-K", q.Get("forms"))
+		assert.Equal(t, "custom", q.Get("dateRange"))
+		assert.Equal(t, "2023-01-01", q.Get("startdt"))
+		assert.Equal(t, "2023-12-31", q.Get("enddt"))
+	})
+	c := testNew(t, WithSearchBaseURL(srv.URL), WithRateLimiter(nil))
+
+	opts := SearchOptions{
+		FormType: "10-K",
+		DateRange: DateRange{
+			Start: time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	results, err := c.CompanySearch(ctx, "Apple", opts)
+	require.NoError(t, err)
+	require.Len(t, results, searchPageSize)
+	assert.Equal(t, SearchResult{
+		EntityName:  "Apple Inc.",
+		CIK:         appleCIK,
+		AccessionNo: "0000320193-23-000000",
+		FiledAt:     time.Date(2023, time.November, 3, 0, 0, 0, 0, time.UTC),
+		FormType:    "10-K",
+	}, results[0])
+
+	opts.MaxResults = 150
+	results, err = c.CompanySearch(ctx, "Apple", opts)
+	require.NoError(t, err)
+	require.Len(t, results, 150)
+	assert.Equal(t, "0000320193-23-000149", results[149].AccessionNo)
+
+	opts.MaxResults = 1000
+	results, err = c.CompanySearch(ctx, "Apple", opts)
+	require.NoError(t, err)
+	assert.Len(t, results, 250)
+}
+
+func TestClient_CompanySearch_noOptions(t *testing.T) {
+	srv := newSearchServer(t, 0, func(q url.Values) {
+		assert.Equal(t, url.Values{"q": {"320193"}}, q)
+	})
+	c := testNew(t, WithSearchBaseURL(srv.URL), WithRateLimiter(nil))
+
+	results, err := c.CompanySearch(context.Background(), "320193",
+		SearchOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestClient_CompanySearch_error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+	t.Cleanup(srv.Close)
+	c := testNew(t, WithSearchBaseURL(srv.URL), WithRateLimiter(nil))
+
+	_, err := c.CompanySearch(context.Background(), "Apple", SearchOptions{})
+	require.ErrorIs(t, err, ErrUnexpectedStatus)
+
+	c = testNew(t, WithSearchBaseURL(":localhost"))
+	_, err = c.CompanySearch(context.Background(), "Apple", SearchOptions{})
+	require.Error(t, err)
+}
+
+func TestSearchSource_Results(t *testing.T) {
+	source := searchSource{
+		CIKs: []string{"0000320193", "0001067983"},
+		DisplayNames: []string{
+			"Apple Inc.  (AAPL)  (CIK 0000320193)",
+			"BERKSHIRE HATHAWAY INC (CIK 0001067983)",
+		},
+		AccessionNo: "0000320193-23-000106",
+		FileDate:    "2023-11-03",
+		Form:        "10-K",
+	}
+	results, err := source.Results()
+	require.NoError(t, err)
+	filedAt := time.Date(2023, time.November, 3, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []SearchResult{
+		{
+			EntityName:  "Apple Inc.",
+			CIK:         320193,
+			AccessionNo: source.AccessionNo,
+			FiledAt:     filedAt,
+			FormType:    "10-K",
+		},
+		{
+			EntityName:  "BERKSHIRE HATHAWAY INC",
+			CIK:         1067983,
+			AccessionNo: source.AccessionNo,
+			FiledAt:     filedAt,
+			FormType:    "10-K",
+		},
+	}, results)
+
+	source.CIKs[1] = "not a CIK"
+	_, err = source.Results()
+	require.Error(t, err)
+
+	source.FileDate = "not a date"
+	_, err = source.Results()
+	require.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnString(t *testing.T) {
+	const edgarURL = "postgres://edgar@localhost:5432/edgar"
+	const databaseURL = "postgres://app@localhost:5432/app"
+
+	tests := []struct {
+		name        string
+		edgarURL    string
+		databaseURL string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "both set",
+			edgarURL:    edgarURL,
+			databaseURL: databaseURL,
+			want:        edgarURL,
+		},
+		{
+			name:     "EDGAR_DB_URL only",
+			edgarURL: edgarURL,
+			want:     edgarURL,
+		},
+		{
+			name:        "DATABASE_URL only",
+			databaseURL: databaseURL,
+			want:        databaseURL,
+		},
+		{
+			name:    "neither set",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDGAR_DB_URL", tt.edgarURL)
+			t.Setenv("DATABASE_URL", tt.databaseURL)
+			connURL, err := connString()
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "EDGAR_DB_URL")
+				assert.Contains(t, err.Error(), "DATABASE_URL")
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, connURL)
+			}
+		})
+	}
+}
+
+func TestValidateParallelism(t *testing.T) {
+	for _, n := range []int{1, uploadProcs, maxParallelism} {
+		require.NoError(t, validateParallelism(n), n)
+	}
+	for _, n := range []int{-1, 0, maxParallelism + 1, 1000} {
+		require.Error(t, validateParallelism(n), n)
+	}
+}
+
+func TestParseSinceDate(t *testing.T) {
+	since, err := parseSinceDate("")
+	require.NoError(t, err)
+	assert.True(t, since.IsZero())
+
+	since, err = parseSinceDate("2024-01-12")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC), since)
+
+	_, err = parseSinceDate("12.01.2024")
+	require.Error(t, err)
+
+	_, err = parseSinceDate(time.Now().AddDate(0, 0, 2).Format(time.DateOnly))
+	require.ErrorContains(t, err, "in the future")
+}
+
+func TestNewLogHandler(t *testing.T) {
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	h, err := newLogHandler(&buf, "json", "WARN", false)
+	require.NoError(t, err)
+	assert.False(t, h.Enabled(ctx, slog.LevelInfo))
+	assert.True(t, h.Enabled(ctx, slog.LevelWarn))
+	slog.New(h).Warn("test", slog.Int("n", 1))
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "test", record[slog.MessageKey])
+
+	buf.Reset()
+	h, err = newLogHandler(&buf, "text", "info", false)
+	require.NoError(t, err)
+	assert.False(t, h.Enabled(ctx, slog.LevelDebug))
+	slog.New(h).Info("test")
+	assert.Contains(t, buf.String(), "msg=test")
+
+	h, err = newLogHandler(&buf, "text", "ERROR", true)
+	require.NoError(t, err)
+	assert.True(t, h.Enabled(ctx, slog.LevelDebug), "verbose overrides level")
+
+	_, err = newLogHandler(&buf, "xml", "INFO", false)
+	require.ErrorContains(t, err, "--log-format")
+	_, err = newLogHandler(&buf, "text", "TRACE", false)
+	require.ErrorContains(t, err, "--log-level")
+}
+
+func TestConnectWithRetry(t *testing.T) {
+	ctx := context.Background()
+	_, err := ConnectWithRetry(ctx, "not a db URL", 3, time.Hour)
+	require.ErrorContains(t, err, "parse db URL")
+
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	const connURL = "postgres://edgar@127.0.0.1:1/edgar?connect_timeout=1"
+	_, err = ConnectWithRetry(ctx, connURL, 2, time.Millisecond)
+	require.ErrorContains(t, err, "ping db")
+	assert.Equal(t, 2, bytes.Count(logs.Bytes(), []byte("retrying")))
+
+	logs.Reset()
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = ConnectWithRetry(ctx, connURL, 2, time.Hour)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, logs.String())
+}
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dsh2dsh/edgar/internal/repo"
+)
+
+var (
+	companyCIK    uint32
+	companyName   string
+	companyOffset int
+	companyLimit  int
+	companyJSON   bool
+	companyYes    bool
+
+	companyCmd = cobra.Command{
+		Use:   "company",
+		Short: "View and manage stored companies",
+	}
+
+	companyGetCmd = cobra.Command{
+		Use:   "get",
+		Short: "Print stored company",
+		Run: func(cmd *cobra.Command, args []string) {
+			cobra.CheckErr(withRepo(func(ctx context.Context, r *repo.Repo) error {
+				return printCompany(ctx, cmd.OutOrStdout(), r, companyCIK)
+			}))
+		},
+	}
+
+	companyListCmd = cobra.Command{
+		Use:   "list",
+		Short: "Print stored companies ordered by CIK",
+		Run: func(cmd *cobra.Command, args []string) {
+			cobra.CheckErr(withRepo(func(ctx context.Context, r *repo.Repo) error {
+				return printCompanies(ctx, cmd.OutOrStdout(), r, companyOffset,
+					companyLimit)
+			}))
+		},
+	}
+
+	companyUpdateNameCmd = cobra.Command{
+		Use:   "update-name",
+		Short: "Change name of stored company",
+		Run: func(cmd *cobra.Command, args []string) {
+			cobra.CheckErr(withRepo(func(ctx context.Context, r *repo.Repo) error {
+				return renameCompany(ctx, cmd.OutOrStdout(), r, companyCIK,
+					companyName)
+			}))
+		},
+	}
+
+	companyDeleteCmd = cobra.Command{
+		Use:   "delete",
+		Short: "Delete stored company with all its fact units",
+		Run: func(cmd *cobra.Command, args []string) {
+			cobra.CheckErr(withRepo(func(ctx context.Context, r *repo.Repo) error {
+				in := cmd.InOrStdin()
+				if companyYes {
+					in = nil
+				}
+				return deleteCompany(ctx, in, cmd.ErrOrStderr(), cmd.OutOrStdout(), r,
+					companyCIK)
+			}))
+		},
+	}
+)
+
+func init() {
+	companyCmd.AddCommand(&companyGetCmd, &companyListCmd,
+		&companyUpdateNameCmd, &companyDeleteCmd)
+
+	companyCmd.PersistentFlags().BoolVar(&companyJSON, "json", false,
+		"print output as JSON")
+
+	for _, cmd := range [...]*cobra.Command{
+		&companyGetCmd, &companyUpdateNameCmd, &companyDeleteCmd,
+	} {
+		cmd.Flags().Uint32Var(&companyCIK, "cik", 0, "CIK of company")
+		cobra.CheckErr(cmd.MarkFlagRequired("cik"))
+	}
+
+	companyListCmd.Flags().IntVar(&companyOffset, "offset", 0,
+		"skip this number of companies")
+	companyListCmd.Flags().IntVar(&companyLimit, "limit", 20,
+		"print up to this number of companies")
+
+	companyUpdateNameCmd.Flags().StringVar(&companyName, "name", "",
+		"new name of company")
+	cobra.CheckErr(companyUpdateNameCmd.MarkFlagRequired("name"))
+
+	companyDeleteCmd.Flags().BoolVarP(&companyYes, "yes", "y", false,
+		"don't ask for confirmation")
+}
+
+type companyRepo interface {
+	Company(ctx context.Context, cik uint32) (repo.Company, error)
+	Companies(ctx context.Context, offset, limit int) ([]repo.Company, error)
+	UpdateCompanyName(ctx context.Context, cik uint32, name string) (bool, error)
+	DeleteCompany(ctx context.Context, cik uint32) (bool, error)
+}
+
+func printCompany(ctx context.Context, w io.Writer, r companyRepo, cik uint32,
+) error {
+	company, err := r.Company(ctx, cik)
+	if err != nil {
+		return fmt.Errorf("get company: %w", err)
+	} else if companyJSON {
+		return printJSON(w, &company)
+	}
+
+	_, err = fmt.Fprintf(w, "CIK:  %v\nName: %v\n", company.CIK, company.Name)
+	if err != nil {
+		return fmt.Errorf("print company: %w", err)
+	}
+	return nil
+}
+
+func printCompanies(ctx context.Context, w io.Writer, r companyRepo,
+	offset, limit int,
+) error {
+	companies, err := r.Companies(ctx, offset, limit)
+	if err != nil {
+		return fmt.Errorf("list companies: %w", err)
+	} else if companyJSON {
+		return printJSON(w, companies)
+	}
+
+	for i := range companies {
+		company := &companies[i]
+		if _, err := fmt.Fprintf(w, "%10v  %v\n", company.CIK, company.Name); err != nil {
+			return fmt.Errorf("print companies: %w", err)
+		}
+	}
+	return nil
+}
+
+func renameCompany(ctx context.Context, w io.Writer, r companyRepo, cik uint32,
+	name string,
+) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("update company name: empty name")
+	}
+
+	updated, err := r.UpdateCompanyName(ctx, cik, name)
+	if err != nil {
+		return fmt.Errorf("update company name: %w", err)
+	}
+	return printCompanyResult(w, cik, "updated", updated)
+}
+
+// deleteCompany asks for confirmation from in, writing the question into
+// prompt, before deleting company cik. If in is nil it doesn't ask.
+func deleteCompany(ctx context.Context, in io.Reader, prompt, w io.Writer,
+	r companyRepo, cik uint32,
+) error {
+	if in != nil {
+		company, err := r.Company(ctx, cik)
+		if err != nil {
+			return fmt.Errorf("delete company: %w", err)
+		}
+
+		_, err = fmt.Fprintf(prompt,
+			"Delete company CIK=%v %q and all its fact units? [y/N] ",
+			company.CIK, company.Name)
+		if err != nil {
+			return fmt.Errorf("delete company: %w", err)
+		}
+
+		answer, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("read confirmation: %w", err)
+		}
+		if answer = strings.TrimSpace(answer); !strings.EqualFold(answer, "y") &&
+			!strings.EqualFold(answer, "yes") {
+			return printCompanyResult(w, cik, "deleted", false)
+		}
+	}
+
+	deleted, err := r.DeleteCompany(ctx, cik)
+	if err != nil {
+		return fmt.Errorf("delete company: %w", err)
+	}
+	return printCompanyResult(w, cik, "deleted", deleted)
+}
+
+func printCompanyResult(w io.Writer, cik uint32, action string, ok bool) error {
+	if companyJSON {
+		return printJSON(w, map[string]any{"cik": cik, action: ok})
+	}
+
+	msg := "not " + action
+	if ok {
+		msg = action
+	}
+	if _, err := fmt.Fprintf(w, "CIK=%v %v\n", cik, msg); err != nil {
+		return fmt.Errorf("print result: %w", err)
+	}
+	return nil
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("print JSON: %w", err)
+	}
+	return nil
+}
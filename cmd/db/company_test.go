@@ -0,0 +1,176 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dsh2dsh/edgar/internal/repo"
+)
+
+type fakeCompanyRepo struct {
+	companies []repo.Company
+	err       error
+	deleted   []uint32
+	renamed   map[uint32]string
+}
+
+func (self *fakeCompanyRepo) Company(ctx context.Context, cik uint32,
+) (repo.Company, error) {
+	if self.err != nil {
+		return repo.Company{}, self.err
+	}
+	for _, company := range self.companies {
+		if company.CIK == cik {
+			return company, nil
+		}
+	}
+	return repo.Company{}, errors.New("not found")
+}
+
+func (self *fakeCompanyRepo) Companies(ctx context.Context, offset, limit int,
+) ([]repo.Company, error) {
+	if self.err != nil {
+		return nil, self.err
+	}
+	offset = min(offset, len(self.companies))
+	return self.companies[offset:min(offset+limit, len(self.companies))], nil
+}
+
+func (self *fakeCompanyRepo) UpdateCompanyName(ctx context.Context, cik uint32,
+	name string,
+) (bool, error) {
+	if self.err != nil {
+		return false, self.err
+	}
+	if self.renamed == nil {
+		self.renamed = make(map[uint32]string)
+	}
+	self.renamed[cik] = name
+	return true, nil
+}
+
+func (self *fakeCompanyRepo) DeleteCompany(ctx context.Context, cik uint32,
+) (bool, error) {
+	if self.err != nil {
+		return false, self.err
+	}
+	self.deleted = append(self.deleted, cik)
+	return true, nil
+}
+
+func newFakeCompanyRepo() *fakeCompanyRepo {
+	return &fakeCompanyRepo{companies: []repo.Company{
+		{CIK: 320193, Name: "Apple Inc."},
+		{CIK: 1067983, Name: "BERKSHIRE HATHAWAY INC"},
+		{CIK: 1895262, Name: "Noble Corp"},
+	}}
+}
+
+func withCompanyJSON(t *testing.T, enabled bool) {
+	old := companyJSON
+	companyJSON = enabled
+	t.Cleanup(func() { companyJSON = old })
+}
+
+func TestPrintCompany(t *testing.T) {
+	ctx := context.Background()
+	r := newFakeCompanyRepo()
+
+	var buf bytes.Buffer
+	require.NoError(t, printCompany(ctx, &buf, r, 320193))
+	assert.Equal(t, "CIK:  320193\nName: Apple Inc.\n", buf.String())
+
+	withCompanyJSON(t, true)
+	buf.Reset()
+	require.NoError(t, printCompany(ctx, &buf, r, 320193))
+	assert.JSONEq(t, `{"cik": 320193, "name": "Apple Inc."}`, buf.String())
+
+	require.Error(t, printCompany(ctx, &buf, r, 1))
+}
+
+func TestPrintCompanies(t *testing.T) {
+	ctx := context.Background()
+	r := newFakeCompanyRepo()
+
+	var buf bytes.Buffer
+	require.NoError(t, printCompanies(ctx, &buf, r, 1, 20))
+	assert.Equal(t, `   1067983  BERKSHIRE HATHAWAY INC
+   1895262  Noble Corp
+`, buf.String())
+
+	withCompanyJSON(t, true)
+	buf.Reset()
+	require.NoError(t, printCompanies(ctx, &buf, r, 0, 1))
+	assert.JSONEq(t, `[{"cik": 320193, "name": "Apple Inc."}]`, buf.String())
+
+	wantErr := errors.New("test error")
+	r.err = wantErr
+	require.ErrorIs(t, printCompanies(ctx, &buf, r, 0, 1), wantErr)
+}
+
+func TestRenameCompany(t *testing.T) {
+	ctx := context.Background()
+	r := newFakeCompanyRepo()
+
+	var buf bytes.Buffer
+	require.NoError(t, renameCompany(ctx, &buf, r, 320193, "Apple Computer"))
+	assert.Equal(t, "CIK=320193 updated\n", buf.String())
+	assert.Equal(t, map[uint32]string{320193: "Apple Computer"}, r.renamed)
+
+	withCompanyJSON(t, true)
+	buf.Reset()
+	require.NoError(t, renameCompany(ctx, &buf, r, 320193, "Apple Inc."))
+	assert.JSONEq(t, `{"cik": 320193, "updated": true}`, buf.String())
+
+	require.Error(t, renameCompany(ctx, &buf, r, 320193, " "))
+
+	wantErr := errors.New("test error")
+	r.err = wantErr
+	require.ErrorIs(t, renameCompany(ctx, &buf, r, 320193, "Apple"), wantErr)
+}
+
+func TestDeleteCompany(t *testing.T) {
+	ctx := context.Background()
+	r := newFakeCompanyRepo()
+
+	var prompt, buf bytes.Buffer
+	require.NoError(t, deleteCompany(ctx, strings.NewReader("n\n"), &prompt,
+		&buf, r, 320193))
+	assert.Equal(t, `Delete company CIK=320193 "Apple Inc." and all its fact units? `+
+		"[y/N] ", prompt.String())
+	assert.Equal(t, "CIK=320193 not deleted\n", buf.String())
+	assert.Empty(t, r.deleted)
+
+	buf.Reset()
+	require.NoError(t, deleteCompany(ctx, strings.NewReader(""), &prompt, &buf,
+		r, 320193))
+	assert.Empty(t, r.deleted)
+
+	buf.Reset()
+	require.NoError(t, deleteCompany(ctx, strings.NewReader("Y\n"), &prompt,
+		&buf, r, 320193))
+	assert.Equal(t, "CIK=320193 deleted\n", buf.String())
+	assert.Equal(t, []uint32{320193}, r.deleted)
+
+	withCompanyJSON(t, true)
+	prompt.Reset()
+	buf.Reset()
+	require.NoError(t, deleteCompany(ctx, nil, &prompt, &buf, r, 1067983))
+	assert.Zero(t, prompt.Len())
+	assert.JSONEq(t, `{"cik": 1067983, "deleted": true}`, buf.String())
+	assert.Equal(t, []uint32{320193, 1067983}, r.deleted)
+
+	require.Error(t, deleteCompany(ctx, strings.NewReader("y\n"), &prompt,
+		&buf, r, 1))
+
+	wantErr := errors.New("test error")
+	r.err = wantErr
+	require.ErrorIs(t, deleteCompany(ctx, nil, &prompt, &buf, r, 320193),
+		wantErr)
+}
@@ -2,32 +2,51 @@ package db
 
 import (
 	"context"
-	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/sync/singleflight"
 )
 
 func newFacts() facts {
-	return facts{knownFacts: make(map[string]*knownFact, 0)}
+	return facts{knownFacts: make(map[string]*knownFact, 0), now: time.Now}
 }
 
 type facts struct {
 	knownFacts map[string]*knownFact
 	group      singleflight.Group
 	mu         sync.RWMutex
+	now        func() time.Time // sets lastUsed of facts
 }
 
+// Len returns number of known facts. It's safe for concurrent use, together
+// with Create, Preload and Evict.
 func (self *facts) Len() int {
 	self.mu.RLock()
 	defer self.mu.RUnlock()
 	return len(self.knownFacts)
 }
 
+// TotalLabelCount returns sum of LabelCount of all known facts.
+func (self *facts) TotalLabelCount() int {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	var n int
+	for _, fact := range self.knownFacts {
+		n += fact.LabelCount()
+	}
+	return n
+}
+
+// Fact returns known fact by key and marks it as used now, so Evict keeps it.
 func (self *facts) Fact(key string) (fact *knownFact, ok bool) {
 	self.mu.RLock()
 	defer self.mu.RUnlock()
-	fact, ok = self.knownFacts[key]
+	if fact, ok = self.knownFacts[key]; ok {
+		fact.touch(self.now())
+	}
 	return
 }
 
@@ -43,6 +62,7 @@ func (self *facts) Create(key string, labelHash, descrHash uint64,
 			return nil, err
 		}
 		fact := newKnownFact(factId, labelHash, descrHash)
+		fact.touch(self.now())
 		self.mu.Lock()
 		defer self.mu.Unlock()
 		self.knownFacts[key] = fact
@@ -55,14 +75,38 @@ func (self *facts) Create(key string, labelHash, descrHash uint64,
 	return v.(*knownFact), nil
 }
 
+// Evict removes facts, which weren't used during olderThan, and returns number
+// of removed facts. Removed facts will be created again on demand.
+func (self *facts) Evict(olderThan time.Duration) int {
+	deadline := self.now().Add(-olderThan).UnixNano()
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	var n int
+	for key, fact := range self.knownFacts {
+		if fact.lastUsed.Load() < deadline {
+			delete(self.knownFacts, key)
+			n++
+		}
+	}
+	return n
+}
+
 func (self *facts) Preload(factId uint32, key string,
 	labelHash, descrHash uint64,
 ) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
 	if fact, ok := self.knownFacts[key]; ok {
+		fact.mu.Lock()
+		defer fact.mu.Unlock()
 		fact.AddMoreLabel(labelHash, descrHash)
 		return false
 	}
-	self.knownFacts[key] = newKnownFact(factId, labelHash, descrHash)
+	fact := newKnownFact(factId, labelHash, descrHash)
+	fact.touch(self.now())
+	self.knownFacts[key] = fact
 	return true
 }
 
@@ -83,32 +127,54 @@ type knownFact struct {
 
 	moreLabels map[uint64]map[uint64]struct{}
 	mu         sync.Mutex
+	lastUsed   atomic.Int64 // UnixNano
 }
 
-func (self *knownFact) AddLabel(labelHash, descrHash uint64,
-	callback func() error,
-) error {
+func (self *knownFact) touch(now time.Time) {
+	self.lastUsed.Store(now.UnixNano())
+}
+
+// RememberLabel remembers label as known, after it was added into the repo.
+func (self *knownFact) RememberLabel(labelHash, descrHash uint64) {
 	if self.LabelHash == labelHash && self.DescrHash == descrHash {
-		return nil
+		return
 	}
 
 	self.mu.Lock()
 	defer self.mu.Unlock()
+	self.AddMoreLabel(labelHash, descrHash)
+}
 
-	if len(self.moreLabels) > 0 {
-		if descrMap, ok := self.moreLabels[labelHash]; ok {
-			if _, ok = descrMap[descrHash]; ok {
-				return nil
-			}
-		}
+// HasLabel reports whether label is known already.
+func (self *knownFact) HasLabel(labelHash, descrHash uint64) bool {
+	if self.LabelHash == labelHash && self.DescrHash == descrHash {
+		return true
 	}
 
-	if err := callback(); err != nil {
-		return fmt.Errorf("callback: %w", err)
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.hasMoreLabel(labelHash, descrHash)
+}
+
+func (self *knownFact) hasMoreLabel(labelHash, descrHash uint64) bool {
+	if descrMap, ok := self.moreLabels[labelHash]; ok {
+		_, ok = descrMap[descrHash]
+		return ok
 	}
+	return false
+}
 
-	self.AddMoreLabel(labelHash, descrHash)
-	return nil
+// LabelCount returns number of cached label entries: the primary label, every
+// extra label hash and every description hash of extra labels.
+func (self *knownFact) LabelCount() int {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	n := 1 + len(self.moreLabels)
+	for _, descrs := range self.moreLabels {
+		n += len(descrs)
+	}
+	return n
 }
 
 func (self *knownFact) AddMoreLabel(labelHash, descrHash uint64) {
@@ -3,7 +3,10 @@ package db
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,6 +47,7 @@ func TestFacts_Fact(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			facts := newFacts()
+			facts.now = zeroTime
 			fact, err := tt.assertCall(t, &facts)
 			if tt.errorIs != nil {
 				require.ErrorIs(t, err, tt.errorIs)
@@ -55,6 +59,34 @@ func TestFacts_Fact(t *testing.T) {
 	}
 }
 
+// TestFacts_Len_concurrent calls Len concurrently with Create and Preload. Run
+// it with go test -race for detecting data races.
+func TestFacts_Len_concurrent(t *testing.T) {
+	const n = 100
+	facts := newFacts()
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			_, err := facts.Create(fmt.Sprintf("us-gaap:Fact%v", i), 1, 1,
+				func() (uint32, error) { return uint32(i), nil })
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			facts.Preload(uint32(n+i), fmt.Sprintf("us-gaap:Preloaded%v", i), 1, 1)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.LessOrEqual(t, facts.Len(), 2*n)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2*n, facts.Len())
+}
+
 func TestFacts_Create(t *testing.T) {
 	const factKey = "us-gaap:AccountsPayable"
 
@@ -116,6 +148,7 @@ func TestFacts_Create(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			facts := newFacts()
+			facts.now = zeroTime
 			fact, err := tt.assertCall(t, &facts)
 			if tt.errorIs != nil {
 				require.ErrorIs(t, err, tt.errorIs)
@@ -166,6 +199,7 @@ func TestFacts_Preload(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			facts := newFacts()
+			facts.now = zeroTime
 			unknownFact := tt.assertCall(t, &facts)
 			assert.Equal(t, tt.unknownFact, unknownFact)
 			assert.Equal(t, tt.wantFacts(), facts.knownFacts)
@@ -173,130 +207,110 @@ func TestFacts_Preload(t *testing.T) {
 	}
 }
 
-func TestKnownFact_AddLabel(t *testing.T) {
-	callbackCalled := func(t *testing.T, err error) func() error {
-		var called bool
-		t.Cleanup(func() { assert.True(t, called, "callback wasn't called") })
-		return func() error {
-			called = true
-			return err
-		}
-	}
+// zeroTime replaces facts.now for comparing facts created by newKnownFact.
+func zeroTime() time.Time { return time.Unix(0, 0) }
 
-	callbackNotCalled := func(t *testing.T) func() error {
-		return func() error {
-			assert.Fail(t, "Shouldn't be called")
-			return nil
-		}
-	}
+func TestFacts_Evict(t *testing.T) {
+	now := time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)
+	facts := newFacts()
+	facts.now = func() time.Time { return now }
 
-	wantErr := errors.New("test error")
-	tests := []struct {
-		name       string
-		assertCall func(t *testing.T, fact *knownFact) error
-		errorIs    error
-		wantFact   *knownFact
-	}{
-		{
-			name: "direct hit",
-			assertCall: func(t *testing.T, fact *knownFact) error {
-				return fact.AddLabel(1, 1, callbackNotCalled(t))
-			},
-			wantFact: &knownFact{LabelHash: 1, DescrHash: 1},
-		},
-		{
-			name: "moreLabels",
-			assertCall: func(t *testing.T, fact *knownFact) error {
-				fact.moreLabels = map[uint64]map[uint64]struct{}{2: {2: {}}}
-				return fact.AddLabel(2, 2, callbackNotCalled(t))
-			},
-			wantFact: &knownFact{
-				LabelHash:  1,
-				DescrHash:  1,
-				moreLabels: map[uint64]map[uint64]struct{}{2: {2: {}}},
-			},
-		},
-		{
-			name: "with callback",
-			assertCall: func(t *testing.T, fact *knownFact) error {
-				return fact.AddLabel(2, 2, callbackCalled(t, nil))
-			},
-			wantFact: &knownFact{
-				LabelHash:  1,
-				DescrHash:  1,
-				moreLabels: map[uint64]map[uint64]struct{}{2: {2: {}}},
-			},
-		},
-		{
-			name: "with callback error",
-			assertCall: func(t *testing.T, fact *knownFact) error {
-				return fact.AddLabel(2, 2, callbackCalled(t, wantErr))
-			},
-			errorIs:  wantErr,
-			wantFact: &knownFact{LabelHash: 1, DescrHash: 1},
-		},
-		{
-			name: "added into moreLabels 1",
-			assertCall: func(t *testing.T, fact *knownFact) error {
-				fact.moreLabels = map[uint64]map[uint64]struct{}{}
-				return fact.AddLabel(2, 2, callbackCalled(t, nil))
-			},
-			wantFact: &knownFact{
-				LabelHash:  1,
-				DescrHash:  1,
-				moreLabels: map[uint64]map[uint64]struct{}{2: {2: {}}},
-			},
-		},
-		{
-			name: "added into moreLabels 2",
-			assertCall: func(t *testing.T, fact *knownFact) error {
-				fact.moreLabels = map[uint64]map[uint64]struct{}{2: {2: {}}}
-				return fact.AddLabel(2, 3, callbackCalled(t, nil))
-			},
-			wantFact: &knownFact{
-				LabelHash:  1,
-				DescrHash:  1,
-				moreLabels: map[uint64]map[uint64]struct{}{2: {2: {}, 3: {}}},
-			},
-		},
-		{
-			name: "with mutex",
-			assertCall: func(t *testing.T, fact *knownFact) error {
-				sig := make(chan struct{})
-				done := make(chan struct{})
-				err := fact.AddLabel(2, 2, func() error {
-					go func() {
-						close(sig)
-						err := fact.AddLabel(2, 3, callbackCalled(t, nil))
-						assert.NoError(t, err)
-						close(done)
-					}()
-					<-sig
-					return nil
-				})
-				<-done
-				return err
-			},
-			wantFact: &knownFact{
-				LabelHash:  1,
-				DescrHash:  1,
-				moreLabels: map[uint64]map[uint64]struct{}{2: {2: {}, 3: {}}},
-			},
-		},
-	}
+	assert.True(t, facts.Preload(1, "us-gaap:AccountsPayable", 1, 1))
+	now = now.Add(time.Minute)
+	_, err := facts.Create("us-gaap:Assets", 2, 2,
+		func() (uint32, error) { return 2, nil })
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+	assert.True(t, facts.Preload(3, "us-gaap:Liabilities", 3, 3))
+	require.Equal(t, 3, facts.Len())
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			fact := newKnownFact(0, 1, 1)
-			err := tt.assertCall(t, fact)
-			if tt.errorIs != nil {
-				require.ErrorIs(t, err, tt.errorIs)
-			} else {
-				require.NoError(t, err)
-			}
-			assert.Equal(t, tt.wantFact, fact)
-		})
-	}
+	assert.Zero(t, facts.Evict(3*time.Minute))
+	assert.Equal(t, 3, facts.Len())
+
+	assert.Equal(t, 2, facts.Evict(30*time.Second))
+	assert.Equal(t, 1, facts.Len())
+	_, ok := facts.Fact("us-gaap:Liabilities")
+	assert.True(t, ok)
+
+	assert.Zero(t, facts.Evict(30*time.Second))
+	assert.Equal(t, 1, facts.Evict(-time.Second))
+	assert.Zero(t, facts.Len())
+}
+
+func TestFacts_Evict_inUse(t *testing.T) {
+	now := time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)
+	facts := newFacts()
+	facts.now = func() time.Time { return now }
+
+	assert.True(t, facts.Preload(1, "us-gaap:AccountsPayable", 1, 1))
+	assert.True(t, facts.Preload(2, "us-gaap:Assets", 2, 2))
+	assert.False(t, facts.Preload(2, "us-gaap:Assets", 2, 3))
+
+	now = now.Add(time.Hour)
+	fact, ok := facts.Fact("us-gaap:Assets")
+	require.True(t, ok)
+	now = now.Add(time.Minute)
+
+	assert.Equal(t, 1, facts.Evict(30*time.Minute))
+	fact2, ok := facts.Fact("us-gaap:Assets")
+	require.True(t, ok, "fact in use evicted")
+	assert.Same(t, fact, fact2)
+	assert.True(t, fact2.HasLabel(2, 3))
+	_, ok = facts.Fact("us-gaap:AccountsPayable")
+	assert.False(t, ok)
+}
+
+func TestKnownFact_HasLabel(t *testing.T) {
+	fact := newKnownFact(0, 1, 1)
+	assert.True(t, fact.HasLabel(1, 1))
+	assert.False(t, fact.HasLabel(2, 2))
+	assert.False(t, fact.HasLabel(1, 2))
+
+	fact.moreLabels = map[uint64]map[uint64]struct{}{2: {2: {}}}
+	assert.True(t, fact.HasLabel(2, 2))
+	assert.False(t, fact.HasLabel(2, 3))
+}
+
+func TestKnownFact_RememberLabel(t *testing.T) {
+	fact := newKnownFact(0, 1, 1)
+	fact.RememberLabel(1, 1)
+	assert.Equal(t, &knownFact{LabelHash: 1, DescrHash: 1}, fact)
+
+	fact.RememberLabel(2, 2)
+	fact.RememberLabel(2, 3)
+	fact.RememberLabel(2, 2)
+	assert.Equal(t, &knownFact{
+		LabelHash:  1,
+		DescrHash:  1,
+		moreLabels: map[uint64]map[uint64]struct{}{2: {2: {}, 3: {}}},
+	}, fact)
+	assert.True(t, fact.HasLabel(2, 3))
+}
+
+func TestKnownFact_LabelCount(t *testing.T) {
+	fact := newKnownFact(1, 1, 1)
+	assert.Equal(t, 1, fact.LabelCount())
+
+	fact.AddMoreLabel(2, 2)
+	assert.Equal(t, 3, fact.LabelCount())
+
+	fact.AddMoreLabel(2, 3)
+	assert.Equal(t, 4, fact.LabelCount())
+
+	fact.AddMoreLabel(3, 3)
+	assert.Equal(t, 6, fact.LabelCount())
+}
+
+func TestFacts_TotalLabelCount(t *testing.T) {
+	facts := newFacts()
+	assert.Zero(t, facts.TotalLabelCount())
+
+	facts.Preload(1, "us-gaap:AccountsPayable", 1, 1)
+	facts.Preload(2, "us-gaap:Assets", 2, 2)
+	assert.Equal(t, 2, facts.TotalLabelCount())
+
+	facts.Preload(2, "us-gaap:Assets", 3, 3)
+	assert.Equal(t, 4, facts.TotalLabelCount())
 }
 
 func TestKnownFact_AddMoreLabel(t *testing.T) {
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dsh2dsh/edgar/internal/repo"
+)
+
+var (
+	gcDryRun bool
+
+	gcCmd = cobra.Command{
+		Use:   "gc",
+		Short: "Remove duplicate fact units",
+		Long: `Remove duplicate fact units.
+
+Fact units are duplicates, if they have the same company, fact, unit, period
+and accn. Only one of them is kept. Run it periodically, after update, for
+instance from cron.
+
+Update compares number of fact units, filed at last filed date, with EDGAR
+counting duplicates once, so it doesn't restore removed duplicates. But if
+update replaces fact units of a company for other reasons, EDGAR duplicates
+are copied again and next gc removes them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cobra.CheckErr(withRepo(func(ctx context.Context, r *repo.Repo) error {
+				return collectGarbage(ctx, r, gcDryRun)
+			}))
+		},
+	}
+)
+
+func init() {
+	gcCmd.Flags().BoolVarP(&gcDryRun, "dry-run", "n", false,
+		"count duplicate fact units without removing them")
+}
+
+type gcRepo interface {
+	CountDuplicateFactUnits(ctx context.Context) (int64, error)
+	DeleteDuplicateFactUnits(ctx context.Context) (int64, error)
+}
+
+// collectGarbage removes duplicate fact units and logs how many of them was
+// removed. If dryRun is true, it only counts and logs them.
+func collectGarbage(ctx context.Context, r gcRepo, dryRun bool) error {
+	if dryRun {
+		n, err := r.CountDuplicateFactUnits(ctx)
+		if err != nil {
+			return fmt.Errorf("count duplicate fact units: %w", err)
+		}
+		slog.Info("found duplicate fact units", slog.Int64("count", n))
+		return nil
+	}
+
+	n, err := r.DeleteDuplicateFactUnits(ctx)
+	if err != nil {
+		return fmt.Errorf("remove duplicate fact units: %w", err)
+	}
+	slog.Info("removed duplicate fact units", slog.Int64("count", n))
+	return nil
+}
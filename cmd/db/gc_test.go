@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeGCRepo struct {
+	dups    int64
+	err     error
+	counted bool
+	deleted bool
+}
+
+func (self *fakeGCRepo) CountDuplicateFactUnits(ctx context.Context,
+) (int64, error) {
+	self.counted = true
+	return self.dups, self.err
+}
+
+func (self *fakeGCRepo) DeleteDuplicateFactUnits(ctx context.Context,
+) (int64, error) {
+	self.deleted = true
+	return self.dups, self.err
+}
+
+func TestCollectGarbage(t *testing.T) {
+	ctx := context.Background()
+
+	r := &fakeGCRepo{dups: 3}
+	require.NoError(t, collectGarbage(ctx, r, false))
+	assert.True(t, r.deleted)
+	assert.False(t, r.counted)
+
+	r = &fakeGCRepo{dups: 3}
+	require.NoError(t, collectGarbage(ctx, r, true))
+	assert.True(t, r.counted)
+	assert.False(t, r.deleted)
+
+	wantErr := errors.New("test error")
+	r = &fakeGCRepo{err: wantErr}
+	require.ErrorIs(t, collectGarbage(ctx, r, false), wantErr)
+	require.ErrorIs(t, collectGarbage(ctx, r, true), wantErr)
+}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dsh2dsh/edgar/internal/repo"
+)
+
+const restoreBatchSize = 10_000 // rows restored at once
+
+var (
+	snapshotOutput string
+	restoreInput   string
+
+	snapshotCmd = cobra.Command{
+		Use:   "snapshot",
+		Short: "Write all stored data into portable backup file",
+		Long: `Write all stored data into portable backup file.
+
+The file contains gzip-compressed NDJSON, one row per line, and can be loaded
+into another database using restore.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cobra.CheckErr(withRepo(func(ctx context.Context, r *repo.Repo) error {
+				return writeSnapshotFile(ctx, snapshotOutput, r)
+			}))
+		},
+	}
+
+	restoreCmd = cobra.Command{
+		Use:   "restore",
+		Short: "Load data from backup file, created by snapshot",
+		Long: `Load data from backup file, created by snapshot.
+
+Intended for restoring into empty database, initialized by init. Rows, which
+already exist, are skipped, so it's safe to restore the same file again.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cobra.CheckErr(withRepo(func(ctx context.Context, r *repo.Repo) error {
+				return restoreSnapshotFile(ctx, restoreInput, r)
+			}))
+		},
+	}
+)
+
+func init() {
+	snapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "",
+		"write snapshot into this file, like snapshot.json.gz")
+	cobra.CheckErr(snapshotCmd.MarkFlagRequired("output"))
+
+	restoreCmd.Flags().StringVarP(&restoreInput, "input", "i", "",
+		"read snapshot from this file")
+	cobra.CheckErr(restoreCmd.MarkFlagRequired("input"))
+}
+
+type snapshotRepo interface {
+	StreamAllCompanies(ctx context.Context, fn func(item repo.Company) error) error
+	StreamAllFacts(ctx context.Context, fn func(item repo.FactInfo) error) error
+	StreamAllFactLabels(ctx context.Context, fn func(item repo.Label) error) error
+	StreamAllUnits(ctx context.Context, fn func(item repo.Unit) error) error
+	StreamFactUnits(ctx context.Context,
+		fn func(item repo.FactUnit) error) error
+}
+
+type restoreRepo interface {
+	RestoreCompanies(ctx context.Context, items []repo.Company) (int64, error)
+	RestoreFacts(ctx context.Context, items []repo.FactInfo) (int64, error)
+	RestoreFactLabels(ctx context.Context, items []repo.Label) (int64, error)
+	RestoreUnits(ctx context.Context, items []repo.Unit) (int64, error)
+	RestoreFactUnits(ctx context.Context, items []repo.FactUnit) (int64, error)
+}
+
+// snapshotRecord is a line of snapshot file.
+type snapshotRecord struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+func writeSnapshotFile(ctx context.Context, name string, r snapshotRepo,
+) (err error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("close snapshot: %w", closeErr))
+		}
+	}()
+	return writeSnapshot(ctx, f, r)
+}
+
+// writeSnapshot writes all tables into w as gzip-compressed NDJSON. Tables are
+// written in order, which allows restoring them without breaking foreign keys.
+func writeSnapshot(ctx context.Context, w io.Writer, r snapshotRepo) error {
+	bufw := bufio.NewWriter(w)
+	zw := gzip.NewWriter(bufw)
+	enc := json.NewEncoder(zw)
+
+	for _, fn := range [...]func() error{
+		func() error {
+			return writeSnapshotTable(ctx, enc, "companies", r.StreamAllCompanies)
+		},
+		func() error {
+			return writeSnapshotTable(ctx, enc, "facts", r.StreamAllFacts)
+		},
+		func() error {
+			return writeSnapshotTable(ctx, enc, "fact_labels", r.StreamAllFactLabels)
+		},
+		func() error {
+			return writeSnapshotTable(ctx, enc, "units", r.StreamAllUnits)
+		},
+		func() error {
+			return writeSnapshotTable(ctx, enc, "fact_units", r.StreamFactUnits)
+		},
+	} {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	} else if err := bufw.Flush(); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return nil
+}
+
+func writeSnapshotTable[T any](ctx context.Context, enc *json.Encoder,
+	table string,
+	stream func(ctx context.Context, fn func(item T) error) error,
+) error {
+	var n int
+	err := stream(ctx, func(item T) error {
+		row, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("marshal row: %w", err)
+		} else if err := enc.Encode(&snapshotRecord{Table: table, Row: row}); err != nil {
+			return fmt.Errorf("write row: %w", err)
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("snapshot %v: %w", table, err)
+	}
+	slog.Info("snapshot", slog.String("table", table), slog.Int("rows", n))
+	return nil
+}
+
+func restoreSnapshotFile(ctx context.Context, name string, r restoreRepo,
+) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("open snapshot: %w", err)
+	}
+	defer f.Close()
+	return restoreSnapshot(ctx, f, r)
+}
+
+// restoreSnapshot reads snapshot, written by writeSnapshot, from in and
+// restores all its rows, restoreBatchSize rows at once.
+func restoreSnapshot(ctx context.Context, in io.Reader, r restoreRepo) error {
+	zr, err := gzip.NewReader(bufio.NewReader(in))
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+	defer zr.Close()
+
+	tables := map[string]restoreTable{
+		"companies":   &restoreBuffer[repo.Company]{restore: r.RestoreCompanies},
+		"facts":       &restoreBuffer[repo.FactInfo]{restore: r.RestoreFacts},
+		"fact_labels": &restoreBuffer[repo.Label]{restore: r.RestoreFactLabels},
+		"units":       &restoreBuffer[repo.Unit]{restore: r.RestoreUnits},
+		"fact_units":  &restoreBuffer[repo.FactUnit]{restore: r.RestoreFactUnits},
+	}
+
+	dec := json.NewDecoder(zr)
+	var lastTable string
+	for {
+		var record snapshotRecord
+		if err := dec.Decode(&record); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("read snapshot: %w", err)
+		}
+
+		t, ok := tables[record.Table]
+		if !ok {
+			return fmt.Errorf("read snapshot: unknown table %q", record.Table)
+		} else if record.Table != lastTable && lastTable != "" {
+			// previous table must be restored first, because of foreign keys
+			if err := tables[lastTable].Flush(ctx, lastTable); err != nil {
+				return err
+			}
+		}
+		lastTable = record.Table
+
+		if err := t.Add(record.Row); err != nil {
+			return fmt.Errorf("restore %v: %w", record.Table, err)
+		} else if t.Len() >= restoreBatchSize {
+			if err := t.Flush(ctx, record.Table); err != nil {
+				return err
+			}
+		}
+	}
+
+	if lastTable != "" {
+		return tables[lastTable].Flush(ctx, lastTable)
+	}
+	return nil
+}
+
+type restoreTable interface {
+	Add(row json.RawMessage) error
+	Len() int
+	Flush(ctx context.Context, table string) error
+}
+
+type restoreBuffer[T any] struct {
+	items   []T
+	restore func(ctx context.Context, items []T) (int64, error)
+}
+
+func (self *restoreBuffer[T]) Add(row json.RawMessage) error {
+	var item T
+	if err := json.Unmarshal(row, &item); err != nil {
+		return fmt.Errorf("unmarshal row: %w", err)
+	}
+	self.items = append(self.items, item)
+	return nil
+}
+
+func (self *restoreBuffer[T]) Len() int { return len(self.items) }
+
+func (self *restoreBuffer[T]) Flush(ctx context.Context, table string) error {
+	if len(self.items) == 0 {
+		return nil
+	}
+
+	n, err := self.restore(ctx, self.items)
+	if err != nil {
+		return fmt.Errorf("restore %v: %w", table, err)
+	}
+	slog.Info("restore", slog.String("table", table),
+		slog.Int("rows", len(self.items)), slog.Int64("added", n))
+	self.items = self.items[:0]
+	return nil
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dsh2dsh/edgar/internal/repo"
+)
+
+type fakeSnapshotRepo struct {
+	companies []repo.Company
+	facts     []repo.FactInfo
+	labels    []repo.Label
+	units     []repo.Unit
+	factUnits []repo.FactUnit
+
+	err     error
+	batches []string
+}
+
+func streamItems[T any](items []T, err error, fn func(item T) error) error {
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (self *fakeSnapshotRepo) StreamAllCompanies(ctx context.Context,
+	fn func(item repo.Company) error,
+) error {
+	return streamItems(self.companies, self.err, fn)
+}
+
+func (self *fakeSnapshotRepo) StreamAllFacts(ctx context.Context,
+	fn func(item repo.FactInfo) error,
+) error {
+	return streamItems(self.facts, self.err, fn)
+}
+
+func (self *fakeSnapshotRepo) StreamAllFactLabels(ctx context.Context,
+	fn func(item repo.Label) error,
+) error {
+	return streamItems(self.labels, self.err, fn)
+}
+
+func (self *fakeSnapshotRepo) StreamAllUnits(ctx context.Context,
+	fn func(item repo.Unit) error,
+) error {
+	return streamItems(self.units, self.err, fn)
+}
+
+func (self *fakeSnapshotRepo) StreamFactUnits(ctx context.Context,
+	fn func(item repo.FactUnit) error,
+) error {
+	return streamItems(self.factUnits, self.err, fn)
+}
+
+func restoreItems[T any](self *fakeSnapshotRepo, table string, dst *[]T,
+	items []T,
+) (int64, error) {
+	if self.err != nil {
+		return 0, self.err
+	}
+	self.batches = append(self.batches, table)
+	*dst = append(*dst, items...)
+	return int64(len(items)), nil
+}
+
+func (self *fakeSnapshotRepo) RestoreCompanies(ctx context.Context,
+	items []repo.Company,
+) (int64, error) {
+	return restoreItems(self, "companies", &self.companies, items)
+}
+
+func (self *fakeSnapshotRepo) RestoreFacts(ctx context.Context,
+	items []repo.FactInfo,
+) (int64, error) {
+	return restoreItems(self, "facts", &self.facts, items)
+}
+
+func (self *fakeSnapshotRepo) RestoreFactLabels(ctx context.Context,
+	items []repo.Label,
+) (int64, error) {
+	return restoreItems(self, "fact_labels", &self.labels, items)
+}
+
+func (self *fakeSnapshotRepo) RestoreUnits(ctx context.Context,
+	items []repo.Unit,
+) (int64, error) {
+	return restoreItems(self, "units", &self.units, items)
+}
+
+func (self *fakeSnapshotRepo) RestoreFactUnits(ctx context.Context,
+	items []repo.FactUnit,
+) (int64, error) {
+	return restoreItems(self, "fact_units", &self.factUnits, items)
+}
+
+func testSnapshotRepo() *fakeSnapshotRepo {
+	fact := repo.FactUnit{
+		CIK:    320193,
+		FactId: 1,
+		UnitId: 2,
+		End:    time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC),
+		Val:    5520000000,
+		Accn:   "0001193125-09-153165",
+		FY:     2009,
+		FP:     "Q3",
+		Form:   "10-Q",
+		Filed:  time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC),
+	}
+	withStart := fact
+	withStart.WithStart(time.Date(2008, 6, 28, 0, 0, 0, 0, time.UTC)).
+		WithFrame("CY2008Q3")
+
+	return &fakeSnapshotRepo{
+		companies: []repo.Company{{CIK: 320193, Name: "Apple Inc."}},
+		facts:     []repo.FactInfo{{Id: 1, Tax: "us-gaap", Name: "AccountsPayable"}},
+		labels: []repo.Label{{
+			Id: 3, FactId: 1, Label: "Accounts Payable", Descr: "Payable",
+			LabelHash: 1<<64 - 1, DescrHash: 42,
+		}},
+		units:     []repo.Unit{{Id: 2, Name: "USD"}},
+		factUnits: []repo.FactUnit{fact, withStart},
+	}
+}
+
+func TestSnapshot_restore(t *testing.T) {
+	ctx := context.Background()
+	want := testSnapshotRepo()
+
+	var buf bytes.Buffer
+	require.NoError(t, writeSnapshot(ctx, &buf, want))
+
+	got := &fakeSnapshotRepo{}
+	require.NoError(t, restoreSnapshot(ctx, &buf, got))
+	assert.Equal(t, want.companies, got.companies)
+	assert.Equal(t, want.facts, got.facts)
+	assert.Equal(t, want.labels, got.labels)
+	assert.Equal(t, want.units, got.units)
+	assert.Equal(t, want.factUnits, got.factUnits)
+	assert.Equal(t,
+		[]string{"companies", "facts", "fact_labels", "units", "fact_units"},
+		got.batches)
+}
+
+func TestSnapshot_file(t *testing.T) {
+	ctx := context.Background()
+	name := filepath.Join(t.TempDir(), "snapshot.json.gz")
+	want := testSnapshotRepo()
+	require.NoError(t, writeSnapshotFile(ctx, name, want))
+
+	got := &fakeSnapshotRepo{}
+	require.NoError(t, restoreSnapshotFile(ctx, name, got))
+	assert.Equal(t, want.factUnits, got.factUnits)
+
+	require.Error(t, restoreSnapshotFile(ctx,
+		filepath.Join(t.TempDir(), "not-exists"), got))
+}
+
+func TestSnapshot_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	var buf bytes.Buffer
+	require.ErrorIs(t, writeSnapshot(ctx, &buf, &fakeSnapshotRepo{err: wantErr}),
+		wantErr)
+
+	buf.Reset()
+	require.NoError(t, writeSnapshot(ctx, &buf, testSnapshotRepo()))
+	require.ErrorIs(t,
+		restoreSnapshot(ctx, &buf, &fakeSnapshotRepo{err: wantErr}), wantErr)
+
+	require.Error(t, restoreSnapshot(ctx, bytes.NewReader([]byte("not gzip")),
+		&fakeSnapshotRepo{}))
+
+	buf.Reset()
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(`{"table":"unknown","row":{}}`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.ErrorContains(t, restoreSnapshot(ctx, &buf, &fakeSnapshotRepo{}),
+		"unknown table")
+}
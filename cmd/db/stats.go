@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats describes results of last Upload or Update.
+type Stats struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Duration   float64   `json:"durationSeconds"`
+
+	Companies uint64 `json:"companies"` // successfully processed companies
+	FactUnits uint64 `json:"factUnits"` // stored fact units
+	Errors    uint64 `json:"errors"`    // failed companies, including skipped
+
+	Error string `json:"error,omitempty"`
+}
+
+type uploadStats struct {
+	companies atomic.Uint64
+	factUnits atomic.Uint64
+	errors    atomic.Uint64
+
+	mu         sync.Mutex
+	startedAt  time.Time
+	finishedAt time.Time
+	err        error
+}
+
+func (self *uploadStats) Start() {
+	self.companies.Store(0)
+	self.factUnits.Store(0)
+	self.errors.Store(0)
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.startedAt = time.Now()
+	self.finishedAt = time.Time{}
+	self.err = nil
+}
+
+func (self *uploadStats) Finish(err error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.finishedAt = time.Now()
+	self.err = err
+}
+
+func (self *uploadStats) Stats() Stats {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	stats := Stats{
+		StartedAt:  self.startedAt,
+		FinishedAt: self.finishedAt,
+		Companies:  self.companies.Load(),
+		FactUnits:  self.factUnits.Load(),
+		Errors:     self.errors.Load(),
+	}
+	if !stats.FinishedAt.IsZero() {
+		stats.Duration = stats.FinishedAt.Sub(stats.StartedAt).Seconds()
+	}
+	if self.err != nil {
+		stats.Error = self.err.Error()
+	}
+	return stats
+}
+
+// writeStatsFile writes stats as JSON into fname. It writes into temporary file
+// first and renames it to fname after that, so fname is always complete.
+func writeStatsFile(fname string, stats Stats) error {
+	b, err := json.MarshalIndent(&stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal stats: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(fname), filepath.Base(fname)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp stats file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write stats into %q: %w", f.Name(), err)
+	} else if err := f.Close(); err != nil {
+		return fmt.Errorf("close %q: %w", f.Name(), err)
+	}
+
+	if err := os.Rename(f.Name(), fname); err != nil {
+		return fmt.Errorf("rename stats file: %w", err)
+	}
+	return nil
+}
+
+type repoCounter interface {
+	CompanyCount(ctx context.Context) (int64, error)
+	FactUnitCount(ctx context.Context) (int64, error)
+	CountFactsByTaxonomy(ctx context.Context) (map[string]int64, error)
+	CountFactUnitsByTaxonomy(ctx context.Context) (map[string]int64, error)
+}
+
+func printRepoStats(ctx context.Context, w io.Writer, r repoCounter) error {
+	companies, err := r.CompanyCount(ctx)
+	if err != nil {
+		return fmt.Errorf("count companies: %w", err)
+	}
+
+	factUnits, err := r.FactUnitCount(ctx)
+	if err != nil {
+		return fmt.Errorf("count fact units: %w", err)
+	}
+
+	taxFacts, err := r.CountFactsByTaxonomy(ctx)
+	if err != nil {
+		return fmt.Errorf("count facts by taxonomy: %w", err)
+	}
+
+	taxFactUnits, err := r.CountFactUnitsByTaxonomy(ctx)
+	if err != nil {
+		return fmt.Errorf("count fact units by taxonomy: %w", err)
+	}
+
+	_, err = fmt.Fprintf(w, "companies:  %v\nfact units: %v\n", companies,
+		factUnits)
+	if err != nil {
+		return fmt.Errorf("print stats: %w", err)
+	}
+
+	taxonomies := make([]string, 0, len(taxFacts))
+	for tax := range taxFacts {
+		taxonomies = append(taxonomies, tax)
+	}
+	slices.Sort(taxonomies)
+
+	for _, tax := range taxonomies {
+		_, err := fmt.Fprintf(w, "%-11v %v facts, %v fact units\n", tax+":",
+			taxFacts[tax], taxFactUnits[tax])
+		if err != nil {
+			return fmt.Errorf("print stats: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadStats(t *testing.T) {
+	var s uploadStats
+	assert.Equal(t, Stats{}, s.Stats())
+
+	s.Start()
+	s.companies.Add(2)
+	s.factUnits.Add(10)
+	s.errors.Add(1)
+	stats := s.Stats()
+	assert.False(t, stats.StartedAt.IsZero())
+	assert.True(t, stats.FinishedAt.IsZero())
+	assert.Zero(t, stats.Duration)
+	assert.Equal(t, uint64(2), stats.Companies)
+	assert.Equal(t, uint64(10), stats.FactUnits)
+	assert.Equal(t, uint64(1), stats.Errors)
+
+	s.Finish(errors.New("test error"))
+	stats = s.Stats()
+	assert.False(t, stats.FinishedAt.Before(stats.StartedAt))
+	assert.GreaterOrEqual(t, stats.Duration, 0.0)
+	assert.Equal(t, "test error", stats.Error)
+
+	s.Start()
+	s.Finish(nil)
+	stats = s.Stats()
+	assert.Zero(t, stats.Companies)
+	assert.Zero(t, stats.FactUnits)
+	assert.Zero(t, stats.Errors)
+	assert.Empty(t, stats.Error)
+}
+
+func TestWriteStatsFile(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "stats.json")
+	startedAt := time.Date(2024, time.January, 12, 3, 0, 0, 0, time.UTC)
+	stats := Stats{
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(90 * time.Second),
+		Duration:   90,
+		Companies:  2,
+		FactUnits:  10,
+		Errors:     1,
+		Error:      "test error",
+	}
+	require.NoError(t, writeStatsFile(fname, stats))
+
+	b, err := os.ReadFile(fname)
+	require.NoError(t, err)
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, map[string]any{
+		"startedAt":       "2024-01-12T03:00:00Z",
+		"finishedAt":      "2024-01-12T03:01:30Z",
+		"durationSeconds": 90.0,
+		"companies":       2.0,
+		"factUnits":       10.0,
+		"errors":          1.0,
+		"error":           "test error",
+	}, got)
+
+	stats.Error = ""
+	require.NoError(t, writeStatsFile(fname, stats))
+	b, err = os.ReadFile(fname)
+	require.NoError(t, err)
+	got = nil
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.NotContains(t, got, "error")
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1, "temp file not removed")
+
+	require.Error(t, writeStatsFile(filepath.Join(dir, "foo", "stats.json"),
+		stats))
+}
+
+type fakeCounter struct {
+	companies, factUnits       int64
+	companiesErr, factUnitsErr error
+
+	taxFacts, taxFactUnits       map[string]int64
+	taxFactsErr, taxFactUnitsErr error
+}
+
+func (self *fakeCounter) CompanyCount(ctx context.Context) (int64, error) {
+	return self.companies, self.companiesErr
+}
+
+func (self *fakeCounter) FactUnitCount(ctx context.Context) (int64, error) {
+	return self.factUnits, self.factUnitsErr
+}
+
+func (self *fakeCounter) CountFactsByTaxonomy(ctx context.Context,
+) (map[string]int64, error) {
+	return self.taxFacts, self.taxFactsErr
+}
+
+func (self *fakeCounter) CountFactUnitsByTaxonomy(ctx context.Context,
+) (map[string]int64, error) {
+	return self.taxFactUnits, self.taxFactUnitsErr
+}
+
+func TestPrintRepoStats(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	require.NoError(t, printRepoStats(ctx, &buf,
+		&fakeCounter{companies: 2, factUnits: 10}))
+	assert.Equal(t, "companies:  2\nfact units: 10\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, printRepoStats(ctx, &buf, &fakeCounter{
+		companies: 2, factUnits: 10,
+		taxFacts:     map[string]int64{"us-gaap": 3000, "dei": 50},
+		taxFactUnits: map[string]int64{"us-gaap": 9},
+	}))
+	assert.Equal(t, `companies:  2
+fact units: 10
+dei:        50 facts, 0 fact units
+us-gaap:    3000 facts, 9 fact units
+`, buf.String())
+
+	wantErr := errors.New("test error")
+	require.ErrorIs(t, printRepoStats(ctx, &buf,
+		&fakeCounter{companiesErr: wantErr}), wantErr)
+	require.ErrorIs(t, printRepoStats(ctx, &buf,
+		&fakeCounter{factUnitsErr: wantErr}), wantErr)
+	require.ErrorIs(t, printRepoStats(ctx, &buf,
+		&fakeCounter{taxFactsErr: wantErr}), wantErr)
+	require.ErrorIs(t, printRepoStats(ctx, &buf,
+		&fakeCounter{taxFactUnitsErr: wantErr}), wantErr)
+	require.ErrorIs(t, printRepoStats(ctx, &errWriter{err: wantErr},
+		&fakeCounter{}), wantErr)
+}
+
+type errWriter struct {
+	err error
+}
+
+func (self *errWriter) Write(p []byte) (int, error) {
+	return 0, self.err
+}
@@ -1,11 +1,14 @@
 package db
 
 import (
-	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"path/filepath"
+	"math"
+	"net/http"
+	"path"
+	"runtime/debug"
 	"slices"
 	"sync"
 	"sync/atomic"
@@ -18,13 +21,26 @@ import (
 	"github.com/dsh2dsh/edgar/internal/repo"
 )
 
+// ErrPanic returned when panic recovered while processing a company.
+var ErrPanic = errors.New("recovered panic")
+
+// ErrTooManyFacts returned, when company has more facts, than can be counted
+// by uint32.
+var ErrTooManyFacts = errors.New("too many facts")
+
 const (
 	indexPath   = "edgar/full-index"
 	masterIndex = "master.gz"
 )
 
-func (self *Upload) Update() error {
-	ctx := context.Background()
+// firstIndexDate is date of first quarterly index file in EDGAR full-index.
+var firstIndexDate = time.Date(1993, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func (self *Upload) Update() (err error) {
+	self.stats.Start()
+	defer func() { self.stats.Finish(err) }()
+
+	ctx := self.WithContextLogger(context.Background())
 	lastUpdated, err := self.preloadUpdateArtefacts(ctx)
 	if err != nil {
 		return err
@@ -45,21 +61,28 @@ func (self *Upload) Update() error {
 	if err := self.saveLastUpdated(ctx, lastUpdated); err != nil {
 		return err
 	}
-	self.log(ctx).Info("update completed")
+	self.logSummary(ctx, "update completed")
+	self.logCacheMetrics(ctx)
 	return nil
 }
 
+func (self *Upload) logSummary(ctx context.Context, msg string) {
+	stats := self.Stats()
+	self.log(ctx).Info(msg, slog.Group("summary",
+		slog.Uint64("companies", stats.Companies),
+		slog.Uint64("factUnits", stats.FactUnits),
+		slog.Duration("duration", time.Since(stats.StartedAt)),
+		slog.Uint64("errors", stats.Errors)))
+}
+
 func (self *Upload) preloadUpdateArtefacts(ctx context.Context,
 ) (lastUpdated time.Time, err error) {
 	if err = self.preloadArtifacts(ctx); err != nil {
 		return
 	}
 
-	if lastUpdated, err = self.repo.LastUpdated(ctx); err != nil {
-		err = fmt.Errorf("failed get last updated: %w", err)
+	if lastUpdated, err = self.lastUpdated(ctx); err != nil {
 		return
-	} else if lastUpdated.IsZero() {
-		lastUpdated = self.mostRecentFiled()
 	}
 
 	lastUpdated, err = self.refreshLastFiled(ctx, lastUpdated)
@@ -69,14 +92,38 @@ func (self *Upload) preloadUpdateArtefacts(ctx context.Context,
 	return
 }
 
+// lastUpdated returns date of last update: sinceDate, if it's configured by
+// WithSinceDate, or stored in the repo, or most recent filed date, if nothing
+// stored yet.
+func (self *Upload) lastUpdated(ctx context.Context) (time.Time, error) {
+	if !self.sinceDate.IsZero() {
+		self.log(ctx).Info("override last updated", slog.String("since",
+			self.sinceDate.Format(time.DateOnly)))
+		return self.sinceDate, nil
+	}
+
+	lastUpdated, err := self.repo.LastUpdated(ctx)
+	if err != nil {
+		return lastUpdated, fmt.Errorf("failed get last updated: %w", err)
+	} else if lastUpdated.IsZero() {
+		lastUpdated = self.mostRecentFiled()
+	}
+	return lastUpdated, nil
+}
+
+// mostRecentFiled returns most recent filed date from lastFiled. It's computed
+// on first call and cached until lastFiled changed.
 func (self *Upload) mostRecentFiled() time.Time {
-	var t time.Time
+	if !self.mostRecent.IsZero() {
+		return self.mostRecent
+	}
+
 	for _, lastFiled := range self.lastFiled {
-		if lastFiled.After(t) {
-			t = lastFiled
+		if lastFiled.After(self.mostRecent) {
+			self.mostRecent = lastFiled
 		}
 	}
-	return t
+	return self.mostRecent
 }
 
 func (self *Upload) refreshLastFiled(ctx context.Context, since time.Time,
@@ -84,7 +131,7 @@ func (self *Upload) refreshLastFiled(ctx context.Context, since time.Time,
 	self.log(ctx).Info("looking for updated companies", slog.String("since",
 		since.Format(time.DateOnly)))
 
-	masterPath := filepath.Join(indexPath, masterIndex)
+	masterPath := masterIndexPath("")
 	lastUpdated, fillings, err := self.indexFillings(ctx, masterPath)
 	if err != nil {
 		return
@@ -94,6 +141,13 @@ func (self *Upload) refreshLastFiled(ctx context.Context, since time.Time,
 		slog.String("at", lastUpdated.Format(time.DateOnly)),
 		slog.String("path", masterPath))
 
+	if since.After(lastUpdated) {
+		self.log(ctx).Warn("last updated after EDGAR index, use index date",
+			slog.String("since", since.Format(time.DateOnly)),
+			slog.String("at", lastUpdated.Format(time.DateOnly)))
+		since = lastUpdated
+	}
+
 	updateCompanies, err := self.hasUpdatesUntil(ctx, since, lastUpdated,
 		self.hasUpdates(since, fillings, make(map[uint32]struct{},
 			len(self.lastFiled))))
@@ -101,7 +155,7 @@ func (self *Upload) refreshLastFiled(ctx context.Context, since time.Time,
 		return
 	}
 
-	self.purgeLastFiled(updateCompanies)
+	self.purgeLastFiled(ctx, updateCompanies)
 	self.log(ctx).Info("got updated companies",
 		slog.Int("length", len(updateCompanies)),
 		slog.Int("actual", len(self.lastFiled)))
@@ -109,6 +163,12 @@ func (self *Upload) refreshLastFiled(ctx context.Context, since time.Time,
 	return
 }
 
+// masterIndexPath returns URL path of master index file from full-index
+// directory. It uses forward slashes on every OS, because it's a part of URL.
+func masterIndexPath(qtrPath string) string {
+	return path.Join(indexPath, qtrPath, masterIndex)
+}
+
 func (self *Upload) indexFillings(ctx context.Context, path string,
 ) (lastFiled time.Time, companies map[uint32]time.Time, err error) {
 	l := self.log(ctx).With(slog.String("path", path))
@@ -121,13 +181,18 @@ func (self *Upload) indexFillings(ctx context.Context, path string,
 	}
 	defer resp.Body.Close()
 
-	gz, err := gzip.NewReader(resp.Body)
+	if resp.StatusCode > client.MaxExpectedStatusCode {
+		err = fmt.Errorf("failed fetch index file %q: %w", path,
+			client.NewUnexpectedStatusError(resp))
+		return
+	}
+
+	f, err := index.NewGzipFile(resp.Body)
 	if err != nil {
 		err = fmt.Errorf("failed gunzip %q: %w", path, err)
 		return
 	}
 
-	f := index.NewFile(gz)
 	if err = f.ReadHeaders(); err != nil {
 		err = fmt.Errorf("failed read headers from %q: %w", path, err)
 		return
@@ -156,43 +221,102 @@ func (self *Upload) hasUpdates(since time.Time, fillings map[uint32]time.Time,
 	return companies
 }
 
+// hasUpdatesUntil adds companies, which filed something since since, from
+// quarterly index files until lastUpdated. It fetches index files
+// concurrently, up to procs at once. Nothing is fetched, if since is after
+// lastUpdated. Quarterly index files, which aren't published yet, are skipped.
 func (self *Upload) hasUpdatesUntil(ctx context.Context, since time.Time,
 	lastUpdated time.Time, companies map[uint32]struct{},
 ) (map[uint32]struct{}, error) {
 	self.log(ctx).Info("checking index files for updates",
 		slog.String("since", since.Format(time.DateOnly)),
 		slog.String("until", lastUpdated.Format(time.DateOnly)))
+
 	qtr := client.NewQtr(since)
+	if firstQtr := client.NewQtr(firstIndexDate); firstQtr.After(qtr) {
+		qtr = firstQtr
+	}
 	lastQtr := client.NewQtr(lastUpdated)
+	var qtrPaths []string
+	for ; !qtr.After(lastQtr); qtr.Next() {
+		qtrPaths = append(qtrPaths, qtr.Path())
+	}
 
-	for path := qtr.Path(); ; path = qtr.Next() {
-		masterPath := filepath.Join(indexPath, path, masterIndex)
-		_, fillings, err := self.indexFillings(ctx, masterPath)
-		if err != nil {
-			return nil, err
-		}
-		companies = self.hasUpdates(since, fillings, companies)
-		if path == lastQtr.Path() {
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(self.procs)
+
+	for _, qtrPath := range qtrPaths {
+		if gctx.Err() != nil {
 			break
 		}
+		g.Go(func() error {
+			path := masterIndexPath(qtrPath)
+			_, fillings, err := self.indexFillings(gctx, path)
+			if isNotFound(err) {
+				self.log(ctx).Info("quarterly index not yet available",
+					slog.String("path", path))
+				return nil
+			} else if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			companies = self.hasUpdates(since, fillings, companies)
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err //nolint:wrapcheck // already wrapped by indexFillings
+	} else if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("check index files for updates: %w", err)
 	}
 	return companies, nil
 }
 
-func (self *Upload) purgeLastFiled(updateCompanies map[uint32]struct{}) {
+// isNotFound reports whether err has UnexpectedStatusError with 404 status.
+func isNotFound(err error) bool {
+	var s *client.UnexpectedStatusError
+	return errors.As(err, &s) && s.StatusCode() == http.StatusNotFound
+}
+
+// purgeLastFiled removes known and unknown companies, which don't exist in
+// updateCompanies, so they aren't updated. Every removed company is logged at
+// DEBUG level.
+func (self *Upload) purgeLastFiled(ctx context.Context,
+	updateCompanies map[uint32]struct{},
+) {
+	l := self.log(ctx)
+	debug := l.Enabled(ctx, slog.LevelDebug)
+	var purged int
 	for cik := range self.lastFiled {
 		if _, ok := updateCompanies[cik]; !ok {
+			if debug {
+				l.LogAttrs(ctx, slog.LevelDebug, "skip company without new filings",
+					slog.Uint64("CIK", uint64(cik)))
+			}
 			delete(self.lastFiled, cik)
+			purged++
 		}
 	}
+	self.mostRecent = time.Time{}
 
 	if len(self.unknown) > 0 {
 		self.unknown = slices.DeleteFunc(self.unknown,
 			func(c client.CompanyTicker) bool {
-				_, ok := updateCompanies[c.CIK]
-				return !ok
+				if _, ok := updateCompanies[c.CIK]; ok {
+					return false
+				} else if debug {
+					l.LogAttrs(ctx, slog.LevelDebug,
+						"skip new company without new filings",
+						slog.Uint64("CIK", uint64(c.CIK)))
+				}
+				purged++
+				return true
 			})
 	}
+	l.Debug("purged companies without new filings", slog.Int("purged", purged))
 }
 
 func (self *Upload) updateWithProgress(ctx context.Context) error {
@@ -249,11 +373,11 @@ func (self *Upload) updateKnownCompanies(ctx context.Context,
 		}
 		cik := cik
 		cnt := progress.Add(1)
-		l := self.log(ctx).With(
+		ctx := self.WithContextLogger(ctx,
 			slog.String("progress", fmt.Sprintf("%v/%v", cnt, len(self.lastFiled))),
 			slog.Uint64("CIK", uint64(cik)))
 		g.Go(func() error {
-			return self.updateCompanyFacts(ContextWithLogger(ctx, l), cik)
+			return self.companyError(ctx, cik, self.updateCompanyFacts(ctx, cik))
 		})
 	}
 	return g.Wait() //nolint:wrapcheck // returned not from external package
@@ -264,18 +388,23 @@ func (self *Upload) updateCompanyFacts(ctx context.Context, cik uint32) error {
 	if err != nil {
 		return err
 	} else if len(facts) == 0 {
+		self.stats.companies.Add(1)
 		return nil
 	}
 
 	nextFunc := func(i int) (repo.FactUnit, error) { return facts[i], nil }
 	if replaceFiled.IsZero() {
-		err = self.repo.CopyFactUnits(ctx, len(facts), nextFunc)
+		err = self.copyFactUnits(ctx, cik, len(facts), nextFunc, false)
 	} else {
 		err = self.repo.ReplaceFactUnits(ctx, cik, replaceFiled, len(facts), nextFunc)
+		if err == nil {
+			self.stats.factUnits.Add(uint64(len(facts)))
+		}
 	}
 	if err != nil {
 		return fmt.Errorf("updateCompanyFacts: company CIK=%v: %w", cik, err)
 	}
+	self.stats.companies.Add(1)
 	return nil
 }
 
@@ -284,59 +413,146 @@ func (self *Upload) repoFactsUpdate(ctx context.Context, cik uint32,
 	lastCnt, facts, err := self.companyFactsUpdate(ctx, cik)
 	if err != nil {
 		return
-	} else if lastCnt == uint32(len(facts)) {
+	} else if err = checkFactsLen(len(facts)); err != nil {
+		return replaceFiled, nil, fmt.Errorf("company CIK=%v: %w", cik, err)
+	} else if uint64(lastCnt) == uint64(repo.CountDistinctFactUnits(facts)) {
 		facts = nil
 		return
 	}
 
+	// lastCnt counts duplicates once, so count them once here too, or facts
+	// removed by gc will be replaced again.
 	lastFiled := self.lastFiled[cik]
 	startIdx := slices.IndexFunc(facts,
 		func(fact repo.FactUnit) bool { return fact.Filed.After(lastFiled) })
-	if uint32(startIdx) == lastCnt {
+	if startIdx >= 0 &&
+		uint64(repo.CountDistinctFactUnits(facts[:startIdx])) == uint64(lastCnt) {
 		self.log(ctx).Info("append new facts",
 			slog.Int("length", len(facts)-startIdx),
 			slog.Int("was", int(lastCnt)), slog.Int("got", len(facts)),
 			slog.Int("start", startIdx))
 		facts = facts[startIdx:]
 	} else {
+		added, removed, err := self.lastFiledDiff(ctx, cik, lastFiled, facts)
+		if err != nil {
+			return replaceFiled, nil, err
+		}
 		self.log(ctx).Info("replace last filed facts",
 			slog.Int("length", len(facts)), slog.Int("was", int(lastCnt)),
-			slog.Int("start", startIdx))
+			slog.Int("start", startIdx), slog.Int("added", len(added)),
+			slog.Int("removed", len(removed)))
+		self.log(ctx).Debug("changed last filed facts",
+			slog.Any("added", factAccns(added)),
+			slog.Any("removed", factAccns(removed)))
 		replaceFiled = lastFiled
 	}
 	return
 }
 
+// checkFactsLen returns error, if n facts can't be compared with number of
+// stored facts, which is uint32.
+func checkFactsLen(n int) error {
+	if uint64(n) > math.MaxUint32 {
+		return fmt.Errorf("%v facts exceed max of %v: %w", n,
+			uint64(math.MaxUint32), ErrTooManyFacts)
+	}
+	return nil
+}
+
+// lastFiledDiff returns facts, which will be added and removed by replacing
+// facts filed since lastFiled.
+func (self *Upload) lastFiledDiff(ctx context.Context, cik uint32,
+	lastFiled time.Time, facts []repo.FactUnit,
+) (added, removed []repo.FactUnit, err error) {
+	added, removed, err = self.repo.FactUnitsDiffSince(ctx, cik, lastFiled,
+		facts)
+	if err != nil {
+		err = fmt.Errorf("diff facts of company CIK=%v: %w", cik, err)
+	}
+	return
+}
+
+// factAccns returns sorted unique accession numbers of facts.
+func factAccns(facts []repo.FactUnit) []string {
+	accns := make([]string, 0, len(facts))
+	for i := range facts {
+		accns = append(accns, facts[i].Accn)
+	}
+	slices.Sort(accns)
+	return slices.Compact(accns)
+}
+
 func (self *Upload) companyFactsUpdate(ctx context.Context, cik uint32,
 ) (lastCnt uint32, facts []repo.FactUnit, err error) {
-	done := make(chan error)
+	var wg sync.WaitGroup
+	var countsErr error
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				countsErr = self.panicError(ctx, r)
+			}
+		}()
 		self.log(ctx).Debug("load company filed fact counts")
-		if counts, err := self.repo.FiledCounts(ctx, cik); err == nil {
-			lastFiled := self.lastFiled[cik]
-			lastCnt = counts[lastFiled]
+		counts, err := self.repo.FiledCounts(ctx, cik)
+		if err != nil {
+			countsErr = err
+			return
+		}
+		lastCnt = counts[self.lastFiled[cik]]
+	}()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = self.panicError(ctx, r)
 		}
-		done <- err
+		wg.Wait()
+		if err == nil {
+			err = countsErr
+		}
+		if err != nil {
+			err = fmt.Errorf("companyFactsUpdate: company CIK=%v: %w", cik, err)
+			return
+		}
+		self.log(ctx).Debug("got company facts update",
+			slog.Int("fresh", len(facts)), slog.Int("lastCnt", int(lastCnt)))
 	}()
 
 	self.log(ctx).Debug("fetch company facts")
 	companyFacts, err := self.retryCompanyFacts(ctx, retryNum, cik)
 	if err != nil {
-		err = fmt.Errorf("companyFactsUpdate: company CIK=%v: %w", cik, err)
-		<-done
 		return
 	}
 
-	facts, err = self.freshRepoFacts(ctx, cik, companyFacts.Facts)
+	storeCIK, err := self.mismatchedCIK(ctx, cik, companyFacts.Id())
 	if err != nil {
-		err = fmt.Errorf("companyFactsUpdate: company CIK=%v: %w", cik, err)
-		<-done
-	} else if err = <-done; err != nil {
-		err = fmt.Errorf("companyFactsUpdate: company CIK=%v: %w", cik, err)
+		return
+	} else if storeCIK != cik {
+		err = fmt.Errorf("%w: can't update facts with CIK=%v", ErrCIKMismatch,
+			storeCIK)
+		return
+	}
+
+	err = self.updateCompanyName(ctx, cik, companyFacts.EntityName)
+	if err != nil {
+		return
 	}
+
+	self.log(ctx).Debug("collect fresh company facts")
+	facts, err = self.freshRepoFacts(ctx, cik, companyFacts.Facts)
 	return
 }
 
+// panicError logs stack trace of recovered panic r at ERROR level and returns
+// it as error. It must be called from deferred function, which recovered r,
+// so the stack trace contains panicking code.
+func (self *Upload) panicError(ctx context.Context, r any) error {
+	self.log(ctx).LogAttrs(ctx, slog.LevelError, "recovered panic",
+		slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+	return fmt.Errorf("%w: %v", ErrPanic, r)
+}
+
 func (self *Upload) freshRepoFacts(ctx context.Context, cik uint32,
 	facts map[string]map[string]client.CompanyFact,
 ) ([]repo.FactUnit, error) {
@@ -0,0 +1,611 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dsh2dsh/edgar/client"
+	mocksClient "github.com/dsh2dsh/edgar/internal/mocks/client"
+	mocks "github.com/dsh2dsh/edgar/internal/mocks/db"
+	"github.com/dsh2dsh/edgar/internal/repo"
+)
+
+func TestMasterIndexPath(t *testing.T) {
+	assert.Equal(t, "edgar/full-index/master.gz", masterIndexPath(""))
+
+	qtr := client.NewQtr(time.Date(2023, time.October, 25, 0, 0, 0, 0, time.UTC))
+	path := masterIndexPath(qtr.Path())
+	assert.Equal(t, "edgar/full-index/2023/QTR4/master.gz", path)
+	assert.NotContains(t, path, `\`)
+}
+
+func TestUpload_mostRecentFiled(t *testing.T) {
+	u := NewUpload(nil, nil)
+	assert.True(t, u.mostRecentFiled().IsZero())
+
+	want := time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)
+	u.lastFiled = map[uint32]time.Time{
+		1: time.Date(2023, time.May, 4, 0, 0, 0, 0, time.UTC),
+		2: want,
+		3: time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC),
+	}
+	assert.Equal(t, want, u.mostRecentFiled())
+
+	u.lastFiled[4] = want.AddDate(0, 0, 1)
+	assert.Equal(t, want, u.mostRecentFiled(), "cached")
+
+	u.purgeLastFiled(context.Background(), map[uint32]struct{}{1: {}, 3: {}})
+	assert.Equal(t, time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC),
+		u.mostRecentFiled())
+}
+
+func TestUpload_lastUpdated(t *testing.T) {
+	ctx := context.Background()
+	stored := time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)
+
+	r := mocks.NewMockRepo(t)
+	r.EXPECT().LastUpdated(ctx).Return(stored, nil).Once()
+	u := NewUpload(nil, r)
+	lastUpdated, err := u.lastUpdated(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, stored, lastUpdated)
+
+	mostRecent := time.Date(2023, time.December, 29, 0, 0, 0, 0, time.UTC)
+	u.lastFiled = map[uint32]time.Time{1: mostRecent}
+	r.EXPECT().LastUpdated(ctx).Return(time.Time{}, nil).Once()
+	lastUpdated, err = u.lastUpdated(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, mostRecent, lastUpdated)
+
+	wantErr := errors.New("test error")
+	r.EXPECT().LastUpdated(ctx).Return(time.Time{}, wantErr).Once()
+	_, err = u.lastUpdated(ctx)
+	require.ErrorIs(t, err, wantErr)
+
+	since := time.Date(2023, time.May, 4, 0, 0, 0, 0, time.UTC)
+	u = NewUpload(nil, mocks.NewMockRepo(t)).WithSinceDate(since).
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	lastUpdated, err = u.lastUpdated(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, since, lastUpdated)
+}
+
+func TestUpload_purgeLastFiled(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs,
+		&slog.HandlerOptions{Level: slog.LevelDebug}))
+	u := NewUpload(nil, nil).WithLogger(logger)
+	u.lastFiled = map[uint32]time.Time{1: {}, 2: {}, 3: {}}
+	u.unknown = []client.CompanyTicker{{CIK: 4}, {CIK: 5}}
+
+	u.purgeLastFiled(context.Background(), map[uint32]struct{}{1: {}, 5: {}})
+	assert.Equal(t, map[uint32]time.Time{1: {}}, u.lastFiled)
+	assert.Equal(t, []client.CompanyTicker{{CIK: 5}}, u.unknown)
+	assert.Contains(t, logs.String(), "CIK=2")
+	assert.Contains(t, logs.String(), "CIK=3")
+	assert.Contains(t, logs.String(), "CIK=4")
+	assert.Contains(t, logs.String(), "purged=3")
+
+	logs.Reset()
+	u.WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	u.purgeLastFiled(context.Background(), map[uint32]struct{}{})
+	assert.Empty(t, u.lastFiled)
+	assert.Empty(t, logs.String())
+}
+
+func TestCheckFactsLen(t *testing.T) {
+	require.NoError(t, checkFactsLen(0))
+	if math.MaxInt <= math.MaxUint32 {
+		t.Skip("int can't exceed uint32")
+	}
+	n := uint64(math.MaxUint32)
+	require.NoError(t, checkFactsLen(int(n)))
+	require.ErrorIs(t, checkFactsLen(int(n+1)), ErrTooManyFacts)
+}
+
+func BenchmarkUpload_mostRecentFiled(b *testing.B) {
+	u := NewUpload(nil, nil)
+	u.lastFiled = make(map[uint32]time.Time, 15000)
+	since := time.Date(2009, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 15000 {
+		u.lastFiled[uint32(i+1)] = since.AddDate(0, 0, i%5000)
+	}
+
+	b.Run("uncached", func(b *testing.B) {
+		for range b.N {
+			u.mostRecent = time.Time{}
+			u.mostRecentFiled()
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		for range b.N {
+			u.mostRecentFiled()
+		}
+	})
+}
+
+func TestUpload_lastFiledDiff(t *testing.T) {
+	const appleCIK = 320193
+	ctx := context.Background()
+	lastFiled := time.Date(2023, time.November, 3, 0, 0, 0, 0, time.UTC)
+	facts := []repo.FactUnit{{Accn: "1", Filed: lastFiled}}
+	wantRemoved := []repo.FactUnit{{Accn: "2", Filed: lastFiled}}
+
+	r := mocks.NewMockRepo(t)
+	r.EXPECT().FactUnitsDiffSince(ctx, uint32(appleCIK), lastFiled, facts).
+		Return(facts, wantRemoved, nil).Once()
+	u := NewUpload(nil, r)
+
+	added, removed, err := u.lastFiledDiff(ctx, appleCIK, lastFiled, facts)
+	require.NoError(t, err)
+	assert.Equal(t, facts, added)
+	assert.Equal(t, wantRemoved, removed)
+
+	wantErr := errors.New("test error")
+	r.EXPECT().FactUnitsDiffSince(ctx, uint32(appleCIK), lastFiled, facts).
+		Return(nil, nil, wantErr).Once()
+	_, _, err = u.lastFiledDiff(ctx, appleCIK, lastFiled, facts)
+	require.ErrorIs(t, err, wantErr)
+}
+
+func TestFactAccns(t *testing.T) {
+	assert.Empty(t, factAccns(nil))
+	assert.Equal(t, []string{"1", "2"}, factAccns([]repo.FactUnit{
+		{Accn: "2"}, {Accn: "1"}, {Accn: "2"},
+	}))
+}
+
+func TestUpload_logSummary(t *testing.T) {
+	var buf bytes.Buffer
+	u := NewUpload(nil, nil).WithLogger(
+		slog.New(slog.NewJSONHandler(&buf, nil)))
+	u.stats.Start()
+	u.stats.companies.Add(2)
+	u.stats.factUnits.Add(10)
+	u.stats.errors.Add(1)
+	u.logSummary(context.Background(), "update completed")
+
+	var got struct {
+		Msg     string `json:"msg"`
+		Summary struct {
+			Companies uint64  `json:"companies"`
+			FactUnits uint64  `json:"factUnits"`
+			Duration  float64 `json:"duration"`
+			Errors    uint64  `json:"errors"`
+		} `json:"summary"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "update completed", got.Msg)
+	assert.Equal(t, uint64(2), got.Summary.Companies)
+	assert.Equal(t, uint64(10), got.Summary.FactUnits)
+	assert.Positive(t, got.Summary.Duration)
+	assert.Equal(t, uint64(1), got.Summary.Errors)
+}
+
+func TestUpload_indexFillings(t *testing.T) {
+	const testPath = "edgar/full-index/2024/QTR1/master.gz"
+
+	tests := []struct {
+		name          string
+		status        int
+		body          func(t *testing.T) []byte
+		wantErr       bool
+		wantLastFiled time.Time
+		wantLen       int
+	}{
+		{
+			name:   "ok",
+			status: http.StatusOK,
+			body: func(t *testing.T) []byte {
+				b, err := os.ReadFile("../../client/index/testdata/master.gz")
+				require.NoError(t, err)
+				return b
+			},
+			wantLastFiled: time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC),
+			wantLen:       17318,
+		},
+		{
+			name:    "not found",
+			status:  http.StatusNotFound,
+			wantErr: true,
+		},
+		{
+			name:    "unexpected status",
+			status:  http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient := mocksClient.NewMockHttpRequestDoer(t)
+			httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+				func(req *http.Request) (*http.Response, error) {
+					assert.Equal(t, "/Archives/"+testPath, req.URL.Path)
+					recorder := httptest.NewRecorder()
+					recorder.WriteHeader(tt.status)
+					if tt.body != nil {
+						_, err := recorder.Write(tt.body(t))
+						require.NoError(t, err)
+					}
+					return recorder.Result(), nil
+				})
+
+			u := NewUpload(client.New(client.WithHttpClient(httpClient)), nil)
+			lastFiled, companies, err := u.indexFillings(
+				context.Background(), testPath)
+			if tt.wantErr {
+				require.ErrorIs(t, err, client.ErrUnexpectedStatus)
+				assert.Equal(t, tt.status == http.StatusNotFound, isNotFound(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLastFiled, lastFiled)
+			assert.Len(t, companies, tt.wantLen)
+		})
+	}
+}
+
+func TestUpload_hasUpdatesUntil(t *testing.T) {
+	const lastQtrPath = "/Archives/edgar/full-index/2024/QTR1/master.gz"
+	since := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	lastUpdated := time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC)
+	wantPaths := []string{
+		"/Archives/edgar/full-index/2023/QTR2/master.gz",
+		"/Archives/edgar/full-index/2023/QTR3/master.gz",
+		"/Archives/edgar/full-index/2023/QTR4/master.gz",
+		lastQtrPath,
+	}
+	masterGz, err := os.ReadFile("../../client/index/testdata/master.gz")
+	require.NoError(t, err)
+
+	newUpload := func(t *testing.T, failPath string) (*Upload, *[]string) {
+		var mu sync.Mutex
+		var paths []string
+		var inFlight sync.WaitGroup
+		inFlight.Add(len(wantPaths))
+
+		httpClient := mocksClient.NewMockHttpRequestDoer(t)
+		httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+			func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				paths = append(paths, req.URL.Path)
+				mu.Unlock()
+
+				// every request waits for all others, so it passes only if all
+				// quarters fetched concurrently.
+				inFlight.Done()
+				waitCtx, cancel := context.WithTimeout(req.Context(), time.Second)
+				defer cancel()
+				go func() { inFlight.Wait(); cancel() }()
+				<-waitCtx.Done()
+				assert.NotErrorIs(t, waitCtx.Err(), context.DeadlineExceeded,
+					"quarters not fetched concurrently")
+
+				recorder := httptest.NewRecorder()
+				switch req.URL.Path {
+				case failPath:
+					recorder.WriteHeader(http.StatusInternalServerError)
+				case lastQtrPath:
+					_, err := recorder.Write(masterGz)
+					require.NoError(t, err)
+				default:
+					recorder.WriteHeader(http.StatusNotFound)
+				}
+				return recorder.Result(), nil
+			})
+
+		edgar := client.New(client.WithHttpClient(httpClient),
+			client.WithRateLimiter(nil))
+		return NewUpload(edgar, nil).WithProcsLimit(len(wantPaths)), &paths
+	}
+
+	ctx := context.Background()
+	u, paths := newUpload(t, "")
+	companies, err := u.hasUpdatesUntil(ctx, since, lastUpdated,
+		map[uint32]struct{}{1: {}})
+	require.NoError(t, err)
+	assert.Len(t, companies, 17318+1)
+	assert.ElementsMatch(t, wantPaths, *paths)
+
+	u, _ = newUpload(t, wantPaths[1])
+	_, err = u.hasUpdatesUntil(ctx, since, lastUpdated, map[uint32]struct{}{})
+	require.ErrorContains(t, err, "unexpected status")
+}
+
+func TestUpload_refreshLastFiled_sinceAfterIndex(t *testing.T) {
+	masterGz, err := os.ReadFile("../../client/index/testdata/master.gz")
+	require.NoError(t, err)
+
+	var paths []string
+	httpClient := mocksClient.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			paths = append(paths, req.URL.Path)
+			recorder := httptest.NewRecorder()
+			_, err := recorder.Write(masterGz)
+			require.NoError(t, err)
+			return recorder.Result(), nil
+		})
+
+	edgar := client.New(client.WithHttpClient(httpClient),
+		client.WithRateLimiter(nil))
+	u := NewUpload(edgar, nil).WithProcsLimit(1).
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	since := time.Date(2999, time.January, 1, 0, 0, 0, 0, time.UTC)
+	lastUpdated, err := u.refreshLastFiled(context.Background(), since)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC),
+		lastUpdated)
+	assert.Equal(t, []string{
+		"/Archives/edgar/full-index/master.gz",
+		"/Archives/edgar/full-index/2024/QTR1/master.gz",
+	}, paths)
+}
+
+func TestUpload_hasUpdatesUntil_noQuarters(t *testing.T) {
+	ctx := context.Background()
+	since := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	lastUpdated := time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC)
+
+	edgar := client.New(client.WithHttpClient(
+		mocksClient.NewMockHttpRequestDoer(t)), client.WithRateLimiter(nil))
+	u := NewUpload(edgar, nil).WithProcsLimit(2)
+
+	companies, err := u.hasUpdatesUntil(ctx, since, lastUpdated,
+		map[uint32]struct{}{1: {}})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint32]struct{}{1: {}}, companies)
+
+	companies, err = u.hasUpdatesUntil(ctx, since, time.Time{},
+		map[uint32]struct{}{})
+	require.NoError(t, err)
+	assert.Empty(t, companies)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = u.hasUpdatesUntil(ctx, lastUpdated, lastUpdated,
+		map[uint32]struct{}{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestUpload_repoFactsUpdate_duplicates(t *testing.T) {
+	const appleCIK = 320193
+	ctx := context.Background()
+	lastFiled := time.Date(2023, time.November, 3, 0, 0, 0, 0, time.UTC)
+
+	dup := client.FactUnit{
+		End: "2023-09-30", Accn: "1", FY: 2023, FP: "FY", Form: "10-K",
+		Filed: "2023-11-03",
+	}
+	newFact := dup
+	newFact.Accn, newFact.Filed = "2", "2024-02-02"
+
+	tests := []struct {
+		name      string
+		factUnits []client.FactUnit
+		wantFacts int
+	}{
+		{
+			name:      "no new facts",
+			factUnits: []client.FactUnit{dup, dup},
+		},
+		{
+			name:      "append new facts",
+			factUnits: []client.FactUnit{dup, dup, newFact},
+			wantFacts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edgar := newTestCompanyFactsClient(t, client.CompanyFacts{
+				CIK:        appleCIK,
+				EntityName: "Apple Inc.",
+				Facts: map[string]map[string]client.CompanyFact{
+					"us-gaap": {
+						"AccountsPayable": client.CompanyFact{
+							Label: "Accounts Payable",
+							Units: map[string][]client.FactUnit{"USD": tt.factUnits},
+						},
+					},
+				},
+			})
+
+			r := mocks.NewMockRepo(t)
+			// duplicates removed by gc or counted once by FiledCounts
+			r.EXPECT().FiledCounts(mock.Anything, uint32(appleCIK)).Return(
+				map[time.Time]uint32{lastFiled: 1}, nil)
+			r.EXPECT().AddFactWithLabel(mock.Anything, "us-gaap", "AccountsPayable",
+				"Accounts Payable", "", mock.Anything, mock.Anything).Return(1, nil)
+			r.EXPECT().AddUnit(mock.Anything, "USD").Return(1, nil)
+			u := NewUpload(edgar, r)
+			u.lastFiled = map[uint32]time.Time{appleCIK: lastFiled}
+
+			replaceFiled, facts, err := u.repoFactsUpdate(ctx, appleCIK)
+			require.NoError(t, err)
+			assert.True(t, replaceFiled.IsZero(), "replace instead of append")
+			assert.Len(t, facts, tt.wantFacts)
+			if tt.wantFacts > 0 {
+				assert.Equal(t, "2", facts[0].Accn)
+			}
+		})
+	}
+}
+
+func TestUpload_companyFactsUpdate_cikMismatch(t *testing.T) {
+	const appleCIK = 320193
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		policy  CIKMismatchPolicy
+		respCIK uint32
+		wantErr bool
+	}{
+		{
+			name:    "Near",
+			policy:  CIKMismatchNear,
+			respCIK: appleCIK + 1,
+			wantErr: true,
+		},
+		{
+			name:    "UseResponse",
+			policy:  CIKMismatchUseResponse,
+			respCIK: appleCIK + 1,
+			wantErr: true,
+		},
+		{
+			name:    "Strict",
+			policy:  CIKMismatchStrict,
+			respCIK: appleCIK + 1,
+			wantErr: true,
+		},
+		{
+			name:    "UseAPI",
+			policy:  CIKMismatchUseAPI,
+			respCIK: appleCIK + 1,
+		},
+		{
+			name:    "the same CIK",
+			policy:  CIKMismatchStrict,
+			respCIK: appleCIK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edgar := newTestCompanyFactsClient(t, client.CompanyFacts{
+				CIK: client.CIK(tt.respCIK), EntityName: "Apple Inc.",
+			})
+			r := mocks.NewMockRepo(t)
+			r.EXPECT().FiledCounts(mock.Anything, uint32(appleCIK)).Return(nil, nil)
+			u := NewUpload(edgar, r).WithCIKMismatchPolicy(tt.policy)
+
+			_, facts, err := u.companyFactsUpdate(ctx, appleCIK)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrCIKMismatch)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Empty(t, facts)
+		})
+	}
+}
+
+func TestUpload_companyFactsUpdate_panic(t *testing.T) {
+	const appleCIK = 320193
+	ctx := context.Background()
+
+	factBytes, err := json.Marshal(client.CompanyFacts{
+		CIK:        appleCIK,
+		EntityName: "Apple Inc.",
+		Facts: map[string]map[string]client.CompanyFact{
+			"us-gaap": {
+				"AccountsPayable": client.CompanyFact{
+					Label: "Accounts Payable",
+					Units: map[string][]client.FactUnit{
+						"USD": {{Accn: "1", Form: "10-K", Filed: "2023-11-03"}},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	httpClient := mocksClient.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			recorder := httptest.NewRecorder()
+			_, err := recorder.Write(factBytes)
+			require.NoError(t, err)
+			return recorder.Result(), nil
+		})
+	edgar := client.New(client.WithHttpClient(httpClient),
+		client.WithRateLimiter(nil))
+
+	tests := []struct {
+		name     string
+		mockRepo func(m *mocks.MockRepo, countsDone *atomic.Bool)
+	}{
+		{
+			name: "freshRepoFacts",
+			mockRepo: func(m *mocks.MockRepo, countsDone *atomic.Bool) {
+				panicking := make(chan struct{})
+				m.EXPECT().FiledCounts(mock.Anything, uint32(appleCIK)).RunAndReturn(
+					func(context.Context, uint32) (map[time.Time]uint32, error) {
+						<-panicking
+						countsDone.Store(true)
+						return nil, nil
+					})
+				m.EXPECT().AddFactWithLabel(mock.Anything, "us-gaap", "AccountsPayable",
+					"Accounts Payable", "", mock.Anything, mock.Anything).
+					RunAndReturn(func(context.Context, string, string, string, string,
+						uint64, uint64,
+					) (uint32, error) {
+						close(panicking)
+						panic("test panic")
+					})
+			},
+		},
+		{
+			name: "FiledCounts",
+			mockRepo: func(m *mocks.MockRepo, countsDone *atomic.Bool) {
+				m.EXPECT().FiledCounts(mock.Anything, uint32(appleCIK)).RunAndReturn(
+					func(context.Context, uint32) (map[time.Time]uint32, error) {
+						countsDone.Store(true)
+						panic("test panic")
+					})
+				m.EXPECT().AddFactWithLabel(mock.Anything, "us-gaap", "AccountsPayable",
+					"Accounts Payable", "", mock.Anything, mock.Anything).Return(1, nil)
+				m.EXPECT().AddUnit(mock.Anything, "USD").Return(1, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var countsDone atomic.Bool
+			r := mocks.NewMockRepo(t)
+			tt.mockRepo(r, &countsDone)
+			var logs bytes.Buffer
+			u := NewUpload(edgar, r).WithLogger(slog.New(
+				slog.NewJSONHandler(&logs, nil)))
+
+			_, _, err := u.companyFactsUpdate(ctx, appleCIK)
+			require.ErrorIs(t, err, ErrPanic)
+			assert.ErrorContains(t, err, "test panic")
+			assert.True(t, countsDone.Load(), "FiledCounts goroutine still running")
+
+			var got struct {
+				Level string `json:"level"`
+				Msg   string `json:"msg"`
+				Panic string `json:"panic"`
+				Stack string `json:"stack"`
+			}
+			require.NoError(t, json.Unmarshal(logs.Bytes(), &got))
+			assert.Equal(t, "ERROR", got.Level)
+			assert.Equal(t, "recovered panic", got.Msg)
+			assert.Contains(t, got.Panic, "test panic")
+			assert.Contains(t, got.Stack, "panic(")
+		})
+	}
+}
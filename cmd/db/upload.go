@@ -18,7 +18,47 @@ import (
 	"github.com/dsh2dsh/edgar/internal/repo"
 )
 
-const retryNum = 2 // how many times repeat API call after 504
+const (
+	retryNum         = 2 // how many times repeat API call after 504
+	companyFactsPath = "/api/xbrl/companyfacts/"
+
+	// Every evictFactsEvery companies uploadUnknownCompanies removes known facts
+	// not used during knownFactTTL, so memory doesn't grow with every company.
+	evictFactsEvery = 1000
+	knownFactTTL    = time.Hour
+
+	labelProgressEvery  = 100_000 // preloadFacts logs progress every N labels
+	factUnitsLogBatches = 10      // copyFactUnits logs progress every N batches
+)
+
+// ErrCIKMismatch returned, when CIK of fetched company facts differs from
+// requested CIK and CIKMismatchPolicy doesn't allow it.
+var ErrCIKMismatch = errors.New("CIK of company facts doesn't match")
+
+// ErrCompanyTimeout returned, when processing of one company takes longer, than
+// configured by WithCompanyTimeout. The company is skipped and will be
+// processed again by next run.
+var ErrCompanyTimeout = errors.New("company processing timed out")
+
+// CIKMismatchPolicy defines what Upload does, when CIK of fetched company
+// facts differs from requested CIK.
+type CIKMismatchPolicy int
+
+const (
+	// CIKMismatchNear logs warning and stores company facts under CIK from
+	// response, if it differs from requested CIK by no more than 1%, like after
+	// rounding or encoding issue. Otherwise it fails company with
+	// ErrCIKMismatch. It's default policy.
+	CIKMismatchNear CIKMismatchPolicy = iota
+	// CIKMismatchUseAPI logs warning and stores company facts under requested
+	// CIK.
+	CIKMismatchUseAPI
+	// CIKMismatchUseResponse logs warning and stores company facts under CIK
+	// from response.
+	CIKMismatchUseResponse
+	// CIKMismatchStrict fails company with ErrCIKMismatch.
+	CIKMismatchStrict
+)
 
 func NewUpload(edgar *client.Client, repo Repo) *Upload {
 	return &Upload{
@@ -34,17 +74,22 @@ func NewUpload(edgar *client.Client, repo Repo) *Upload {
 
 type Repo interface {
 	AddCompany(ctx context.Context, cik uint32, name string) (bool, error)
-	AddFact(ctx context.Context, tax, name string) (uint32, error)
-	AddLabel(ctx context.Context, factId uint32, label, descr string,
-		labelHash, descrHash uint64) error
+	UpdateCompanyName(ctx context.Context, cik uint32, name string) (bool, error)
+	AddFactWithLabel(ctx context.Context, tax, name string,
+		label, descr string, labelHash, descrHash uint64) (uint32, error)
+	BatchAddFactLabels(ctx context.Context, labels []repo.LabelEntry,
+	) (int64, error)
 	AddUnit(ctx context.Context, name string) (uint32, error)
 	AddFactUnit(ctx context.Context, fact repo.FactUnit) error
 	CopyFactUnits(ctx context.Context, length int,
 		next func(i int) (repo.FactUnit, error)) error
 	LastFiled(ctx context.Context) (map[uint32]time.Time, error)
-	FactLabels(ctx context.Context) ([]repo.FactLabels, error)
+	StreamFactLabels(ctx context.Context, fn func(item repo.FactLabels) error,
+	) error
 	Units(ctx context.Context) (map[uint32]string, error)
 	FiledCounts(ctx context.Context, cik uint32) (map[time.Time]uint32, error)
+	FactUnitsDiffSince(ctx context.Context, cik uint32, lastFiled time.Time,
+		incoming []repo.FactUnit) (added, removed []repo.FactUnit, err error)
 	ReplaceFactUnits(ctx context.Context, cik uint32, lastFiled time.Time,
 		length int, next func(i int) (repo.FactUnit, error)) error
 	AddLastUpdate(ctx context.Context, at time.Time) error
@@ -59,9 +104,20 @@ type Upload struct {
 	knownFacts facts
 	knownUnits factUnits
 	lastFiled  map[uint32]time.Time
+	mostRecent time.Time // cached by mostRecentFiled, zero if not computed
 	unknown    []client.CompanyTicker
+	exchanges  []string
 
-	procs int
+	procs       int
+	batchSize   int
+	updateNames bool
+	cikMismatch CIKMismatchPolicy
+
+	companyTimeout time.Duration
+	sinceDate      time.Time
+
+	stats        uploadStats
+	errorHandler func(cik uint32, err error) bool
 }
 
 func (self *Upload) WithLogger(l *slog.Logger) *Upload {
@@ -74,6 +130,75 @@ func (self *Upload) WithProcsLimit(n int) *Upload {
 	return self
 }
 
+// WithBatchSize limits number of fact units copied into DB by one CopyFrom
+// call. Zero means no limit.
+func (self *Upload) WithBatchSize(n int) *Upload {
+	self.batchSize = n
+	return self
+}
+
+func (self *Upload) WithCompanyNameUpdate(enabled bool) *Upload {
+	self.updateNames = enabled
+	return self
+}
+
+// WithCIKMismatchPolicy sets what to do, when CIK of fetched company facts
+// differs from requested CIK. Default is CIKMismatchNear.
+//
+// Update can't move known company to another CIK, so it fails company with
+// ErrCIKMismatch, if the policy selects CIK from response.
+func (self *Upload) WithCIKMismatchPolicy(policy CIKMismatchPolicy) *Upload {
+	self.cikMismatch = policy
+	return self
+}
+
+// WithCompanyTimeout limits time of processing one company by d. A company,
+// which wasn't processed in time, is skipped with ErrCompanyTimeout, instead of
+// aborting all processing. Zero means no limit, which is default.
+func (self *Upload) WithCompanyTimeout(d time.Duration) *Upload {
+	self.companyTimeout = d
+	return self
+}
+
+// WithSinceDate overrides date of last update by t, instead of getting it from
+// the repo, so Update looks for companies, which filed something since t. An
+// earlier t makes hasUpdatesUntil fetch more quarterly index files. Zero t
+// means no override, which is default.
+func (self *Upload) WithSinceDate(t time.Time) *Upload {
+	self.sinceDate = t
+	return self
+}
+
+func (self *Upload) WithExchanges(exchanges []string) *Upload {
+	self.exchanges = exchanges
+	return self
+}
+
+// WithErrorHandler sets fn, which decides what to do with error of processing
+// one company. It returns true for skipping the company and continue, or false
+// for aborting all processing. By default any error aborts processing. Upload
+// also calls it for failed batch of fact units, see WithBatchSize, and returning
+// true skips that batch only. Update never skips batches.
+func (self *Upload) WithErrorHandler(fn func(cik uint32, err error) bool,
+) *Upload {
+	self.errorHandler = fn
+	return self
+}
+
+// WithContextLogger returns ctx with logger of one operation, like processing
+// of one company. The logger is current logger of ctx, see log, with attrs
+// added to every its record. Later calls of log with returned ctx use it.
+func (self *Upload) WithContextLogger(ctx context.Context, attrs ...slog.Attr,
+) context.Context {
+	l := self.log(ctx)
+	if len(attrs) > 0 {
+		l = slog.New(l.Handler().WithAttrs(attrs))
+	}
+	return ContextWithLogger(ctx, l)
+}
+
+// log returns logger from ctx, if it has one, or logger configured by
+// WithLogger, or default logger.
 func (self *Upload) log(ctx context.Context) *slog.Logger {
 	if l := ContextLogger(ctx, nil); l != nil {
 		return l
@@ -83,8 +208,16 @@ func (self *Upload) log(ctx context.Context) *slog.Logger {
 	return self.logger
 }
 
-func (self *Upload) Upload() error {
-	ctx := context.Background()
+// Stats returns statistics of last Upload or Update.
+func (self *Upload) Stats() Stats {
+	return self.stats.Stats()
+}
+
+func (self *Upload) Upload() (err error) {
+	self.stats.Start()
+	defer func() { self.stats.Finish(err) }()
+
+	ctx := self.WithContextLogger(context.Background())
 	if err := self.preloadArtifacts(ctx); err != nil {
 		return err
 	}
@@ -93,9 +226,16 @@ func (self *Upload) Upload() error {
 		return fmt.Errorf("upload facts: %w", err)
 	}
 	self.log(ctx).Info("upload completed")
+	self.logCacheMetrics(ctx)
 	return nil
 }
 
+func (self *Upload) logCacheMetrics(ctx context.Context) {
+	self.log(ctx).Info("cache metrics",
+		slog.Int("facts", self.knownFacts.Len()),
+		slog.Int("labels", self.knownFacts.TotalLabelCount()))
+}
+
 func (self *Upload) preloadArtifacts(ctx context.Context) error {
 	if err := self.preloadFacts(ctx); err != nil {
 		return err
@@ -108,6 +248,7 @@ func (self *Upload) preloadArtifacts(ctx context.Context) error {
 		return fmt.Errorf("preload last filed: %w", err)
 	} else {
 		self.lastFiled = lastFiled
+		self.mostRecent = time.Time{}
 	}
 	self.log(ctx).Info("preloaded last filed companies",
 		slog.Int("len", len(self.lastFiled)))
@@ -122,21 +263,25 @@ func (self *Upload) preloadArtifacts(ctx context.Context) error {
 
 func (self *Upload) preloadFacts(ctx context.Context) error {
 	self.log(ctx).Info("preload facts and labels")
-	factLabels, err := self.repo.FactLabels(ctx)
-	if err != nil {
-		return fmt.Errorf("preload facts and labels: %w", err)
-	}
-
-	var extraLabelsCnt int
-	for i := range factLabels {
-		item := &factLabels[i]
+	var labelsCnt, extraLabelsCnt int
+	err := self.repo.StreamFactLabels(ctx, func(item repo.FactLabels) error {
 		factKey := self.makeFactKey(item.FactTax, item.FactName)
-		unknownFact := self.knownFacts.Preload(item.FactId, factKey, item.LabelHash,
-			item.DescrHash)
+		unknownFact := self.knownFacts.Preload(item.FactId, factKey,
+			item.LabelHash, item.DescrHash)
 		if !unknownFact {
 			extraLabelsCnt++
 		}
+
+		if labelsCnt++; labelsCnt%labelProgressEvery == 0 {
+			self.log(ctx).Info("preloading facts and labels",
+				slog.Int("labels", labelsCnt), slog.Int("len", self.knownFacts.Len()))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("preload facts and labels: %w", err)
 	}
+
 	self.log(ctx).Info("preloaded facts and labels",
 		slog.Int("len", self.knownFacts.Len()), slog.Int("extra", extraLabelsCnt))
 	return nil
@@ -164,21 +309,27 @@ func (self *Upload) unknownCompanies(ctx context.Context,
 		return nil, err
 	}
 
-	unknownIdx := slices.IndexFunc(companies,
-		func(c client.CompanyTicker) bool { return !self.loadedCompany(c.CIK) })
-	if unknownIdx < 0 {
+	// Don't depend on sortCompanies placing loaded companies first, check every
+	// company instead.
+	length := len(companies)
+	unknownCompanies := slices.DeleteFunc(companies,
+		func(c client.CompanyTicker) bool { return self.loadedCompany(c.CIK) })
+	if len(unknownCompanies) == 0 {
 		return nil, nil
-	} else if unknownIdx > 0 {
-		self.log(ctx).Info("skip loaded companies", slog.Int("skipped", unknownIdx))
+	} else if skipped := length - len(unknownCompanies); skipped > 0 {
+		self.log(ctx).Info("skip loaded companies", slog.Int("skipped", skipped))
 	}
 
-	unknownCompanies := companies[unknownIdx:]
 	self.log(ctx).Info("found unknown companies", slog.Int("length",
 		len(unknownCompanies)))
 	return unknownCompanies, nil
 }
 
 func (self *Upload) companies(ctx context.Context) ([]client.CompanyTicker, error) {
+	if len(self.exchanges) > 0 {
+		return self.exchangeCompanies(ctx)
+	}
+
 	self.log(ctx).Info("fetch company tickers")
 	companies, err := self.edgar.CompanyTickers(ctx)
 	if err != nil {
@@ -188,10 +339,42 @@ func (self *Upload) companies(ctx context.Context) ([]client.CompanyTicker, erro
 	return self.sortCompanies(ctx, companies), nil
 }
 
+func (self *Upload) exchangeCompanies(ctx context.Context,
+) ([]client.CompanyTicker, error) {
+	self.log(ctx).Info("fetch company tickers",
+		slog.String("exchange", strings.Join(self.exchanges, ",")))
+	tickers, err := self.edgar.CompanyTickersExchange(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch company tickers: %w", err)
+	}
+
+	companies := self.filterExchanges(tickers)
+	self.log(ctx).Info("fetched tickers", slog.Int("length", len(tickers)),
+		slog.Int("filtered", len(companies)))
+	return self.sortCompanies(ctx, companies), nil
+}
+
+func (self *Upload) filterExchanges(tickers []client.CompanyTickerExchange,
+) []client.CompanyTicker {
+	companies := make([]client.CompanyTicker, 0, len(tickers))
+	for i := range tickers {
+		t := &tickers[i]
+		if slices.ContainsFunc(self.exchanges, func(exchange string) bool {
+			return strings.EqualFold(exchange, t.Exchange)
+		}) {
+			companies = append(companies, t.CompanyTicker)
+		}
+	}
+	return companies
+}
+
+// sortCompanies sorts companies by CIK, loaded companies first, and removes
+// duplicated CIKs. The sort is stable, because one company can have a few
+// tickers, and only first of them by original order is kept.
 func (self *Upload) sortCompanies(ctx context.Context,
 	companies []client.CompanyTicker,
 ) []client.CompanyTicker {
-	slices.SortFunc(companies, func(a, b client.CompanyTicker) int {
+	slices.SortStableFunc(companies, func(a, b client.CompanyTicker) int {
 		switch {
 		case self.loadedCompany(a.CIK) && self.loadedCompany(b.CIK):
 			return cmp.Compare(a.CIK, b.CIK)
@@ -230,46 +413,119 @@ func (self *Upload) uploadUnknownCompanies(ctx context.Context) error {
 		if ctx.Err() != nil {
 			break
 		}
+		if i > 0 && i%evictFactsEvery == 0 {
+			self.evictFacts(ctx)
+		}
 		company := &self.unknown[i]
 		cik, title := company.CIK, company.Title
-		l := self.log(ctx).With(
+		ctx := self.WithContextLogger(ctx,
 			slog.String("progress", fmt.Sprintf("%v/%v", i+1, len(self.unknown))),
 			slog.Uint64("CIK", uint64(cik)))
 		g.Go(func() error {
-			return self.processCompanyFacts(ContextWithLogger(ctx, l), cik, title)
+			return self.companyError(ctx, cik,
+				self.processCompanyFacts(ctx, cik, title))
 		})
 	}
 	return g.Wait() //nolint:wrapcheck // returned not from external package
 }
 
+func (self *Upload) evictFacts(ctx context.Context) {
+	if n := self.knownFacts.Evict(knownFactTTL); n > 0 {
+		self.log(ctx).Debug("evicted known facts", slog.Int("evicted", n),
+			slog.Int("len", self.knownFacts.Len()))
+	}
+}
+
+// companyError returns nil, if err is nil or errorHandler allows to skip
+// company cik, or err as is.
+func (self *Upload) companyError(ctx context.Context, cik uint32, err error,
+) error {
+	if err == nil {
+		return nil
+	}
+
+	self.stats.errors.Add(1)
+	if errors.Is(err, ErrCompanyTimeout) {
+		self.log(ctx).LogAttrs(ctx, slog.LevelWarn, "skip company, retry next run",
+			slog.String("error", err.Error()))
+		return nil
+	} else if self.errorHandler == nil || !self.errorHandler(cik, err) {
+		return err
+	}
+	self.log(ctx).LogAttrs(ctx, slog.LevelError, "skip company",
+		slog.String("error", err.Error()))
+	return nil
+}
+
 func (self *Upload) processCompanyFacts(ctx context.Context, cik uint32,
 	title string,
+) error {
+	return self.withCompanyTimeout(ctx, func(ctx context.Context) error {
+		return self.uploadCompanyFacts(ctx, cik, title)
+	})
+}
+
+// withCompanyTimeout calls fn with ctx limited by companyTimeout, if it's set.
+// If the timeout fires, it returns ErrCompanyTimeout.
+func (self *Upload) withCompanyTimeout(ctx context.Context,
+	fn func(ctx context.Context) error,
+) error {
+	if self.companyTimeout <= 0 {
+		return fn(ctx)
+	}
+
+	startedAt := time.Now()
+	timeoutCtx, cancel := context.WithTimeoutCause(ctx, self.companyTimeout,
+		ErrCompanyTimeout)
+	defer cancel()
+
+	err := fn(timeoutCtx)
+	if err != nil && ctx.Err() == nil &&
+		errors.Is(context.Cause(timeoutCtx), ErrCompanyTimeout) {
+		duration := time.Since(startedAt)
+		self.log(ctx).LogAttrs(ctx, slog.LevelWarn, "company timeout",
+			slog.Duration("duration", duration))
+		return fmt.Errorf("%w after %v: %w", ErrCompanyTimeout,
+			duration.Round(time.Millisecond), err)
+	}
+	return err
+}
+
+func (self *Upload) uploadCompanyFacts(ctx context.Context, cik uint32,
+	title string,
 ) error {
 	self.log(ctx).Info("fetch company facts", slog.String("title", title))
-	companyFacts, err := self.companyFacts(ctx, cik, title)
+	companyFacts, cik, err := self.companyFacts(ctx, cik, title)
 	if err != nil {
 		return err
 	} else if companyFacts == nil {
 		return nil
 	}
+	self.log(ctx).Info("fetched company facts",
+		slog.Int("facts", companyFacts.FactCount()),
+		slog.Int("units", companyFacts.FactUnitCount()))
 
-	err = self.iterateCompanyFacts(ctx, cik, companyFacts, self.addFactUnits)
+	err = self.iterateCompanyFacts(ctx, cik, companyFacts.Facts,
+		self.addFactUnits)
 	if err != nil {
 		return fmt.Errorf("processCompanyFacts: %w", err)
 	}
+	self.stats.companies.Add(1)
 	return nil
 }
 
+// companyFacts fetches and returns facts of company cik, together with CIK
+// for storing them, which depends on CIKMismatchPolicy.
 func (self *Upload) companyFacts(ctx context.Context, cik uint32, title string,
-) (map[string]map[string]client.CompanyFact, error) {
+) (*client.CompanyFacts, uint32, error) {
 	facts, err := self.retryCompanyFacts(ctx, retryNum, cik)
 	if err != nil {
 		var s *client.UnexpectedStatusError
 		if errors.As(err, &s) && s.StatusCode() == http.StatusNotFound {
 			self.log(ctx).Info("skip company", slog.Any("cause", err))
-			return nil, nil
+			return nil, cik, nil
 		}
-		return nil, err
+		return nil, cik, err
 	}
 
 	if facts.EntityName == "" {
@@ -279,19 +535,83 @@ func (self *Upload) companyFacts(ctx context.Context, cik uint32, title string,
 		title = facts.EntityName
 	}
 
-	if facts.Id() != cik {
-		self.log(ctx).LogAttrs(ctx, slog.LevelWarn, "wrong cik",
-			slog.Uint64("cik", uint64(facts.Id())))
+	if cik, err = self.mismatchedCIK(ctx, cik, facts.Id()); err != nil {
+		return nil, cik, fmt.Errorf("companyFacts: %w", err)
 	}
 
 	unknownCompany, err := self.repo.AddCompany(ctx, cik, title)
 	if err != nil {
-		return nil, fmt.Errorf("companyFacts: %w", err)
+		return nil, cik, fmt.Errorf("companyFacts: %w", err)
 	} else if unknownCompany {
 		self.log(ctx).Info("add company")
+	} else if err := self.updateCompanyName(ctx, cik, title); err != nil {
+		return nil, cik, fmt.Errorf("companyFacts: %w", err)
+	}
+
+	return &facts, cik, nil
+}
+
+// mismatchedCIK returns CIK for storing company facts of requested CIK cik,
+// fetched with CIK respCIK, according to CIKMismatchPolicy.
+func (self *Upload) mismatchedCIK(ctx context.Context, cik, respCIK uint32,
+) (uint32, error) {
+	if respCIK == cik {
+		return cik, nil
 	}
 
-	return facts.Facts, nil
+	switch self.cikMismatch {
+	case CIKMismatchUseAPI:
+		self.log(ctx).LogAttrs(ctx, slog.LevelWarn, "wrong cik",
+			slog.Uint64("respCIK", uint64(respCIK)))
+		return cik, nil
+	case CIKMismatchUseResponse:
+	case CIKMismatchNear:
+		if !nearCIK(cik, respCIK) {
+			return cik, fmt.Errorf("%w: requested CIK=%v, got CIK=%v",
+				ErrCIKMismatch, cik, respCIK)
+		}
+	default:
+		return cik, fmt.Errorf("%w: requested CIK=%v, got CIK=%v",
+			ErrCIKMismatch, cik, respCIK)
+	}
+
+	self.log(ctx).LogAttrs(ctx, slog.LevelWarn, "wrong cik, use it",
+		slog.Uint64("respCIK", uint64(respCIK)))
+	return respCIK, nil
+}
+
+// nearCIK reports whether respCIK differs from cik by no more than 1% of cik.
+func nearCIK(cik, respCIK uint32) bool {
+	diff := int64(cik) - int64(respCIK)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff*100 <= int64(cik)
+}
+
+// logResponseSize logs size of company facts responses. It's designed for
+// client.WithSizeTracking.
+func (self *Upload) logResponseSize(url string, n int64) {
+	if strings.Contains(url, companyFactsPath) {
+		self.log(context.Background()).Debug("company facts response",
+			slog.String("url", url), slog.Int64("response_bytes", n))
+	}
+}
+
+func (self *Upload) updateCompanyName(ctx context.Context, cik uint32,
+	name string,
+) error {
+	if !self.updateNames || name == "" {
+		return nil
+	}
+
+	updated, err := self.repo.UpdateCompanyName(ctx, cik, name)
+	if err != nil {
+		return fmt.Errorf("failed update company name: %w", err)
+	} else if updated {
+		self.log(ctx).Info("company name changed", slog.String("name", name))
+	}
+	return nil
 }
 
 func (self *Upload) retryCompanyFacts(ctx context.Context, tries int, cik uint32,
@@ -324,8 +644,8 @@ func (self *Upload) retryFunc(ctx context.Context, max int,
 		if ctx.Err() != nil {
 			return false, fmt.Errorf("stop retrying: %w", ctx.Err())
 		}
-		l := self.log(ctx).With(slog.Int("try", i+1))
-		if ok, err := fn(ContextWithLogger(ctx, l), i); err != nil {
+		ctx := self.WithContextLogger(ctx, slog.Int("try", i+1))
+		if ok, err := fn(ctx, i); err != nil {
 			return false, err
 		} else if ok {
 			return true, nil
@@ -339,14 +659,18 @@ func (self *Upload) iterateCompanyFacts(ctx context.Context, cik uint32,
 	fn func(ctx context.Context, cik, factId, unitId uint32,
 		factUnits []client.FactUnit) error,
 ) error {
+	var labels []pendingLabel
 	for taxName, facts := range companyFacts {
 		for factName, fact := range facts {
 			factId, err := self.addFact(ctx, taxName, factName, fact.Label,
-				fact.Description)
+				fact.Description, &labels)
 			if err != nil {
 				return fmt.Errorf("iterateCompanyFacts: company CIK=%v: %w", cik, err)
 			}
 			for unitName, factUnits := range fact.Units {
+				if !fact.HasFactUnits(unitName) {
+					continue
+				}
 				unitId, err := self.addUnit(ctx, unitName)
 				if err != nil {
 					return err
@@ -358,36 +682,51 @@ func (self *Upload) iterateCompanyFacts(ctx context.Context, cik uint32,
 			}
 		}
 	}
+
+	if err := self.addLabels(ctx, labels); err != nil {
+		return fmt.Errorf("iterateCompanyFacts: company CIK=%v: %w", cik, err)
+	}
 	return nil
 }
 
+// pendingLabel is a new label of known fact, which addFact found and addLabels
+// adds into the repo.
+type pendingLabel struct {
+	fact  *knownFact
+	entry repo.LabelEntry
+}
+
+// addFact returns id of fact, adding it into the repo, if it's unknown yet. New
+// label of already known fact is appended to labels, for adding all labels of
+// company at once by addLabels.
 func (self *Upload) addFact(ctx context.Context, tax, name, label, descr string,
+	labels *[]pendingLabel,
 ) (uint32, error) {
 	factKey := self.makeFactKey(tax, name)
 	labelHash := xxhash.Sum64String(label)
 	descrHash := xxhash.Sum64String(descr)
 
-	addLabel := func(factId uint32) error {
-		err := self.repo.AddLabel(ctx, factId, label, descr, labelHash, descrHash)
-		if err != nil {
-			return fmt.Errorf("failed add label fact %q: %w", factKey, err)
-		}
-		return nil
-	}
-
 	if fact, ok := self.knownFacts.Fact(factKey); ok {
-		return fact.Id, fact.AddLabel(labelHash, descrHash, func() error {
-			return addLabel(fact.Id)
-		})
+		if !fact.HasLabel(labelHash, descrHash) {
+			*labels = append(*labels, pendingLabel{
+				fact: fact,
+				entry: repo.LabelEntry{
+					FactId:    fact.Id,
+					Label:     label,
+					Descr:     descr,
+					LabelHash: labelHash,
+					DescrHash: descrHash,
+				},
+			})
+		}
+		return fact.Id, nil
 	}
 
 	fact, err := self.knownFacts.Create(factKey, labelHash, descrHash,
 		func() (uint32, error) {
-			factId, err := self.repo.AddFact(ctx, tax, name)
-			if err != nil {
-				return 0, err //nolint:wrapcheck // will wrap below
-			}
-			return factId, addLabel(factId)
+			//nolint:wrapcheck // will wrap below
+			return self.repo.AddFactWithLabel(ctx, tax, name, label, descr,
+				labelHash, descrHash)
 		})
 	if err != nil {
 		return 0, fmt.Errorf("failed add fact %q: %w", factKey, err)
@@ -395,6 +734,29 @@ func (self *Upload) addFact(ctx context.Context, tax, name, label, descr string,
 	return fact.Id, nil
 }
 
+// addLabels adds labels into the repo at once and remembers them as known
+// after that.
+func (self *Upload) addLabels(ctx context.Context, labels []pendingLabel,
+) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	entries := make([]repo.LabelEntry, len(labels))
+	for i := range labels {
+		entries[i] = labels[i].entry
+	}
+	if _, err := self.repo.BatchAddFactLabels(ctx, entries); err != nil {
+		return fmt.Errorf("failed add %v labels: %w", len(entries), err)
+	}
+
+	for i := range labels {
+		l := &labels[i]
+		l.fact.RememberLabel(l.entry.LabelHash, l.entry.DescrHash)
+	}
+	return nil
+}
+
 func (self *Upload) addUnit(ctx context.Context, name string) (uint32, error) {
 	unitId, err := self.knownUnits.Id(ctx, name, func() (uint32, error) {
 		return self.repo.AddUnit(ctx, name)
@@ -408,10 +770,10 @@ func (self *Upload) addUnit(ctx context.Context, name string) (uint32, error) {
 func (self *Upload) addFactUnits(ctx context.Context, cik uint32,
 	factId, unitId uint32, clientFacts []client.FactUnit,
 ) error {
-	err := self.repo.CopyFactUnits(ctx, len(clientFacts),
+	err := self.copyFactUnits(ctx, cik, len(clientFacts),
 		func(i int) (repo.FactUnit, error) {
 			return self.repoFactUnit(cik, factId, unitId, &clientFacts[i])
-		})
+		}, true)
 	if err != nil {
 		return fmt.Errorf("failed add %v facts: cik=%v, factId=%v, unitId=%v: %w",
 			len(clientFacts), cik, factId, unitId, err)
@@ -419,6 +781,48 @@ func (self *Upload) addFactUnits(ctx context.Context, cik uint32,
 	return nil
 }
 
+// copyFactUnits copies length fact units of company cik into the repo, using
+// batches of batchSize fact units, each by its own [repo.Repo.CopyFactUnits].
+// It logs progress every factUnitsLogBatches batches. If a batch fails,
+// skipFailed is true and errorHandler allows it, copyFactUnits skips the batch
+// and continues with the next one. Update must not skip batches, because
+// skipped fact units would never be fetched again.
+func (self *Upload) copyFactUnits(ctx context.Context, cik uint32, length int,
+	next func(i int) (repo.FactUnit, error), skipFailed bool,
+) error {
+	batchSize := self.batchSize
+	if batchSize <= 0 {
+		batchSize = length
+	}
+	batches := 0
+	if batchSize > 0 {
+		batches = (length + batchSize - 1) / batchSize
+	}
+
+	for batch, start := 1, 0; start < length; batch, start = batch+1, start+batchSize {
+		n := min(batchSize, length-start)
+		err := self.repo.CopyFactUnits(ctx, n,
+			func(i int) (repo.FactUnit, error) { return next(start + i) })
+		if err != nil {
+			self.log(ctx).LogAttrs(ctx, slog.LevelError, "failed copy fact units",
+				slog.Int("batch", batch), slog.Int("batches", batches),
+				slog.String("error", err.Error()))
+			if !skipFailed || self.errorHandler == nil ||
+				!self.errorHandler(cik, err) {
+				return fmt.Errorf("batch %v of %v: %w", batch, batches, err)
+			}
+			self.stats.errors.Add(1)
+			continue
+		}
+		self.stats.factUnits.Add(uint64(n))
+		if batch%factUnitsLogBatches == 0 {
+			self.log(ctx).Info("copying fact units", slog.Int("batch", batch),
+				slog.Int("batches", batches))
+		}
+	}
+	return nil
+}
+
 func (self *Upload) repoFactUnit(cik uint32, factId, unitId uint32,
 	clientFact *client.FactUnit,
 ) (repo.FactUnit, error) {
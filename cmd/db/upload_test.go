@@ -1,13 +1,26 @@
 package db
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/dsh2dsh/edgar/client"
+	mocksClient "github.com/dsh2dsh/edgar/internal/mocks/client"
+	mocks "github.com/dsh2dsh/edgar/internal/mocks/db"
 	"github.com/dsh2dsh/edgar/internal/repo"
 )
 
@@ -109,3 +122,587 @@ func TestUpload_repoFactUnit(t *testing.T) {
 		})
 	}
 }
+
+func TestUpload_updateCompanyName(t *testing.T) {
+	const appleCIK = 320193
+	const appleName = "Apple Inc."
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	tests := []struct {
+		name     string
+		disabled bool
+		company  string
+		mockRepo func(m *mocks.MockRepo)
+		errorIs  error
+	}{
+		{
+			name:     "disabled",
+			disabled: true,
+			company:  appleName,
+		},
+		{
+			name: "empty name",
+		},
+		{
+			name:    "changed",
+			company: appleName,
+			mockRepo: func(m *mocks.MockRepo) {
+				m.EXPECT().UpdateCompanyName(ctx, uint32(appleCIK), appleName).
+					Return(true, nil)
+			},
+		},
+		{
+			name:    "not changed",
+			company: appleName,
+			mockRepo: func(m *mocks.MockRepo) {
+				m.EXPECT().UpdateCompanyName(ctx, uint32(appleCIK), appleName).
+					Return(false, nil)
+			},
+		},
+		{
+			name:    "with error",
+			company: appleName,
+			mockRepo: func(m *mocks.MockRepo) {
+				m.EXPECT().UpdateCompanyName(ctx, uint32(appleCIK), appleName).
+					Return(false, wantErr)
+			},
+			errorIs: wantErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mocks.NewMockRepo(t)
+			if tt.mockRepo != nil {
+				tt.mockRepo(r)
+			}
+			u := NewUpload(nil, r).WithCompanyNameUpdate(!tt.disabled)
+			err := u.updateCompanyName(ctx, appleCIK, tt.company)
+			if tt.errorIs != nil {
+				require.ErrorIs(t, err, tt.errorIs)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUpload_addFact(t *testing.T) {
+	const tax, name, label, descr = "us-gaap", "AccountsPayable",
+		"Accounts Payable", "Accounts payable description"
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	r := mocks.NewMockRepo(t)
+	u := NewUpload(nil, r)
+	factKey := u.makeFactKey(tax, name)
+	var labels []pendingLabel
+
+	r.EXPECT().AddFactWithLabel(ctx, tax, name, label, descr, mock.Anything,
+		mock.Anything).Return(0, wantErr).Once()
+	_, err := u.addFact(ctx, tax, name, label, descr, &labels)
+	require.ErrorIs(t, err, wantErr)
+	_, ok := u.knownFacts.Fact(factKey)
+	assert.False(t, ok, "failed fact must not be cached")
+
+	r.EXPECT().AddFactWithLabel(ctx, tax, name, label, descr, mock.Anything,
+		mock.Anything).Return(1, nil).Once()
+	factId, err := u.addFact(ctx, tax, name, label, descr, &labels)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), factId)
+	_, ok = u.knownFacts.Fact(factKey)
+	assert.True(t, ok)
+
+	factId, err = u.addFact(ctx, tax, name, label, descr, &labels)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), factId)
+	assert.Empty(t, labels)
+
+	factId, err = u.addFact(ctx, tax, name, "Accounts Payable, Current", descr,
+		&labels)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), factId)
+	require.Len(t, labels, 1)
+	assert.Equal(t, uint32(1), labels[0].entry.FactId)
+	assert.Equal(t, "Accounts Payable, Current", labels[0].entry.Label)
+	assert.Equal(t, descr, labels[0].entry.Descr)
+}
+
+func TestUpload_addLabels(t *testing.T) {
+	ctx := context.Background()
+	r := mocks.NewMockRepo(t)
+	u := NewUpload(nil, r)
+	require.NoError(t, u.addLabels(ctx, nil))
+
+	fact := newKnownFact(1, 1, 1)
+	labels := []pendingLabel{
+		{fact: fact, entry: repo.LabelEntry{FactId: 1, LabelHash: 2, DescrHash: 2}},
+		{fact: fact, entry: repo.LabelEntry{FactId: 1, LabelHash: 2, DescrHash: 3}},
+	}
+	entries := []repo.LabelEntry{labels[0].entry, labels[1].entry}
+
+	wantErr := errors.New("test error")
+	r.EXPECT().BatchAddFactLabels(ctx, entries).Return(0, wantErr).Once()
+	require.ErrorIs(t, u.addLabels(ctx, labels), wantErr)
+	assert.False(t, fact.HasLabel(2, 2), "failed label must not be cached")
+
+	r.EXPECT().BatchAddFactLabels(ctx, entries).Return(2, nil).Once()
+	require.NoError(t, u.addLabels(ctx, labels))
+	assert.True(t, fact.HasLabel(2, 2))
+	assert.True(t, fact.HasLabel(2, 3))
+}
+
+func TestUpload_companyFacts_cikMismatch(t *testing.T) {
+	const appleCIK, nearCIK, farCIK = 320193, 320194, 1067983
+	const appleName = "Apple Inc."
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		policy  CIKMismatchPolicy
+		respCIK uint32
+		wantCIK uint32
+		errorIs error
+	}{
+		{
+			name:    "Near",
+			policy:  CIKMismatchNear,
+			respCIK: nearCIK,
+			wantCIK: nearCIK,
+		},
+		{
+			name:    "Near too far",
+			policy:  CIKMismatchNear,
+			respCIK: farCIK,
+			wantCIK: appleCIK,
+			errorIs: ErrCIKMismatch,
+		},
+		{
+			name:    "UseAPI",
+			policy:  CIKMismatchUseAPI,
+			respCIK: farCIK,
+			wantCIK: appleCIK,
+		},
+		{
+			name:    "UseResponse",
+			policy:  CIKMismatchUseResponse,
+			respCIK: farCIK,
+			wantCIK: farCIK,
+		},
+		{
+			name:    "Strict",
+			policy:  CIKMismatchStrict,
+			respCIK: nearCIK,
+			wantCIK: appleCIK,
+			errorIs: ErrCIKMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edgar := newTestCompanyFactsClient(t, client.CompanyFacts{
+				CIK: client.CIK(tt.respCIK), EntityName: appleName,
+			})
+			r := mocks.NewMockRepo(t)
+			if tt.errorIs == nil {
+				r.EXPECT().AddCompany(ctx, tt.wantCIK, appleName).Return(true, nil)
+			}
+			u := NewUpload(edgar, r)
+			assert.Same(t, u, u.WithCIKMismatchPolicy(tt.policy))
+
+			facts, cik, err := u.companyFacts(ctx, appleCIK, "")
+			assert.Equal(t, tt.wantCIK, cik)
+			if tt.errorIs != nil {
+				require.ErrorIs(t, err, tt.errorIs)
+				assert.Nil(t, facts)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, facts)
+				assert.Equal(t, tt.respCIK, facts.Id())
+			}
+		})
+	}
+}
+
+// newTestCompanyFactsClient returns EDGAR client, which responds with
+// companyFacts to every request.
+func newTestCompanyFactsClient(t *testing.T, companyFacts client.CompanyFacts,
+) *client.Client {
+	factBytes, err := json.Marshal(companyFacts)
+	require.NoError(t, err)
+
+	httpClient := mocksClient.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			recorder := httptest.NewRecorder()
+			_, err := recorder.Write(factBytes)
+			require.NoError(t, err)
+			return recorder.Result(), nil
+		})
+	return client.New(client.WithHttpClient(httpClient),
+		client.WithRateLimiter(nil))
+}
+
+func TestNearCIK(t *testing.T) {
+	assert.True(t, nearCIK(320193, 320193))
+	assert.True(t, nearCIK(320193, 320194))
+	assert.True(t, nearCIK(320193, 316992))
+	assert.False(t, nearCIK(320193, 316991))
+	assert.False(t, nearCIK(320193, 1067983))
+	assert.False(t, nearCIK(0, 1))
+}
+
+func TestUpload_filterExchanges(t *testing.T) {
+	apple := client.CompanyTicker{CIK: 320193, Ticker: "AAPL", Title: "Apple Inc."}
+	berkshire := client.CompanyTicker{
+		CIK: 1067983, Ticker: "BRK-B", Title: "BERKSHIRE HATHAWAY INC",
+	}
+	noble := client.CompanyTicker{CIK: 1895262, Ticker: "NE", Title: "Noble Corp"}
+
+	tickers := []client.CompanyTickerExchange{
+		{CompanyTicker: apple, Exchange: "Nasdaq"},
+		{CompanyTicker: berkshire, Exchange: "NYSE"},
+		{CompanyTicker: noble},
+	}
+
+	u := NewUpload(nil, nil).WithExchanges([]string{"NASDAQ", "nyse"})
+	assert.Equal(t, []client.CompanyTicker{apple, berkshire},
+		u.filterExchanges(tickers))
+
+	u.WithExchanges([]string{"CBOE"})
+	assert.Empty(t, u.filterExchanges(tickers))
+}
+
+func TestUpload_sortCompanies(t *testing.T) {
+	const loadedCIK, unknownCIK = 1067983, 320193
+	tickers := []client.CompanyTicker{
+		{CIK: unknownCIK, Ticker: "AAPL", Title: "Apple Inc."},
+		{CIK: loadedCIK, Ticker: "BRK-B", Title: "BERKSHIRE HATHAWAY INC"},
+		{CIK: unknownCIK, Ticker: "AAPL2", Title: "Apple Inc."},
+		{CIK: loadedCIK, Ticker: "BRK-A", Title: "BERKSHIRE HATHAWAY INC"},
+		{CIK: 1, Ticker: "FOO", Title: "Foo"},
+	}
+
+	u := NewUpload(nil, nil)
+	u.lastFiled = map[uint32]time.Time{loadedCIK: {}}
+	for range 10 {
+		companies := u.sortCompanies(context.Background(), slices.Clone(tickers))
+		assert.Equal(t, []client.CompanyTicker{tickers[1], tickers[4], tickers[0]},
+			companies)
+	}
+}
+
+func TestUpload_unknownCompanies(t *testing.T) {
+	tickers := map[string]client.CompanyTicker{
+		"0": {CIK: 5, Ticker: "E", Title: "Unknown E"},
+		"1": {CIK: 4, Ticker: "D", Title: "Loaded D"},
+		"2": {CIK: 1, Ticker: "A", Title: "Unknown A"},
+		"3": {CIK: 3, Ticker: "C", Title: "Unknown C"},
+		"4": {CIK: 2, Ticker: "B", Title: "Loaded B"},
+		"5": {CIK: 3, Ticker: "C2", Title: "Unknown C"},
+		"6": {CIK: 6, Ticker: "F", Title: "Loaded F"},
+	}
+
+	httpClient := mocksClient.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			recorder := httptest.NewRecorder()
+			if err := json.NewEncoder(recorder).Encode(tickers); err != nil {
+				return nil, err
+			}
+			return recorder.Result(), nil
+		})
+	edgar := client.New(client.WithHttpClient(httpClient),
+		client.WithRateLimiter(nil))
+
+	u := NewUpload(edgar, nil)
+	u.lastFiled = map[uint32]time.Time{2: {}, 4: {}, 6: {}}
+	companies, err := u.unknownCompanies(context.Background())
+	require.NoError(t, err)
+
+	ciks := make([]uint32, len(companies))
+	for i := range companies {
+		ciks[i] = companies[i].CIK
+	}
+	assert.Equal(t, []uint32{1, 3, 5}, ciks)
+
+	u.lastFiled = map[uint32]time.Time{1: {}, 2: {}, 3: {}, 4: {}, 5: {}, 6: {}}
+	companies, err = u.unknownCompanies(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, companies)
+}
+
+func TestUpload_WithErrorHandler(t *testing.T) {
+	const appleCIK = 320193
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	u := NewUpload(nil, nil)
+	require.NoError(t, u.companyError(ctx, appleCIK, nil))
+	require.ErrorIs(t, u.companyError(ctx, appleCIK, wantErr), wantErr)
+
+	var skipped []uint32
+	assert.Same(t, u, u.WithErrorHandler(func(cik uint32, err error) bool {
+		skipped = append(skipped, cik)
+		return errors.Is(err, wantErr)
+	}))
+	require.NoError(t, u.companyError(ctx, appleCIK, nil))
+	require.NoError(t, u.companyError(ctx, appleCIK, wantErr))
+
+	otherErr := errors.New("other error")
+	require.ErrorIs(t, u.companyError(ctx, appleCIK, otherErr), otherErr)
+	assert.Equal(t, []uint32{appleCIK, appleCIK}, skipped)
+	assert.Equal(t, uint64(3), u.Stats().Errors)
+}
+
+func TestUpload_WithCompanyTimeout(t *testing.T) {
+	ctx := context.Background()
+	httpClient := mocksClient.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		})
+	edgar := client.New(client.WithHttpClient(httpClient),
+		client.WithRateLimiter(nil))
+
+	u := NewUpload(edgar, nil)
+	assert.Same(t, u, u.WithCompanyTimeout(10*time.Millisecond))
+	err := u.processCompanyFacts(ctx, 1, "Foo")
+	require.ErrorIs(t, err, ErrCompanyTimeout)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NoError(t, u.companyError(ctx, 1, err))
+	assert.Equal(t, uint64(1), u.Stats().Errors)
+
+	u.unknown = []client.CompanyTicker{{CIK: 1}, {CIK: 2}}
+	require.NoError(t, u.uploadUnknownCompanies(ctx))
+	assert.Equal(t, uint64(3), u.Stats().Errors)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	u.WithCompanyTimeout(time.Minute)
+	err = u.processCompanyFacts(ctx, 1, "Foo")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotErrorIs(t, err, ErrCompanyTimeout)
+}
+
+func TestUpload_uploadUnknownCompanies_errorHandler(t *testing.T) {
+	ctx := context.Background()
+	httpClient := mocksClient.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			recorder := httptest.NewRecorder()
+			recorder.WriteHeader(http.StatusInternalServerError)
+			return recorder.Result(), nil
+		})
+	edgar := client.New(client.WithHttpClient(httpClient),
+		client.WithRateLimiter(nil))
+
+	u := NewUpload(edgar, nil)
+	u.unknown = []client.CompanyTicker{{CIK: 1}, {CIK: 2}}
+	require.ErrorIs(t, u.uploadUnknownCompanies(ctx), client.ErrUnexpectedStatus)
+
+	var skipped []uint32
+	u.WithErrorHandler(func(cik uint32, err error) bool {
+		skipped = append(skipped, cik)
+		return true
+	})
+	require.NoError(t, u.uploadUnknownCompanies(ctx))
+	assert.Equal(t, []uint32{1, 2}, skipped)
+}
+
+func TestUpload_copyFactUnits(t *testing.T) {
+	ctx := context.Background()
+	facts := make([]repo.FactUnit, 5)
+	for i := range facts {
+		facts[i].FY = uint(2000 + i)
+	}
+	next := func(i int) (repo.FactUnit, error) { return facts[i], nil }
+
+	tests := []struct {
+		name      string
+		batchSize int
+		want      [][]repo.FactUnit
+	}{
+		{
+			name: "no limit",
+			want: [][]repo.FactUnit{facts},
+		},
+		{
+			name:      "bigger batch",
+			batchSize: 10,
+			want:      [][]repo.FactUnit{facts},
+		},
+		{
+			name:      "with batches",
+			batchSize: 2,
+			want:      [][]repo.FactUnit{facts[:2], facts[2:4], facts[4:]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mocks.NewMockRepo(t)
+			var got [][]repo.FactUnit
+			r.EXPECT().CopyFactUnits(ctx, mock.Anything, mock.Anything).RunAndReturn(
+				func(ctx context.Context, length int,
+					next func(i int) (repo.FactUnit, error),
+				) error {
+					batch := make([]repo.FactUnit, length)
+					for i := range batch {
+						fact, err := next(i)
+						require.NoError(t, err)
+						batch[i] = fact
+					}
+					got = append(got, batch)
+					return nil
+				})
+
+			u := NewUpload(nil, r).WithBatchSize(tt.batchSize)
+			require.NoError(t, u.copyFactUnits(ctx, 320193, len(facts), next, true))
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, uint64(len(facts)), u.Stats().FactUnits)
+		})
+	}
+
+	wantErr := errors.New("test error")
+	r := mocks.NewMockRepo(t)
+	r.EXPECT().CopyFactUnits(ctx, 2, mock.Anything).Return(wantErr).Once()
+	u := NewUpload(nil, r).WithBatchSize(2)
+	require.ErrorIs(t, u.copyFactUnits(ctx, 320193, len(facts), next, true),
+		wantErr)
+}
+
+func TestUpload_copyFactUnits_skipBatch(t *testing.T) {
+	ctx := context.Background()
+	facts := make([]repo.FactUnit, 25)
+	next := func(i int) (repo.FactUnit, error) { return facts[i], nil }
+
+	wantErr := errors.New("test error")
+	r := mocks.NewMockRepo(t)
+	r.EXPECT().CopyFactUnits(ctx, 2, mock.Anything).Return(wantErr).Once()
+	r.EXPECT().CopyFactUnits(ctx, mock.Anything, mock.Anything).Return(nil)
+
+	var buf bytes.Buffer
+	var skipped []error
+	u := NewUpload(nil, r).WithBatchSize(2).
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))).
+		WithErrorHandler(func(cik uint32, err error) bool {
+			assert.Equal(t, uint32(320193), cik)
+			skipped = append(skipped, err)
+			return true
+		})
+
+	require.NoError(t, u.copyFactUnits(ctx, 320193, len(facts), next, true))
+	require.Len(t, skipped, 1)
+	require.ErrorIs(t, skipped[0], wantErr)
+	stats := u.Stats()
+	assert.Equal(t, uint64(len(facts)-2), stats.FactUnits)
+	assert.Equal(t, uint64(1), stats.Errors)
+
+	var msgs []string
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec struct {
+			Msg   string `json:"msg"`
+			Batch int    `json:"batch"`
+		}
+		require.NoError(t, dec.Decode(&rec))
+		msgs = append(msgs, fmt.Sprintf("%v %v", rec.Msg, rec.Batch))
+	}
+	assert.Equal(t, []string{"failed copy fact units 1", "copying fact units 10"},
+		msgs)
+
+	r = mocks.NewMockRepo(t)
+	r.EXPECT().CopyFactUnits(ctx, 2, mock.Anything).Return(nil).Once()
+	r.EXPECT().CopyFactUnits(ctx, 2, mock.Anything).Return(wantErr).Once()
+	u = NewUpload(nil, r).WithBatchSize(2).WithLogger(slog.New(
+		slog.NewJSONHandler(io.Discard, nil)))
+	err := u.copyFactUnits(ctx, 320193, len(facts), next, true)
+	require.ErrorIs(t, err, wantErr)
+	assert.ErrorContains(t, err, "batch 2 of 13")
+
+	r = mocks.NewMockRepo(t)
+	r.EXPECT().CopyFactUnits(ctx, 2, mock.Anything).Return(wantErr).Once()
+	u = NewUpload(nil, r).WithBatchSize(2).WithLogger(slog.New(
+		slog.NewJSONHandler(io.Discard, nil))).
+		WithErrorHandler(func(cik uint32, err error) bool { return true })
+	err = u.copyFactUnits(ctx, 320193, len(facts), next, false)
+	require.ErrorIs(t, err, wantErr, "update must not skip batches")
+	assert.Zero(t, u.Stats().Errors)
+}
+
+func TestUpload_WithContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	u := NewUpload(nil, nil).WithLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	ctx := u.WithContextLogger(context.Background())
+	assert.Same(t, u.logger, ContextLogger(ctx, nil))
+
+	ctx = u.WithContextLogger(ctx, slog.Uint64("CIK", 320193))
+	ctx = u.WithContextLogger(ctx, slog.Int("try", 1))
+	u.log(ctx).Info("test")
+
+	var got struct {
+		Msg string `json:"msg"`
+		CIK uint32 `json:"CIK"`
+		Try int    `json:"try"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "test", got.Msg)
+	assert.Equal(t, uint32(320193), got.CIK)
+	assert.Equal(t, 1, got.Try)
+}
+
+func TestUpload_logResponseSize(t *testing.T) {
+	var buf bytes.Buffer
+	u := NewUpload(nil, nil).WithLogger(slog.New(slog.NewJSONHandler(&buf,
+		&slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	u.logResponseSize("https://www.sec.gov/files/company_tickers.json", 10)
+	assert.Zero(t, buf.Len())
+
+	const url = "https://data.sec.gov/api/xbrl/companyfacts/CIK0000320193.json"
+	u.logResponseSize(url, 100)
+	var got struct {
+		URL   string `json:"url"`
+		Bytes int64  `json:"response_bytes"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, url, got.URL)
+	assert.Equal(t, int64(100), got.Bytes)
+}
+
+func TestUpload_preloadFacts(t *testing.T) {
+	ctx := context.Background()
+	labels := []repo.FactLabels{
+		{FactId: 1, FactTax: "us-gaap", FactName: "Assets", LabelId: 1, LabelHash: 1, DescrHash: 1},
+		{FactId: 2, FactTax: "us-gaap", FactName: "Liabilities", LabelId: 2, LabelHash: 2, DescrHash: 2},
+		{FactId: 1, FactTax: "us-gaap", FactName: "Assets", LabelId: 5, LabelHash: 3, DescrHash: 3},
+	}
+
+	r := mocks.NewMockRepo(t)
+	r.EXPECT().StreamFactLabels(ctx, mock.Anything).RunAndReturn(
+		func(ctx context.Context, fn func(item repo.FactLabels) error) error {
+			for _, item := range labels {
+				if err := fn(item); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Once()
+
+	u := NewUpload(nil, r)
+	require.NoError(t, u.preloadFacts(ctx))
+	assert.Equal(t, 2, u.knownFacts.Len())
+	assert.Equal(t, 4, u.knownFacts.TotalLabelCount())
+
+	fact, ok := u.knownFacts.Fact(u.makeFactKey("us-gaap", "Assets"))
+	require.True(t, ok)
+	assert.Equal(t, uint32(1), fact.Id)
+
+	wantErr := errors.New("test error")
+	r.EXPECT().StreamFactLabels(ctx, mock.Anything).Return(wantErr).Once()
+	require.ErrorIs(t, u.preloadFacts(ctx), wantErr)
+}
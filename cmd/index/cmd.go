@@ -1,7 +1,7 @@
 package index
 
 import (
-	"path/filepath"
+	"path"
 
 	"github.com/spf13/cobra"
 
@@ -10,6 +10,8 @@ import (
 
 var (
 	edgarDataDir string
+	filterForms  []string
+	maxDepth     int
 
 	Cmd = cobra.Command{
 		Use:   "archive",
@@ -26,17 +28,23 @@ var (
 
   - Download all files from daily-index:
 
-    $ edgar index download daily-index`,
+    $ edgar index download daily-index
+
+  - Download master.gz files and list filenames of 10-K and 10-Q filings:
+
+    $ edgar index download full-index master.gz --filter-forms 10-K,10-Q`,
 		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			client, err := common.NewClient()
 			cobra.CheckErr(err)
 			d := NewDownload(client, newDownloadDir(edgarDataDir)).
-				WithProcsLimit(downloadProcs)
+				WithProcsLimit(downloadProcs).WithFilterForms(filterForms).
+				WithMaxDepth(maxDepth).
+				WithDownloadRetry(RetryPolicy{Tries: downloadTries, Delay: retryDelay})
 			if len(args) > 1 {
 				d.WithNeedFiles(args[1:])
 			}
-			cobra.CheckErr(d.Download(filepath.Join(edgarPath, args[0])))
+			cobra.CheckErr(d.Download(path.Join(edgarPath, args[0])))
 		},
 	}
 )
@@ -45,4 +53,9 @@ func init() {
 	Cmd.AddCommand(&downloadCmd)
 	downloadCmd.Flags().StringVarP(&edgarDataDir, "datadir", "d", "./",
 		"store EDGAR files into this directory")
+	downloadCmd.Flags().StringSliceVar(&filterForms, "filter-forms", nil,
+		"write "+formsManifest+" with filenames of these forms for every "+
+			masterIndex)
+	downloadCmd.Flags().IntVar(&maxDepth, "max-depth", downloadDepth,
+		"don't go deeper than this number of subdirectories, 0 means unlimited")
 }
@@ -1,24 +1,39 @@
 package index
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
 	"github.com/dsh2dsh/edgar/client"
+	"github.com/dsh2dsh/edgar/client/index"
 )
 
 const (
 	downloadProcs = 10 // Number of parallel downloads
+	downloadDepth = 5  // Default max depth of directories, see WithMaxDepth
 	edgarPath     = "edgar"
+
+	masterIndex   = "master.gz"
+	formsManifest = "master.forms.txt" // filenames of filtered forms
+
+	downloadTries = 3           // Default tries of every file download
+	retryDelay    = time.Second // Default delay between them
 )
 
+// ErrMaxDepth returned by Download, when it goes deeper than configured by
+// WithMaxDepth.
+var ErrMaxDepth = errors.New("max depth of directories exceeded")
+
 func NewDownload(client *client.Client, st Storage) *Download {
 	return &Download{
 		client:  client,
@@ -31,8 +46,22 @@ type Download struct {
 	client  *client.Client
 	storage Storage
 
-	needFiles map[string]struct{}
-	procs     int
+	needFiles   map[string]struct{}
+	filterForms []string
+	procs       int
+	maxDepth    int
+	retry       RetryPolicy
+
+	unknownTypeHandler func(item client.ArchiveItem) error
+}
+
+// RetryPolicy configures how Download retries downloading of a file after
+// transient errors: 5xx statuses and network timeouts.
+type RetryPolicy struct {
+	// Tries is maximum number of tries. Zero or one means no retries.
+	Tries int
+	// Delay is a delay before every retry.
+	Delay time.Duration
 }
 
 type Storage interface {
@@ -47,6 +76,38 @@ func (self *Download) WithNeedFiles(needFiles []string) *Download {
 	return self
 }
 
+// WithFilterForms configures Download to write manifest file for every
+// downloaded master.gz. The manifest contains filenames of filings with form
+// type from forms, one per line.
+func (self *Download) WithFilterForms(forms []string) *Download {
+	self.filterForms = forms
+	return self
+}
+
+// WithMaxDepth limits how deep Download goes into subdirectories of
+// downloading path. Zero means unlimited.
+func (self *Download) WithMaxDepth(n int) *Download {
+	self.maxDepth = n
+	return self
+}
+
+// WithDownloadRetry configures retries of downloading files after transient
+// errors. By default Download doesn't retry.
+func (self *Download) WithDownloadRetry(policy RetryPolicy) *Download {
+	self.retry = policy
+	return self
+}
+
+// WithUnknownTypeHandler sets fn, which is called for every archive item of
+// unknown type, after logging a warning about it. It can collect such items or
+// return error for aborting Download. By default these items are skipped.
+func (self *Download) WithUnknownTypeHandler(
+	fn func(item client.ArchiveItem) error,
+) *Download {
+	self.unknownTypeHandler = fn
+	return self
+}
+
 func (self *Download) WithProcsLimit(lim int) *Download {
 	self.procs = lim
 	return self
@@ -56,7 +117,7 @@ func (self *Download) Download(path string) error {
 	g, ctx := errgroup.WithContext(context.Background())
 	g.SetLimit(self.procs)
 
-	if err := self.processIndex(ctx, path, g); err != nil {
+	if err := self.processIndex(ctx, path, 0, g); err != nil {
 		return err
 	}
 
@@ -67,9 +128,13 @@ func (self *Download) Download(path string) error {
 	return nil
 }
 
-func (self *Download) processIndex(ctx context.Context, path string,
+func (self *Download) processIndex(ctx context.Context, path string, depth int,
 	g *errgroup.Group,
 ) error {
+	if self.maxDepth > 0 && depth > self.maxDepth {
+		return fmt.Errorf("go into %v: %w (%v)", path, ErrMaxDepth, self.maxDepth)
+	}
+
 	index, skipPath, err := self.readIndex(ctx, path)
 	if err != nil {
 		return err
@@ -82,9 +147,11 @@ func (self *Download) processIndex(ctx context.Context, path string,
 		if ctx.Err() != nil {
 			return nil
 		}
-		handler, err := self.itemHandler(ctx, path, item)
+		handler, err := self.itemHandler(ctx, path, depth, item)
 		if err != nil {
 			return err
+		} else if handler == nil {
+			continue
 		} else if g != nil {
 			g.Go(handler)
 		} else if err := handler(); err != nil {
@@ -111,7 +178,7 @@ func (self *Download) readIndex(ctx context.Context, path string,
 	return
 }
 
-func (self *Download) itemHandler(ctx context.Context, path string,
+func (self *Download) itemHandler(ctx context.Context, path string, depth int,
 	item client.ArchiveItem,
 ) (h func() error, err error) {
 	fullPath, err := url.JoinPath(path, item.Name)
@@ -120,15 +187,31 @@ func (self *Download) itemHandler(ctx context.Context, path string,
 			path, item.Name, err)
 	}
 
+	// Handlers can be started after ctx cancelled, like when errgroup waits for
+	// free slot, so every handler checks it first and does nothing in this
+	// case.
 	switch item.Type {
 	case "dir":
-		h = func() error { return self.processIndex(ctx, fullPath, nil) }
+		h = func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return self.processIndex(ctx, fullPath, depth+1, nil)
+		}
 	case "file":
 		h = func() error {
-			if self.NeedFile(item.Name) {
-				return self.downloadFile(ctx, path, item.Name, fullPath)
+			if ctx.Err() != nil || !self.NeedFile(item.Name) {
+				return nil
+			}
+			return self.downloadFile(ctx, path, item.Name, fullPath)
+		}
+	default:
+		log.Printf("skip archive item of unknown type %q: %v", item.Type, fullPath)
+		if self.unknownTypeHandler != nil {
+			if err := self.unknownTypeHandler(item); err != nil {
+				return nil, fmt.Errorf("unknown type %q of %v: %w",
+					item.Type, fullPath, err)
 			}
-			return nil
 		}
 	}
 	return
@@ -144,6 +227,47 @@ func (self *Download) NeedFile(fname string) bool {
 
 func (self *Download) downloadFile(ctx context.Context, parentPath, fname,
 	fullPath string,
+) error {
+	var err error
+	for i := range max(self.retry.Tries, 1) {
+		if i > 0 {
+			log.Printf("retry download %v: %v", fullPath, err)
+			if err := sleepContext(ctx, self.retry.Delay); err != nil {
+				return fmt.Errorf("stop retrying download of %v: %w", fullPath, err)
+			}
+		}
+		err = self.tryDownloadFile(ctx, parentPath, fname, fullPath)
+		if err == nil || !transientError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// transientError returns true if err is worth to retry: 5xx status or network
+// timeout.
+func transientError(err error) bool {
+	var statusErr *client.UnexpectedStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode() >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // wrapped by caller
+	case <-t.C:
+		return nil
+	}
+}
+
+func (self *Download) tryDownloadFile(ctx context.Context, parentPath, fname,
+	fullPath string,
 ) error {
 	resp, err := self.client.GetArchiveFile(ctx, fullPath)
 	if err != nil {
@@ -151,9 +275,48 @@ func (self *Download) downloadFile(ctx context.Context, parentPath, fname,
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("download %v: %w", fullPath,
+			client.NewUnexpectedStatusError(resp))
+	}
+
 	log.Printf("download %v", fullPath)
-	if err = self.storage.Save(parentPath, fname, resp.Body); err != nil {
+	var r io.Reader = resp.Body
+	var buf bytes.Buffer
+	needManifest := len(self.filterForms) > 0 && fname == masterIndex
+	if needManifest {
+		r = io.TeeReader(r, &buf)
+	}
+
+	if err = self.storage.Save(parentPath, fname, r); err != nil {
 		return fmt.Errorf("download error: %w", err)
+	} else if needManifest {
+		return self.saveFormsManifest(parentPath, &buf)
+	}
+	return nil
+}
+
+func (self *Download) saveFormsManifest(parentPath string, r io.Reader) error {
+	f, err := index.NewGzipFile(r)
+	if err != nil {
+		return fmt.Errorf("forms manifest of %v: %w", parentPath, err)
+	} else if err := f.ReadHeaders(); err != nil {
+		return fmt.Errorf("forms manifest of %v: %w", parentPath, err)
+	}
+
+	var manifest bytes.Buffer
+	err = f.IterateByForm(self.filterForms, func(item *index.Item) error {
+		manifest.WriteString(item.Filename)
+		manifest.WriteByte('\n')
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("forms manifest of %v: %w", parentPath, err)
+	}
+
+	log.Printf("save %v/%v", parentPath, formsManifest)
+	if err := self.storage.Save(parentPath, formsManifest, &manifest); err != nil {
+		return fmt.Errorf("forms manifest of %v: %w", parentPath, err)
 	}
 	return nil
 }
@@ -5,13 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -86,12 +90,7 @@ func TestDownload_readIndex(t *testing.T) {
 				httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
 					func(req *http.Request) (*http.Response, error) {
 						index := readTestArchiveIndex(t, testPath)
-						b, err := json.Marshal(&index)
-						require.NoError(t, err)
-						recorder := httptest.NewRecorder()
-						_, err = recorder.Write(b)
-						require.NoError(t, err)
-						return recorder.Result(), nil
+						return newIndexResponse(t, &index), nil
 					})
 			}
 
@@ -145,6 +144,17 @@ func newTestDownload(t *testing.T, httpClient client.HttpRequestDoer,
 	return d
 }
 
+// newIndexResponse returns HTTP response with JSON encoded index, like
+// returned by EDGAR for index.json.
+func newIndexResponse(t *testing.T, index *client.ArchiveIndex) *http.Response {
+	b, err := json.Marshal(index)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	_, err = recorder.Write(b)
+	require.NoError(t, err)
+	return recorder.Result()
+}
+
 func TestDownload_itemHandler(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -152,6 +162,7 @@ func TestDownload_itemHandler(t *testing.T) {
 		item    client.ArchiveItem
 		wantNil bool
 		wantErr bool
+		wantLog string
 	}{
 		{
 			name: "dir",
@@ -168,12 +179,8 @@ func TestDownload_itemHandler(t *testing.T) {
 			path:    "edgar/full-index/1994/QTR1",
 			item:    client.ArchiveItem{Name: "master.gz", Type: "unknown"},
 			wantNil: true,
-		},
-		{
-			name:    "unknown type",
-			path:    "edgar/full-index/1994/QTR1",
-			item:    client.ArchiveItem{Name: "master.gz", Type: "unknown"},
-			wantNil: true,
+			wantLog: `skip archive item of unknown type "unknown": ` +
+				"edgar/full-index/1994/QTR1/master.gz",
 		},
 		{
 			name:    "JoinPath error",
@@ -185,8 +192,17 @@ func TestDownload_itemHandler(t *testing.T) {
 	ctx := context.Background()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
 			d := Download{}
-			f, err := d.itemHandler(ctx, tt.path, tt.item)
+			f, err := d.itemHandler(ctx, tt.path, 0, tt.item)
+			if tt.wantLog == "" {
+				assert.Empty(t, logs.String())
+			} else {
+				assert.Contains(t, logs.String(), tt.wantLog)
+			}
 			if tt.wantErr {
 				require.Error(t, err)
 			} else {
@@ -201,6 +217,133 @@ func TestDownload_itemHandler(t *testing.T) {
 	}
 }
 
+func TestDownload_WithUnknownTypeHandler(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+	items := []client.ArchiveItem{
+		{Name: "foo", Type: "symlink"},
+		{Name: "bar", Type: "socket"},
+	}
+
+	var unknown []client.ArchiveItem
+	d := Download{}
+	assert.Same(t, &d, d.WithUnknownTypeHandler(func(item client.ArchiveItem) error {
+		unknown = append(unknown, item)
+		return nil
+	}))
+	for _, item := range items {
+		h, err := d.itemHandler(ctx, "edgar/full-index", 0, item)
+		require.NoError(t, err)
+		assert.Nil(t, h)
+	}
+	assert.Equal(t, items, unknown)
+
+	d.WithUnknownTypeHandler(func(item client.ArchiveItem) error {
+		return wantErr
+	})
+	_, err := d.itemHandler(ctx, "edgar/full-index", 0, items[0])
+	require.ErrorIs(t, err, wantErr)
+}
+
+func TestDownload_itemHandler_cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	d := Download{}
+	for _, item := range []client.ArchiveItem{
+		{Name: "QTR1", Type: "dir"},
+		{Name: "master.gz", Type: "file"},
+	} {
+		h, err := d.itemHandler(ctx, "edgar/full-index/1994", 0, item)
+		require.NoError(t, err)
+		require.NoError(t, h(), "nil client, but not called")
+	}
+}
+
+func TestDownload_processIndex_stopOnError(t *testing.T) {
+	const testPath = "edgar/full-index"
+	testErr := errors.New("test error")
+
+	httpClient := mocksClient.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			switch filepath.Base(req.URL.Path) {
+			case "index.json":
+				var index client.ArchiveIndex
+				index.Directory.Item = []client.ArchiveItem{
+					{Name: "a.gz", Type: "file"},
+					{Name: "b.gz", Type: "file"},
+				}
+				return newIndexResponse(t, &index), nil
+			case "a.gz":
+				return nil, testErr
+			}
+			t.Errorf("unexpected request: %v", req.URL)
+			return nil, errors.New("unexpected request")
+		})
+
+	storage := mocksDownload.NewMockStorage(t)
+	d := newTestDownload(t, httpClient, storage).WithProcsLimit(1)
+	require.ErrorIs(t, d.Download(testPath), testErr)
+}
+
+func TestDownload_processIndex_subdirError(t *testing.T) {
+	const testPath = "edgar/full-index"
+	testErr := errors.New("test error")
+
+	writeIndex := func(items ...client.ArchiveItem) *http.Response {
+		var index client.ArchiveIndex
+		index.Directory.Item = items
+		return newIndexResponse(t, &index)
+	}
+
+	httpClient := mocksClient.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			switch req.URL.Path {
+			case "/Archives/" + testPath + "/index.json":
+				return writeIndex(
+					client.ArchiveItem{Name: "1994", Type: "dir"},
+					client.ArchiveItem{Name: "1995", Type: "dir"},
+					client.ArchiveItem{Name: "1996", Type: "dir"},
+				), nil
+			case "/Archives/" + testPath + "/1994/index.json":
+				return writeIndex(
+					client.ArchiveItem{Name: "QTR1", Type: "dir"},
+				), nil
+			case "/Archives/" + testPath + "/1994/QTR1/index.json":
+				return nil, testErr
+			}
+			t.Errorf("unexpected request after cancel: %v", req.URL)
+			return nil, errors.New("unexpected request")
+		})
+
+	storage := mocksDownload.NewMockStorage(t)
+	d := newTestDownload(t, httpClient, storage).WithProcsLimit(1)
+	require.ErrorIs(t, d.Download(testPath), testErr)
+}
+
+func TestDownload_WithMaxDepth(t *testing.T) {
+	const testPath = "edgar/full-index"
+
+	var requests int
+	httpClient := mocksClient.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			requests++
+			// every directory contains subdirectory, which points to itself
+			var index client.ArchiveIndex
+			index.Directory.Item = []client.ArchiveItem{{Name: ".", Type: "dir"}}
+			return newIndexResponse(t, &index), nil
+		})
+
+	storage := mocksDownload.NewMockStorage(t)
+	d := newTestDownload(t, httpClient, storage)
+	assert.Same(t, d, d.WithMaxDepth(3))
+	require.ErrorIs(t, d.Download(testPath), ErrMaxDepth)
+	assert.Equal(t, 4, requests)
+}
+
 func TestDownload_NeedFile(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -330,8 +473,143 @@ func TestDownload_downloadFile(t *testing.T) {
 	}
 }
 
+func TestDownload_downloadFile_retry(t *testing.T) {
+	const testFile = "edgar/full-index/master.gz"
+	parentPath := filepath.Dir(testFile)
+	fname := filepath.Base(testFile)
+	ctx := context.Background()
+
+	newHttpClient := func(t *testing.T) *mocksClient.MockHttpRequestDoer {
+		httpClient := mocksClient.NewMockHttpRequestDoer(t)
+		httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+			func(req *http.Request) (*http.Response, error) {
+				recorder := httptest.NewRecorder()
+				recorder.WriteHeader(http.StatusServiceUnavailable)
+				return recorder.Result(), nil
+			}).Once()
+		return httpClient
+	}
+
+	httpClient := newHttpClient(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			recorder := httptest.NewRecorder()
+			_, err := recorder.Write(readTestArchiveFile(t, testFile))
+			require.NoError(t, err)
+			return recorder.Result(), nil
+		}).Once()
+
+	var savedBytes []byte
+	storage := mocksDownload.NewMockStorage(t)
+	storage.EXPECT().Save(parentPath, fname, mock.Anything).RunAndReturn(
+		func(path, fname string, r io.Reader) (err error) {
+			savedBytes, err = io.ReadAll(r)
+			return
+		}).Once()
+
+	d := newTestDownload(t, httpClient, storage)
+	assert.Same(t, d, d.WithDownloadRetry(RetryPolicy{Tries: 2}))
+	require.NoError(t, d.downloadFile(ctx, parentPath, fname, testFile))
+	assert.Equal(t, readTestArchiveFile(t, testFile), savedBytes)
+
+	d = newTestDownload(t, newHttpClient(t), mocksDownload.NewMockStorage(t))
+	err := d.downloadFile(ctx, parentPath, fname, testFile)
+	require.ErrorIs(t, err, client.ErrUnexpectedStatus, "no retries by default")
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	httpClient = mocksClient.NewMockHttpRequestDoer(t)
+	httpClient.EXPECT().Do(mock.Anything).RunAndReturn(
+		func(req *http.Request) (*http.Response, error) {
+			cancel()
+			recorder := httptest.NewRecorder()
+			recorder.WriteHeader(http.StatusServiceUnavailable)
+			return recorder.Result(), nil
+		}).Once()
+	d = newTestDownload(t, httpClient, mocksDownload.NewMockStorage(t)).
+		WithDownloadRetry(RetryPolicy{Tries: 2, Delay: time.Minute})
+	err = d.downloadFile(cancelCtx, parentPath, fname, testFile)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTransientError(t *testing.T) {
+	newStatusErr := func(code int) error {
+		recorder := httptest.NewRecorder()
+		recorder.WriteHeader(code)
+		return fmt.Errorf("test: %w",
+			client.NewUnexpectedStatusError(recorder.Result()))
+	}
+
+	assert.True(t, transientError(newStatusErr(http.StatusInternalServerError)))
+	assert.True(t, transientError(newStatusErr(http.StatusServiceUnavailable)))
+	assert.False(t, transientError(newStatusErr(http.StatusNotFound)))
+	assert.True(t, transientError(fmt.Errorf("test: %w",
+		&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded})))
+	assert.False(t, transientError(fmt.Errorf("test: %w",
+		&net.OpError{Op: "read", Err: errors.New("connection refused")})))
+	assert.False(t, transientError(errors.New("test error")))
+}
+
 func readTestArchiveFile(t *testing.T, path string) []byte {
 	b, err := os.ReadFile(filepath.Join("testdata", path))
 	require.NoError(t, err)
 	return b
 }
+
+func TestDownload_WithFilterForms(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	t.Cleanup(srv.Close)
+
+	datadir := t.TempDir()
+	c := client.New(client.WithBaseURLs("", srv.URL))
+	d := NewDownload(c, newDownloadDir(datadir)).
+		WithFilterForms([]string{"10-K"})
+
+	const parentPath = "edgar/full-index"
+	require.NoError(t, d.downloadFile(context.Background(), parentPath,
+		masterIndex, parentPath+"/"+masterIndex))
+
+	b, err := os.ReadFile(filepath.Join(datadir, parentPath, masterIndex))
+	require.NoError(t, err)
+	assert.Equal(t, readTestArchiveFile(t, parentPath+"/"+masterIndex), b)
+
+	b, err = os.ReadFile(filepath.Join(datadir, parentPath, formsManifest))
+	require.NoError(t, err)
+	assert.Equal(t, "edgar/data/936528/0000936528-23-000207.txt\n", string(b))
+
+	d.WithFilterForms([]string{"10-K", "10-Q"})
+	require.NoError(t, d.downloadFile(context.Background(), parentPath,
+		masterIndex, parentPath+"/"+masterIndex))
+	b, err = os.ReadFile(filepath.Join(datadir, parentPath, formsManifest))
+	require.NoError(t, err)
+	assert.Equal(t, `edgar/data/9984/0000009984-23-000196.txt
+edgar/data/936528/0000936528-23-000207.txt
+`, string(b))
+}
+
+func TestDownload_WithFilterForms_notIndex(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	t.Cleanup(srv.Close)
+
+	storage := mocksDownload.NewMockStorage(t)
+	storage.EXPECT().Save("edgar/full-index", "foobar.gz", mock.Anything).
+		Return(nil).Once()
+
+	c := client.New(client.WithBaseURLs("", srv.URL))
+	d := NewDownload(c, storage).WithFilterForms([]string{"10-K"})
+	require.NoError(t, d.downloadFile(context.Background(), "edgar/full-index",
+		"foobar.gz", "edgar/full-index/master.gz"))
+}
+
+func TestDownload_saveFormsManifest_error(t *testing.T) {
+	storage := mocksDownload.NewMockStorage(t)
+	d := NewDownload(nil, storage).WithFilterForms([]string{"10-K"})
+	require.Error(t, d.saveFormsManifest("edgar/full-index",
+		strings.NewReader("not gzip")))
+
+	testErr := errors.New("test error")
+	storage.EXPECT().Save("edgar/full-index", formsManifest, mock.Anything).
+		Return(testErr)
+	err := d.saveFormsManifest("edgar/full-index", bytes.NewReader(
+		readTestArchiveFile(t, "edgar/full-index/master.gz")))
+	require.ErrorIs(t, err, testErr)
+}
@@ -8,12 +8,25 @@ import (
 	"github.com/dsh2dsh/edgar/client"
 )
 
-func NewClient() (*client.Client, error) {
+// Sample of User-Agent required by SEC, see
+// https://www.sec.gov/os/webmaster-faq#code-support
+const sampleUA = "Sample Company Name AdminContact@<sample company domain>.com"
+
+// NewClient returns client.Client with User-Agent from EDGAR_UA env and opts.
+func NewClient(opts ...client.ClientOption) (*client.Client, error) {
 	cfg := struct {
 		UA string `env:"EDGAR_UA,notEmpty"`
 	}{}
 	if err := env.Parse(&cfg); err != nil {
-		return nil, fmt.Errorf("parse edgar envs: %w", err)
+		return nil, fmt.Errorf(
+			"parse edgar envs: %w (SEC requires User-Agent like %q)", err, sampleUA)
+	}
+
+	withUA, err := client.WithValidatedUserAgent(cfg.UA)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"EDGAR_UA: %w (SEC requires User-Agent with contact email, like %q)",
+			err, sampleUA)
 	}
-	return client.New().WithUserAgent(cfg.UA), nil
+	return client.New(append([]client.ClientOption{withUA}, opts...)...), nil
 }
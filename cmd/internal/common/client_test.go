@@ -0,0 +1,45 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		ua      string
+		wantErr bool
+	}{
+		{
+			name:    "missing UA",
+			wantErr: true,
+		},
+		{
+			name:    "malformed UA",
+			ua:      "Acme",
+			wantErr: true,
+		},
+		{
+			name: "valid UA",
+			ua:   "Acme admin@acme.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EDGAR_UA", tt.ua)
+			c, err := NewClient()
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "User-Agent")
+				assert.Nil(t, c)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, c)
+			}
+		})
+	}
+}
@@ -19,6 +19,8 @@ var rootCmd = cobra.Command{
 }
 
 func init() {
+	// run loadEnvs before persistent hooks of sub-commands too
+	cobra.EnableTraverseRunHooks = true
 	rootCmd.AddCommand(&db.Cmd)
 	rootCmd.AddCommand(&index.Cmd)
 }
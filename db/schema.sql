@@ -49,6 +49,23 @@ CREATE TABLE fact_units (
 
 CREATE INDEX ON fact_units (company_cik, filed);
 
+-- Fact units are duplicates, if they have the same
+-- (company_cik, fact_id, unit_id, fact_start, fact_end, accn). fact_start is a
+-- part of the key, because the same filing reports quarterly and year-to-date
+-- values with the same fact_end. Repo.DeleteDuplicateFactUnits,
+-- Repo.RestoreFactUnits and Repo.AddFactUnitIdempotent use this key, see
+-- factUnitKeyCols in internal/repo.
+
+-- Repo.UpsertFactUnit requires unique index below, on the same key. It isn't
+-- created by default, because EDGAR can report the same fact more than once,
+-- so tune columns per deployment and keep them in sync with
+-- Repo.UpsertFactUnit. NULLS NOT DISTINCT makes fact units without fact_start
+-- conflict too.
+--
+-- CREATE UNIQUE INDEX fact_units_upsert_key
+--   ON fact_units (company_cik, fact_id, unit_id, fact_start, fact_end, accn)
+--   NULLS NOT DISTINCT;
+
 DROP TABLE IF EXISTS last_updates;
 CREATE TABLE last_updates (
   updated_at DATE PRIMARY KEY
@@ -83,122 +83,126 @@ func (_c *MockRepo_AddCompany_Call) RunAndReturn(run func(context.Context, uint3
 	return _c
 }
 
-// AddFact provides a mock function with given fields: ctx, tax, name
-func (_m *MockRepo) AddFact(ctx context.Context, tax string, name string) (uint32, error) {
-	ret := _m.Called(ctx, tax, name)
+// AddFactUnit provides a mock function with given fields: ctx, fact
+func (_m *MockRepo) AddFactUnit(ctx context.Context, fact repo.FactUnit) error {
+	ret := _m.Called(ctx, fact)
 
 	if len(ret) == 0 {
-		panic("no return value specified for AddFact")
-	}
-
-	var r0 uint32
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (uint32, error)); ok {
-		return rf(ctx, tax, name)
-	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) uint32); ok {
-		r0 = rf(ctx, tax, name)
-	} else {
-		r0 = ret.Get(0).(uint32)
+		panic("no return value specified for AddFactUnit")
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, tax, name)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, repo.FactUnit) error); ok {
+		r0 = rf(ctx, fact)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// MockRepo_AddFact_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddFact'
-type MockRepo_AddFact_Call struct {
+// MockRepo_AddFactUnit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddFactUnit'
+type MockRepo_AddFactUnit_Call struct {
 	*mock.Call
 }
 
-// AddFact is a helper method to define mock.On call
+// AddFactUnit is a helper method to define mock.On call
 //   - ctx context.Context
-//   - tax string
-//   - name string
-func (_e *MockRepo_Expecter) AddFact(ctx interface{}, tax interface{}, name interface{}) *MockRepo_AddFact_Call {
-	return &MockRepo_AddFact_Call{Call: _e.mock.On("AddFact", ctx, tax, name)}
+//   - fact repo.FactUnit
+func (_e *MockRepo_Expecter) AddFactUnit(ctx interface{}, fact interface{}) *MockRepo_AddFactUnit_Call {
+	return &MockRepo_AddFactUnit_Call{Call: _e.mock.On("AddFactUnit", ctx, fact)}
 }
 
-func (_c *MockRepo_AddFact_Call) Run(run func(ctx context.Context, tax string, name string)) *MockRepo_AddFact_Call {
+func (_c *MockRepo_AddFactUnit_Call) Run(run func(ctx context.Context, fact repo.FactUnit)) *MockRepo_AddFactUnit_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(string), args[2].(string))
+		run(args[0].(context.Context), args[1].(repo.FactUnit))
 	})
 	return _c
 }
 
-func (_c *MockRepo_AddFact_Call) Return(_a0 uint32, _a1 error) *MockRepo_AddFact_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *MockRepo_AddFactUnit_Call) Return(_a0 error) *MockRepo_AddFactUnit_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockRepo_AddFact_Call) RunAndReturn(run func(context.Context, string, string) (uint32, error)) *MockRepo_AddFact_Call {
+func (_c *MockRepo_AddFactUnit_Call) RunAndReturn(run func(context.Context, repo.FactUnit) error) *MockRepo_AddFactUnit_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// AddFactUnit provides a mock function with given fields: ctx, fact
-func (_m *MockRepo) AddFactUnit(ctx context.Context, fact repo.FactUnit) error {
-	ret := _m.Called(ctx, fact)
+// AddFactWithLabel provides a mock function with given fields: ctx, tax, name, label, descr, labelHash, descrHash
+func (_m *MockRepo) AddFactWithLabel(ctx context.Context, tax string, name string, label string, descr string, labelHash uint64, descrHash uint64) (uint32, error) {
+	ret := _m.Called(ctx, tax, name, label, descr, labelHash, descrHash)
 
 	if len(ret) == 0 {
-		panic("no return value specified for AddFactUnit")
+		panic("no return value specified for AddFactWithLabel")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, repo.FactUnit) error); ok {
-		r0 = rf(ctx, fact)
+	var r0 uint32
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, uint64, uint64) (uint32, error)); ok {
+		return rf(ctx, tax, name, label, descr, labelHash, descrHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, uint64, uint64) uint32); ok {
+		r0 = rf(ctx, tax, name, label, descr, labelHash, descrHash)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(uint32)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, uint64, uint64) error); ok {
+		r1 = rf(ctx, tax, name, label, descr, labelHash, descrHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockRepo_AddFactUnit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddFactUnit'
-type MockRepo_AddFactUnit_Call struct {
+// MockRepo_AddFactWithLabel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddFactWithLabel'
+type MockRepo_AddFactWithLabel_Call struct {
 	*mock.Call
 }
 
-// AddFactUnit is a helper method to define mock.On call
+// AddFactWithLabel is a helper method to define mock.On call
 //   - ctx context.Context
-//   - fact repo.FactUnit
-func (_e *MockRepo_Expecter) AddFactUnit(ctx interface{}, fact interface{}) *MockRepo_AddFactUnit_Call {
-	return &MockRepo_AddFactUnit_Call{Call: _e.mock.On("AddFactUnit", ctx, fact)}
+//   - tax string
+//   - name string
+//   - label string
+//   - descr string
+//   - labelHash uint64
+//   - descrHash uint64
+func (_e *MockRepo_Expecter) AddFactWithLabel(ctx interface{}, tax interface{}, name interface{}, label interface{}, descr interface{}, labelHash interface{}, descrHash interface{}) *MockRepo_AddFactWithLabel_Call {
+	return &MockRepo_AddFactWithLabel_Call{Call: _e.mock.On("AddFactWithLabel", ctx, tax, name, label, descr, labelHash, descrHash)}
 }
 
-func (_c *MockRepo_AddFactUnit_Call) Run(run func(ctx context.Context, fact repo.FactUnit)) *MockRepo_AddFactUnit_Call {
+func (_c *MockRepo_AddFactWithLabel_Call) Run(run func(ctx context.Context, tax string, name string, label string, descr string, labelHash uint64, descrHash uint64)) *MockRepo_AddFactWithLabel_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(repo.FactUnit))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string), args[4].(string), args[5].(uint64), args[6].(uint64))
 	})
 	return _c
 }
 
-func (_c *MockRepo_AddFactUnit_Call) Return(_a0 error) *MockRepo_AddFactUnit_Call {
-	_c.Call.Return(_a0)
+func (_c *MockRepo_AddFactWithLabel_Call) Return(_a0 uint32, _a1 error) *MockRepo_AddFactWithLabel_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockRepo_AddFactUnit_Call) RunAndReturn(run func(context.Context, repo.FactUnit) error) *MockRepo_AddFactUnit_Call {
+func (_c *MockRepo_AddFactWithLabel_Call) RunAndReturn(run func(context.Context, string, string, string, string, uint64, uint64) (uint32, error)) *MockRepo_AddFactWithLabel_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// AddLabel provides a mock function with given fields: ctx, factId, label, descr, labelHash, descrHash
-func (_m *MockRepo) AddLabel(ctx context.Context, factId uint32, label string, descr string, labelHash uint64, descrHash uint64) error {
-	ret := _m.Called(ctx, factId, label, descr, labelHash, descrHash)
+// AddLastUpdate provides a mock function with given fields: ctx, at
+func (_m *MockRepo) AddLastUpdate(ctx context.Context, at time.Time) error {
+	ret := _m.Called(ctx, at)
 
 	if len(ret) == 0 {
-		panic("no return value specified for AddLabel")
+		panic("no return value specified for AddLastUpdate")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint32, string, string, uint64, uint64) error); ok {
-		r0 = rf(ctx, factId, label, descr, labelHash, descrHash)
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) error); ok {
+		r0 = rf(ctx, at)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -206,35 +210,31 @@ func (_m *MockRepo) AddLabel(ctx context.Context, factId uint32, label string, d
 	return r0
 }
 
-// MockRepo_AddLabel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddLabel'
-type MockRepo_AddLabel_Call struct {
+// MockRepo_AddLastUpdate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddLastUpdate'
+type MockRepo_AddLastUpdate_Call struct {
 	*mock.Call
 }
 
-// AddLabel is a helper method to define mock.On call
+// AddLastUpdate is a helper method to define mock.On call
 //   - ctx context.Context
-//   - factId uint32
-//   - label string
-//   - descr string
-//   - labelHash uint64
-//   - descrHash uint64
-func (_e *MockRepo_Expecter) AddLabel(ctx interface{}, factId interface{}, label interface{}, descr interface{}, labelHash interface{}, descrHash interface{}) *MockRepo_AddLabel_Call {
-	return &MockRepo_AddLabel_Call{Call: _e.mock.On("AddLabel", ctx, factId, label, descr, labelHash, descrHash)}
+//   - at time.Time
+func (_e *MockRepo_Expecter) AddLastUpdate(ctx interface{}, at interface{}) *MockRepo_AddLastUpdate_Call {
+	return &MockRepo_AddLastUpdate_Call{Call: _e.mock.On("AddLastUpdate", ctx, at)}
 }
 
-func (_c *MockRepo_AddLabel_Call) Run(run func(ctx context.Context, factId uint32, label string, descr string, labelHash uint64, descrHash uint64)) *MockRepo_AddLabel_Call {
+func (_c *MockRepo_AddLastUpdate_Call) Run(run func(ctx context.Context, at time.Time)) *MockRepo_AddLastUpdate_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(uint32), args[2].(string), args[3].(string), args[4].(uint64), args[5].(uint64))
+		run(args[0].(context.Context), args[1].(time.Time))
 	})
 	return _c
 }
 
-func (_c *MockRepo_AddLabel_Call) Return(_a0 error) *MockRepo_AddLabel_Call {
+func (_c *MockRepo_AddLastUpdate_Call) Return(_a0 error) *MockRepo_AddLastUpdate_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockRepo_AddLabel_Call) RunAndReturn(run func(context.Context, uint32, string, string, uint64, uint64) error) *MockRepo_AddLabel_Call {
+func (_c *MockRepo_AddLastUpdate_Call) RunAndReturn(run func(context.Context, time.Time) error) *MockRepo_AddLastUpdate_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -296,6 +296,63 @@ func (_c *MockRepo_AddUnit_Call) RunAndReturn(run func(context.Context, string)
 	return _c
 }
 
+// BatchAddFactLabels provides a mock function with given fields: ctx, labels
+func (_m *MockRepo) BatchAddFactLabels(ctx context.Context, labels []repo.LabelEntry) (int64, error) {
+	ret := _m.Called(ctx, labels)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BatchAddFactLabels")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []repo.LabelEntry) (int64, error)); ok {
+		return rf(ctx, labels)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []repo.LabelEntry) int64); ok {
+		r0 = rf(ctx, labels)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []repo.LabelEntry) error); ok {
+		r1 = rf(ctx, labels)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepo_BatchAddFactLabels_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'BatchAddFactLabels'
+type MockRepo_BatchAddFactLabels_Call struct {
+	*mock.Call
+}
+
+// BatchAddFactLabels is a helper method to define mock.On call
+//   - ctx context.Context
+//   - labels []repo.LabelEntry
+func (_e *MockRepo_Expecter) BatchAddFactLabels(ctx interface{}, labels interface{}) *MockRepo_BatchAddFactLabels_Call {
+	return &MockRepo_BatchAddFactLabels_Call{Call: _e.mock.On("BatchAddFactLabels", ctx, labels)}
+}
+
+func (_c *MockRepo_BatchAddFactLabels_Call) Run(run func(ctx context.Context, labels []repo.LabelEntry)) *MockRepo_BatchAddFactLabels_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]repo.LabelEntry))
+	})
+	return _c
+}
+
+func (_c *MockRepo_BatchAddFactLabels_Call) Return(_a0 int64, _a1 error) *MockRepo_BatchAddFactLabels_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepo_BatchAddFactLabels_Call) RunAndReturn(run func(context.Context, []repo.LabelEntry) (int64, error)) *MockRepo_BatchAddFactLabels_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CopyFactUnits provides a mock function with given fields: ctx, length, next
 func (_m *MockRepo) CopyFactUnits(ctx context.Context, length int, next func(int) (repo.FactUnit, error)) error {
 	ret := _m.Called(ctx, length, next)
@@ -344,60 +401,72 @@ func (_c *MockRepo_CopyFactUnits_Call) RunAndReturn(run func(context.Context, in
 	return _c
 }
 
-// FactLabels provides a mock function with given fields: ctx
-func (_m *MockRepo) FactLabels(ctx context.Context) ([]repo.FactLabels, error) {
-	ret := _m.Called(ctx)
+// FactUnitsDiffSince provides a mock function with given fields: ctx, cik, lastFiled, incoming
+func (_m *MockRepo) FactUnitsDiffSince(ctx context.Context, cik uint32, lastFiled time.Time, incoming []repo.FactUnit) ([]repo.FactUnit, []repo.FactUnit, error) {
+	ret := _m.Called(ctx, cik, lastFiled, incoming)
 
 	if len(ret) == 0 {
-		panic("no return value specified for FactLabels")
+		panic("no return value specified for FactUnitsDiffSince")
 	}
 
-	var r0 []repo.FactLabels
-	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]repo.FactLabels, error)); ok {
-		return rf(ctx)
+	var r0 []repo.FactUnit
+	var r1 []repo.FactUnit
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, time.Time, []repo.FactUnit) ([]repo.FactUnit, []repo.FactUnit, error)); ok {
+		return rf(ctx, cik, lastFiled, incoming)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []repo.FactLabels); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, time.Time, []repo.FactUnit) []repo.FactUnit); ok {
+		r0 = rf(ctx, cik, lastFiled, incoming)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]repo.FactLabels)
+			r0 = ret.Get(0).([]repo.FactUnit)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, uint32, time.Time, []repo.FactUnit) []repo.FactUnit); ok {
+		r1 = rf(ctx, cik, lastFiled, incoming)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]repo.FactUnit)
+		}
 	}
 
-	return r0, r1
+	if rf, ok := ret.Get(2).(func(context.Context, uint32, time.Time, []repo.FactUnit) error); ok {
+		r2 = rf(ctx, cik, lastFiled, incoming)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
-// MockRepo_FactLabels_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FactLabels'
-type MockRepo_FactLabels_Call struct {
+// MockRepo_FactUnitsDiffSince_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FactUnitsDiffSince'
+type MockRepo_FactUnitsDiffSince_Call struct {
 	*mock.Call
 }
 
-// FactLabels is a helper method to define mock.On call
+// FactUnitsDiffSince is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockRepo_Expecter) FactLabels(ctx interface{}) *MockRepo_FactLabels_Call {
-	return &MockRepo_FactLabels_Call{Call: _e.mock.On("FactLabels", ctx)}
+//   - cik uint32
+//   - lastFiled time.Time
+//   - incoming []repo.FactUnit
+func (_e *MockRepo_Expecter) FactUnitsDiffSince(ctx interface{}, cik interface{}, lastFiled interface{}, incoming interface{}) *MockRepo_FactUnitsDiffSince_Call {
+	return &MockRepo_FactUnitsDiffSince_Call{Call: _e.mock.On("FactUnitsDiffSince", ctx, cik, lastFiled, incoming)}
 }
 
-func (_c *MockRepo_FactLabels_Call) Run(run func(ctx context.Context)) *MockRepo_FactLabels_Call {
+func (_c *MockRepo_FactUnitsDiffSince_Call) Run(run func(ctx context.Context, cik uint32, lastFiled time.Time, incoming []repo.FactUnit)) *MockRepo_FactUnitsDiffSince_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(uint32), args[2].(time.Time), args[3].([]repo.FactUnit))
 	})
 	return _c
 }
 
-func (_c *MockRepo_FactLabels_Call) Return(_a0 []repo.FactLabels, _a1 error) *MockRepo_FactLabels_Call {
-	_c.Call.Return(_a0, _a1)
+func (_c *MockRepo_FactUnitsDiffSince_Call) Return(added []repo.FactUnit, removed []repo.FactUnit, err error) *MockRepo_FactUnitsDiffSince_Call {
+	_c.Call.Return(added, removed, err)
 	return _c
 }
 
-func (_c *MockRepo_FactLabels_Call) RunAndReturn(run func(context.Context) ([]repo.FactLabels, error)) *MockRepo_FactLabels_Call {
+func (_c *MockRepo_FactUnitsDiffSince_Call) RunAndReturn(run func(context.Context, uint32, time.Time, []repo.FactUnit) ([]repo.FactUnit, []repo.FactUnit, error)) *MockRepo_FactUnitsDiffSince_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -519,6 +588,159 @@ func (_c *MockRepo_LastFiled_Call) RunAndReturn(run func(context.Context) (map[u
 	return _c
 }
 
+// LastUpdated provides a mock function with given fields: ctx
+func (_m *MockRepo) LastUpdated(ctx context.Context) (time.Time, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LastUpdated")
+	}
+
+	var r0 time.Time
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (time.Time, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) time.Time); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepo_LastUpdated_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LastUpdated'
+type MockRepo_LastUpdated_Call struct {
+	*mock.Call
+}
+
+// LastUpdated is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockRepo_Expecter) LastUpdated(ctx interface{}) *MockRepo_LastUpdated_Call {
+	return &MockRepo_LastUpdated_Call{Call: _e.mock.On("LastUpdated", ctx)}
+}
+
+func (_c *MockRepo_LastUpdated_Call) Run(run func(ctx context.Context)) *MockRepo_LastUpdated_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockRepo_LastUpdated_Call) Return(lastUpdated time.Time, err error) *MockRepo_LastUpdated_Call {
+	_c.Call.Return(lastUpdated, err)
+	return _c
+}
+
+func (_c *MockRepo_LastUpdated_Call) RunAndReturn(run func(context.Context) (time.Time, error)) *MockRepo_LastUpdated_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReplaceFactUnits provides a mock function with given fields: ctx, cik, lastFiled, length, next
+func (_m *MockRepo) ReplaceFactUnits(ctx context.Context, cik uint32, lastFiled time.Time, length int, next func(int) (repo.FactUnit, error)) error {
+	ret := _m.Called(ctx, cik, lastFiled, length, next)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplaceFactUnits")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, time.Time, int, func(int) (repo.FactUnit, error)) error); ok {
+		r0 = rf(ctx, cik, lastFiled, length, next)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepo_ReplaceFactUnits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReplaceFactUnits'
+type MockRepo_ReplaceFactUnits_Call struct {
+	*mock.Call
+}
+
+// ReplaceFactUnits is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cik uint32
+//   - lastFiled time.Time
+//   - length int
+//   - next func(int)(repo.FactUnit , error)
+func (_e *MockRepo_Expecter) ReplaceFactUnits(ctx interface{}, cik interface{}, lastFiled interface{}, length interface{}, next interface{}) *MockRepo_ReplaceFactUnits_Call {
+	return &MockRepo_ReplaceFactUnits_Call{Call: _e.mock.On("ReplaceFactUnits", ctx, cik, lastFiled, length, next)}
+}
+
+func (_c *MockRepo_ReplaceFactUnits_Call) Run(run func(ctx context.Context, cik uint32, lastFiled time.Time, length int, next func(int) (repo.FactUnit, error))) *MockRepo_ReplaceFactUnits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uint32), args[2].(time.Time), args[3].(int), args[4].(func(int) (repo.FactUnit, error)))
+	})
+	return _c
+}
+
+func (_c *MockRepo_ReplaceFactUnits_Call) Return(_a0 error) *MockRepo_ReplaceFactUnits_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepo_ReplaceFactUnits_Call) RunAndReturn(run func(context.Context, uint32, time.Time, int, func(int) (repo.FactUnit, error)) error) *MockRepo_ReplaceFactUnits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamFactLabels provides a mock function with given fields: ctx, fn
+func (_m *MockRepo) StreamFactLabels(ctx context.Context, fn func(repo.FactLabels) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamFactLabels")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(repo.FactLabels) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockRepo_StreamFactLabels_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamFactLabels'
+type MockRepo_StreamFactLabels_Call struct {
+	*mock.Call
+}
+
+// StreamFactLabels is a helper method to define mock.On call
+//   - ctx context.Context
+//   - fn func(repo.FactLabels) error
+func (_e *MockRepo_Expecter) StreamFactLabels(ctx interface{}, fn interface{}) *MockRepo_StreamFactLabels_Call {
+	return &MockRepo_StreamFactLabels_Call{Call: _e.mock.On("StreamFactLabels", ctx, fn)}
+}
+
+func (_c *MockRepo_StreamFactLabels_Call) Run(run func(ctx context.Context, fn func(repo.FactLabels) error)) *MockRepo_StreamFactLabels_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(func(repo.FactLabels) error))
+	})
+	return _c
+}
+
+func (_c *MockRepo_StreamFactLabels_Call) Return(_a0 error) *MockRepo_StreamFactLabels_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockRepo_StreamFactLabels_Call) RunAndReturn(run func(context.Context, func(repo.FactLabels) error) error) *MockRepo_StreamFactLabels_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Units provides a mock function with given fields: ctx
 func (_m *MockRepo) Units(ctx context.Context) (map[uint32]string, error) {
 	ret := _m.Called(ctx)
@@ -577,6 +799,64 @@ func (_c *MockRepo_Units_Call) RunAndReturn(run func(context.Context) (map[uint3
 	return _c
 }
 
+// UpdateCompanyName provides a mock function with given fields: ctx, cik, name
+func (_m *MockRepo) UpdateCompanyName(ctx context.Context, cik uint32, name string) (bool, error) {
+	ret := _m.Called(ctx, cik, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCompanyName")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, string) (bool, error)); ok {
+		return rf(ctx, cik, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint32, string) bool); ok {
+		r0 = rf(ctx, cik, name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint32, string) error); ok {
+		r1 = rf(ctx, cik, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockRepo_UpdateCompanyName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateCompanyName'
+type MockRepo_UpdateCompanyName_Call struct {
+	*mock.Call
+}
+
+// UpdateCompanyName is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cik uint32
+//   - name string
+func (_e *MockRepo_Expecter) UpdateCompanyName(ctx interface{}, cik interface{}, name interface{}) *MockRepo_UpdateCompanyName_Call {
+	return &MockRepo_UpdateCompanyName_Call{Call: _e.mock.On("UpdateCompanyName", ctx, cik, name)}
+}
+
+func (_c *MockRepo_UpdateCompanyName_Call) Run(run func(ctx context.Context, cik uint32, name string)) *MockRepo_UpdateCompanyName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(uint32), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockRepo_UpdateCompanyName_Call) Return(_a0 bool, _a1 error) *MockRepo_UpdateCompanyName_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockRepo_UpdateCompanyName_Call) RunAndReturn(run func(context.Context, uint32, string) (bool, error)) *MockRepo_UpdateCompanyName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockRepo creates a new instance of MockRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockRepo(t interface {
@@ -279,6 +279,66 @@ func (_c *MockPostgreser_Query_Call) RunAndReturn(run func(context.Context, stri
 	return _c
 }
 
+// QueryRow provides a mock function with given fields: ctx, sql, args
+func (_m *MockPostgreser) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, sql)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QueryRow")
+	}
+
+	var r0 pgx.Row
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) pgx.Row); ok {
+		r0 = rf(ctx, sql, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(pgx.Row)
+		}
+	}
+
+	return r0
+}
+
+// MockPostgreser_QueryRow_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QueryRow'
+type MockPostgreser_QueryRow_Call struct {
+	*mock.Call
+}
+
+// QueryRow is a helper method to define mock.On call
+//   - ctx context.Context
+//   - sql string
+//   - args ...interface{}
+func (_e *MockPostgreser_Expecter) QueryRow(ctx interface{}, sql interface{}, args ...interface{}) *MockPostgreser_QueryRow_Call {
+	return &MockPostgreser_QueryRow_Call{Call: _e.mock.On("QueryRow",
+		append([]interface{}{ctx, sql}, args...)...)}
+}
+
+func (_c *MockPostgreser_QueryRow_Call) Run(run func(ctx context.Context, sql string, args ...interface{})) *MockPostgreser_QueryRow_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]interface{}, len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(interface{})
+			}
+		}
+		run(args[0].(context.Context), args[1].(string), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockPostgreser_QueryRow_Call) Return(_a0 pgx.Row) *MockPostgreser_QueryRow_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockPostgreser_QueryRow_Call) RunAndReturn(run func(context.Context, string, ...interface{}) pgx.Row) *MockPostgreser_QueryRow_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockPostgreser creates a new instance of MockPostgreser. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockPostgreser(t interface {
@@ -1,26 +1,49 @@
 package repo
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Company struct {
+	CIK  uint32 `db:"cik" json:"cik"`
+	Name string `db:"entity_name" json:"name"`
+}
+
 type FactUnit struct {
-	CIK    uint32 `db:"company_cik"`
-	FactId uint32 `db:"fact_id"`
-	UnitId uint32 `db:"unit_id"`
+	CIK    uint32 `db:"company_cik" json:"cik"`
+	FactId uint32 `db:"fact_id" json:"factId"`
+	UnitId uint32 `db:"unit_id" json:"unitId"`
+
+	Start pgtype.Date `db:"fact_start" json:"start"`
+	End   time.Time   `db:"fact_end" json:"end"`
+	Val   float64     `db:"val" json:"val"`
+	Accn  string      `db:"accn" json:"accn"`
+	FY    uint        `db:"fy" json:"fy"`
+	FP    string      `db:"fp" json:"fp"`
+	Form  string      `db:"form" json:"form"`
+	Filed time.Time   `db:"filed" json:"filed"`
+	Frame pgtype.Text `db:"frame" json:"frame"`
+}
 
-	Start pgtype.Date `db:"fact_start"`
-	End   time.Time   `db:"fact_end"`
-	Val   float64     `db:"val"`
-	Accn  string      `db:"accn"`
-	FY    uint        `db:"fy"`
-	FP    string      `db:"fp"`
-	Form  string      `db:"form"`
-	Filed time.Time   `db:"filed"`
-	Frame pgtype.Text `db:"frame"`
+// LabelEntry is a label of fact for [Repo.BatchAddFactLabels].
+type LabelEntry struct {
+	FactId    uint32
+	Label     string
+	Descr     string
+	LabelHash uint64
+	DescrHash uint64
+}
+
+// FactReplacement is a replacement of fact units of company CIK filed since
+// LastFiled by Facts, see [Repo.BulkReplaceFactUnits].
+type FactReplacement struct {
+	CIK       uint32
+	LastFiled time.Time
+	Facts     []FactUnit
 }
 
 func (self *FactUnit) WithStart(d time.Time) *FactUnit {
@@ -33,6 +56,16 @@ func (self *FactUnit) WithFrame(frame string) *FactUnit {
 	return self
 }
 
+// String returns short human readable representation of fact unit, for debug
+// output. It has value receiver, so fmt prints both FactUnit and *FactUnit
+// this way.
+func (self FactUnit) String() string {
+	return fmt.Sprintf(
+		"FactUnit{CIK:%v, FactId:%v, UnitId:%v, End:%v, Val:%.2f, Form:%v, Filed:%v}",
+		self.CIK, self.FactId, self.UnitId, self.End.Format(time.DateOnly),
+		self.Val, self.Form, self.Filed.Format(time.DateOnly))
+}
+
 func (self *FactUnit) NamedArgs() pgx.NamedArgs {
 	return pgx.NamedArgs{
 		"company_cik": self.CIK,
@@ -51,6 +84,57 @@ func (self *FactUnit) NamedArgs() pgx.NamedArgs {
 	}
 }
 
+// factUnitKey identifies fact unit for [Repo.FactUnitsDiffSince].
+type factUnitKey struct {
+	Accn   string
+	FactId uint32
+	UnitId uint32
+	End    string
+}
+
+// duplicateKey identifies duplicate fact units, like factUnitKeyCols.
+type duplicateKey struct {
+	CIK    uint32
+	FactId uint32
+	UnitId uint32
+	Start  string
+	End    string
+	Accn   string
+}
+
+func (self *FactUnit) duplicateKey() duplicateKey {
+	key := duplicateKey{
+		CIK:    self.CIK,
+		FactId: self.FactId,
+		UnitId: self.UnitId,
+		End:    self.End.Format(time.DateOnly),
+		Accn:   self.Accn,
+	}
+	if self.Start.Valid {
+		key.Start = self.Start.Time.Format(time.DateOnly)
+	}
+	return key
+}
+
+// CountDistinctFactUnits returns number of facts, which aren't duplicates of
+// each other, like [Repo.FiledCounts] counts them.
+func CountDistinctFactUnits(facts []FactUnit) int {
+	keys := make(map[duplicateKey]struct{}, len(facts))
+	for i := range facts {
+		keys[facts[i].duplicateKey()] = struct{}{}
+	}
+	return len(keys)
+}
+
+func (self *FactUnit) key() factUnitKey {
+	return factUnitKey{
+		Accn:   self.Accn,
+		FactId: self.FactId,
+		UnitId: self.UnitId,
+		End:    self.End.Format(time.DateOnly),
+	}
+}
+
 type FactLabels struct {
 	FactId    uint32 `db:"fact_id"`
 	FactTax   string `db:"fact_tax"`
@@ -59,3 +143,25 @@ type FactLabels struct {
 	LabelHash uint64 `db:"xxhash1"`
 	DescrHash uint64 `db:"xxhash2"`
 }
+
+type FactInfo struct {
+	Id   uint32 `db:"id" json:"id"`
+	Tax  string `db:"fact_tax" json:"tax"`
+	Name string `db:"fact_name" json:"name"`
+}
+
+// Label is a row of fact_labels table, see [Repo.StreamAllFactLabels].
+type Label struct {
+	Id        uint32 `db:"id" json:"id"`
+	FactId    uint32 `db:"fact_id" json:"factId"`
+	Label     string `db:"fact_label" json:"label"`
+	Descr     string `db:"descr" json:"descr"`
+	LabelHash uint64 `db:"xxhash1" json:"labelHash"`
+	DescrHash uint64 `db:"xxhash2" json:"descrHash"`
+}
+
+// Unit is a row of units table, see [Repo.StreamAllUnits].
+type Unit struct {
+	Id   uint32 `db:"id" json:"id"`
+	Name string `db:"unit_name" json:"name"`
+}
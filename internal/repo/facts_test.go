@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactUnit_String(t *testing.T) {
+	fact := FactUnit{
+		CIK:    320193,
+		FactId: 1,
+		UnitId: 2,
+		End:    time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC),
+		Val:    1234567890,
+		Accn:   "0000320193-24-000006",
+		FY:     2024,
+		FP:     "FY",
+		Form:   "10-K",
+		Filed:  time.Date(2024, time.January, 22, 0, 0, 0, 0, time.UTC),
+	}
+	fact.WithStart(time.Date(2023, time.January, 15, 0, 0, 0, 0, time.UTC))
+
+	const want = "FactUnit{CIK:320193, FactId:1, UnitId:2, End:2024-01-15, " +
+		"Val:1234567890.00, Form:10-K, Filed:2024-01-22}"
+	assert.Equal(t, want, fact.String())
+	assert.Equal(t, want, fmt.Sprint(fact))
+	assert.Equal(t, want, fmt.Sprint(&fact))
+}
+
+func TestCountDistinctFactUnits(t *testing.T) {
+	assert.Zero(t, CountDistinctFactUnits(nil))
+
+	fact := FactUnit{
+		CIK:    320193,
+		FactId: 1,
+		UnitId: 2,
+		End:    time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC),
+		Val:    1234567890,
+		Accn:   "0000320193-24-000006",
+		Filed:  time.Date(2024, time.January, 22, 0, 0, 0, 0, time.UTC),
+	}
+	restated := fact
+	restated.Val = 1234567891
+	yearToDate := fact
+	yearToDate.WithStart(time.Date(2023, time.January, 15, 0, 0, 0, 0, time.UTC))
+	otherAccn := fact
+	otherAccn.Accn = "0000320193-24-000007"
+
+	assert.Equal(t, 1, CountDistinctFactUnits([]FactUnit{fact, restated}))
+	assert.Equal(t, 3, CountDistinctFactUnits(
+		[]FactUnit{fact, restated, yearToDate, otherAccn, yearToDate}))
+}
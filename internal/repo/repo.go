@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -11,6 +13,14 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// TruncateAllConfirm must be passed to [Repo.TruncateAll] for confirming
+// truncation of all data.
+const TruncateAllConfirm = "TRUNCATE ALL DATA"
+
+var ErrTruncateNotConfirmed = errors.New("truncate not confirmed")
+
+var labelCols = [...]string{"fact_id", "fact_label", "descr", "xxhash1", "xxhash2"}
+
 var factUnitCols = [...]string{
 	"company_cik", "fact_id", "unit_id", "fact_start", "fact_end", "val", "accn",
 	"fy", "fp", "form", "filed", "frame",
@@ -21,17 +31,43 @@ func New(db Postgreser) *Repo {
 }
 
 type Repo struct {
-	db Postgreser
+	db     Postgreser
+	logger *slog.Logger
 }
 
 type Postgreser interface {
 	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string,
 		rowSrc pgx.CopyFromSource) (int64, error)
 	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
+func (self *Repo) WithLogger(l *slog.Logger) *Repo {
+	self.logger = l
+	return self
+}
+
+// WithTx calls fn with Repo, which runs all queries in one transaction. The
+// transaction is committed if fn returns nil and rolled back otherwise.
+func (self *Repo) WithTx(ctx context.Context, fn func(r *Repo) error) error {
+	err := pgx.BeginFunc(ctx, self.db, func(tx pgx.Tx) error {
+		return fn(&Repo{db: tx, logger: self.logger})
+	})
+	if err != nil {
+		return fmt.Errorf("repo.WithTx: %w", err)
+	}
+	return nil
+}
+
+func (self *Repo) log() *slog.Logger {
+	if self.logger == nil {
+		return slog.Default()
+	}
+	return self.logger
+}
+
 func (self *Repo) AddCompany(ctx context.Context, cik uint32, name string,
 ) (bool, error) {
 	cmdTag, err := self.db.Exec(ctx, `
@@ -44,6 +80,117 @@ INSERT INTO companies (cik, entity_name)
 	return cmdTag.RowsAffected() > 0, nil
 }
 
+func (self *Repo) UpdateCompanyName(ctx context.Context, cik uint32, name string,
+) (bool, error) {
+	cmdTag, err := self.db.Exec(ctx, `
+UPDATE companies SET entity_name = $2
+  WHERE cik = $1 AND entity_name <> $2`, cik, name)
+	if err != nil {
+		return false, fmt.Errorf("update company CIK=%v %q: %w", cik, name, err)
+	}
+	return cmdTag.RowsAffected() > 0, nil
+}
+
+// Company returns company cik. It returns error wrapped pgx.ErrNoRows, if
+// company doesn't exist.
+func (self *Repo) Company(ctx context.Context, cik uint32) (Company, error) {
+	rows, err := self.db.Query(ctx,
+		`SELECT cik, entity_name FROM companies WHERE cik = $1`, cik)
+	if err != nil {
+		return Company{}, fmt.Errorf("repo.Company: %w", err)
+	}
+
+	company, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[Company])
+	if err != nil {
+		return Company{}, fmt.Errorf("repo.Company CIK=%v: %w", cik, err)
+	}
+	return company, nil
+}
+
+// Companies returns up to limit companies ordered by CIK, skipping first offset
+// companies.
+func (self *Repo) Companies(ctx context.Context, offset, limit int,
+) ([]Company, error) {
+	rows, err := self.db.Query(ctx, `
+SELECT cik, entity_name FROM companies ORDER BY cik OFFSET $1 LIMIT $2`,
+		offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repo.Companies: %w", err)
+	}
+
+	companies, err := pgx.CollectRows(rows, pgx.RowToStructByName[Company])
+	if err != nil {
+		return nil, fmt.Errorf("repo.Companies: %w", err)
+	}
+	return companies, nil
+}
+
+// DeleteCompany deletes company cik with all its fact units in one transaction.
+// It returns true if company existed.
+func (self *Repo) DeleteCompany(ctx context.Context, cik uint32) (bool, error) {
+	var deleted bool
+	err := pgx.BeginFunc(ctx, self.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `DELETE FROM fact_units WHERE company_cik = $1`, cik)
+		if err != nil {
+			return err //nolint:wrapcheck // wrap it below
+		}
+
+		cmdTag, err := tx.Exec(ctx, `DELETE FROM companies WHERE cik = $1`, cik)
+		if err != nil {
+			return err //nolint:wrapcheck // wrap it below
+		}
+		deleted = cmdTag.RowsAffected() > 0
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("repo.DeleteCompany CIK=%v: %w", cik, err)
+	}
+	return deleted, nil
+}
+
+func (self *Repo) BulkUpdateCompanyNames(ctx context.Context,
+	updates map[uint32]string,
+) (int64, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+
+	ciks := make([]uint32, 0, len(updates))
+	names := make([]string, 0, len(updates))
+	for cik, name := range updates {
+		ciks = append(ciks, cik)
+		names = append(names, name)
+	}
+
+	rows, err := self.db.Query(ctx, `
+UPDATE companies SET entity_name = u.entity_name
+  FROM unnest($1::INTEGER[], $2::TEXT[]) AS u(cik, entity_name)
+  WHERE companies.cik = u.cik
+  RETURNING companies.cik`, ciks, names)
+	if err != nil {
+		return 0, fmt.Errorf("repo.BulkUpdateCompanyNames: %w", err)
+	}
+
+	updated, err := pgx.CollectRows(rows, pgx.RowTo[uint32])
+	if err != nil {
+		return 0, fmt.Errorf("repo.BulkUpdateCompanyNames: %w", err)
+	}
+
+	if len(updated) < len(updates) {
+		found := make(map[uint32]struct{}, len(updated))
+		for _, cik := range updated {
+			found[cik] = struct{}{}
+		}
+		for cik := range updates {
+			if _, ok := found[cik]; !ok {
+				self.log().LogAttrs(ctx, slog.LevelWarn, "company not found",
+					slog.Uint64("CIK", uint64(cik)))
+			}
+		}
+	}
+	return int64(len(updated)), nil
+}
+
 func (self *Repo) AddFact(ctx context.Context, tax, name string) (uint32, error) {
 	makeErr := func(err error) error {
 		return fmt.Errorf("add fact \"%v:%v\": %w", tax, name, err)
@@ -64,13 +211,10 @@ INSERT INTO facts (fact_tax, fact_name)
 		return 0, makeErr(err)
 	}
 
-	rows, err = self.db.Query(ctx,
-		`SELECT id FROM facts WHERE fact_tax = $1 AND fact_name = $2`, tax, name)
-	if err != nil {
-		return 0, makeErr(err)
-	}
-
-	id, err := pgx.CollectExactlyOneRow(rows, pgx.RowTo[uint32])
+	var id uint32
+	err = self.db.QueryRow(ctx,
+		`SELECT id FROM facts WHERE fact_tax = $1 AND fact_name = $2`, tax, name).
+		Scan(&id)
 	if err != nil {
 		return 0, makeErr(err)
 	}
@@ -92,6 +236,73 @@ INSERT INTO fact_labels (fact_id, fact_label, descr, xxhash1, xxhash2)
 	return nil
 }
 
+// AddFactWithLabel is like AddFact followed by AddLabel, but both of them run
+// in one transaction, so fact never exists without its label.
+func (self *Repo) AddFactWithLabel(ctx context.Context, tax, name string,
+	label, descr string, labelHash, descrHash uint64,
+) (id uint32, err error) {
+	err = self.WithTx(ctx, func(r *Repo) (err error) {
+		if id, err = r.AddFact(ctx, tax, name); err != nil {
+			return
+		}
+		return r.AddLabel(ctx, id, label, descr, labelHash, descrHash)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("repo.AddFactWithLabel: %w", err)
+	}
+	return id, nil
+}
+
+// BatchAddFactLabels is like AddLabel, but adds all labels at once. It copies
+// labels into temporary table first and inserts them from there, ignoring
+// already existing labels. It returns number of actually added labels.
+func (self *Repo) BatchAddFactLabels(ctx context.Context, labels []LabelEntry,
+) (n int64, err error) {
+	if len(labels) == 0 {
+		return 0, nil
+	}
+
+	err = pgx.BeginFunc(ctx, self.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+CREATE TEMP TABLE fact_labels_stage (
+  fact_id    INTEGER,
+  fact_label TEXT,
+  descr      TEXT,
+  xxhash1    NUMERIC,
+  xxhash2    NUMERIC
+) ON COMMIT DROP`)
+		if err != nil {
+			return fmt.Errorf("create staging table: %w", err)
+		}
+
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"fact_labels_stage"},
+			labelCols[:], pgx.CopyFromSlice(len(labels),
+				func(i int) ([]any, error) {
+					l := &labels[i]
+					return []any{
+						l.FactId, l.Label, l.Descr, l.LabelHash, l.DescrHash,
+					}, nil
+				}))
+		if err != nil {
+			return fmt.Errorf("copy %v labels: %w", len(labels), err)
+		}
+
+		tag, err := tx.Exec(ctx, `
+INSERT INTO fact_labels (fact_id, fact_label, descr, xxhash1, xxhash2)
+  SELECT fact_id, fact_label, descr, xxhash1, xxhash2 FROM fact_labels_stage
+  ON CONFLICT DO NOTHING`)
+		if err != nil {
+			return fmt.Errorf("insert staged labels: %w", err)
+		}
+		n = tag.RowsAffected()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("repo.BatchAddFactLabels: %w", err)
+	}
+	return n, nil
+}
+
 func (self *Repo) AddUnit(ctx context.Context, name string) (uint32, error) {
 	makeErr := func(err error) error {
 		return fmt.Errorf("add unit %q: %w", name, err)
@@ -112,13 +323,9 @@ INSERT INTO units (unit_name)
 		return 0, makeErr(err)
 	}
 
-	rows, err = self.db.Query(ctx,
-		`SELECT id FROM units WHERE unit_name = $1`, name)
-	if err != nil {
-		return 0, makeErr(err)
-	}
-
-	id, err := pgx.CollectExactlyOneRow(rows, pgx.RowTo[uint32])
+	var id uint32
+	err = self.db.QueryRow(ctx,
+		`SELECT id FROM units WHERE unit_name = $1`, name).Scan(&id)
 	if err != nil {
 		return 0, makeErr(err)
 	}
@@ -140,6 +347,51 @@ INSERT INTO fact_units (company_cik,  fact_id,   unit_id,
 	return nil
 }
 
+// UpsertFactUnit inserts fact or updates val and filed of already existing
+// fact. Conflict target is factUnitKeyCols and it requires unique index
+// fact_units_upsert_key on these columns, with NULLS NOT DISTINCT, so facts
+// without fact_start conflict too. db/schema.sql doesn't create this index,
+// it's only commented out there, because EDGAR can report the same fact more
+// than once and creating it fails on such data. The conflict target may need
+// to be tuned per deployment.
+func (self *Repo) UpsertFactUnit(ctx context.Context, fact FactUnit) error {
+	_, err := self.db.Exec(ctx, `
+INSERT INTO fact_units (company_cik,  fact_id,   unit_id,
+                        fact_start,   fact_end,  val,      accn,  fy,  fp,
+                        form,         filed,     frame)
+  VALUES               (@company_cik, @fact_id,  @unit_id,
+                        @fact_start,  @fact_end, @val,     @accn, @fy, @fp,
+                        @form,        @filed,    @frame)
+  ON CONFLICT (`+factUnitKeyCols+`)
+  DO UPDATE SET val = EXCLUDED.val, filed = EXCLUDED.filed`, fact.NamedArgs())
+	if err != nil {
+		return fmt.Errorf("failed upsert fact unit: %w", err)
+	}
+	return nil
+}
+
+// AddFactUnitIdempotent is like AddFactUnit, but it doesn't add fact, if a
+// duplicate fact unit already exists, and returns false in this case. See
+// factUnitKeyCols about duplicates. It doesn't require unique index, but
+// without it concurrent calls can add the same fact twice.
+func (self *Repo) AddFactUnitIdempotent(ctx context.Context, fact FactUnit,
+) (bool, error) {
+	tag, err := self.db.Exec(ctx, `
+INSERT INTO fact_units (company_cik,  fact_id,   unit_id,
+                        fact_start,   fact_end,  val,      accn,  fy,  fp,
+                        form,         filed,     frame)
+  SELECT @company_cik::INTEGER, @fact_id::INTEGER, @unit_id::INTEGER,
+         @fact_start::DATE, @fact_end::DATE, @val::NUMERIC, @accn::TEXT,
+         @fy::INTEGER, @fp::TEXT, @form::TEXT, @filed::DATE, @frame::TEXT
+  WHERE NOT EXISTS (
+    SELECT FROM fact_units AS f WHERE `+sameFactUnitKey("f", "@")+`)
+  ON CONFLICT DO NOTHING`, fact.NamedArgs())
+	if err != nil {
+		return false, fmt.Errorf("repo.AddFactUnitIdempotent: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 func (self *Repo) CopyFactUnits(ctx context.Context, length int,
 	next func(i int) (FactUnit, error),
 ) error {
@@ -169,14 +421,88 @@ func (self *Repo) copyFactUnits(ctx context.Context, conn Postgreser,
 	return nil
 }
 
+// StreamFactUnits calls fn for every stored fact unit, while reading them from
+// db, instead of collecting all of them in memory. It stops and returns error
+// of fn, if fn returns error.
+func (self *Repo) StreamFactUnits(ctx context.Context,
+	fn func(fact FactUnit) error,
+) error {
+	rows, err := self.db.Query(ctx, `SELECT * FROM fact_units`)
+	if err != nil {
+		return fmt.Errorf("repo.StreamFactUnits: %w", err)
+	} else if err := forEachFactUnit(rows, fn); err != nil {
+		return fmt.Errorf("repo.StreamFactUnits: %w", err)
+	}
+	return nil
+}
+
+// StreamFactUnitsByCIK is like StreamFactUnits, but for fact units of company
+// cik only.
+func (self *Repo) StreamFactUnitsByCIK(ctx context.Context, cik uint32,
+	fn func(fact FactUnit) error,
+) error {
+	rows, err := self.db.Query(ctx,
+		`SELECT * FROM fact_units WHERE company_cik = $1`, cik)
+	if err != nil {
+		return fmt.Errorf("repo.StreamFactUnitsByCIK: %w", err)
+	} else if err := forEachFactUnit(rows, fn); err != nil {
+		return fmt.Errorf("repo.StreamFactUnitsByCIK: %w", err)
+	}
+	return nil
+}
+
+func forEachFactUnit(rows pgx.Rows, fn func(fact FactUnit) error) error {
+	var fact FactUnit
+	_, err := pgx.ForEachRow(rows, []any{
+		&fact.CIK, &fact.FactId, &fact.UnitId, &fact.Start, &fact.End, &fact.Val,
+		&fact.Accn, &fact.FY, &fact.FP, &fact.Form, &fact.Filed, &fact.Frame,
+	}, func() error { return fn(fact) })
+	return err //nolint:wrapcheck // wrapped by caller
+}
+
+// lastFiledQuery selects last filed date of every company.
+const lastFiledQuery = `
+SELECT company_cik, MAX(filed) AS last_filed
+  FROM fact_units GROUP BY company_cik`
+
 func (self *Repo) LastFiled(ctx context.Context) (map[uint32]time.Time, error) {
+	rows, err := self.db.Query(ctx, lastFiledQuery)
+	if err != nil {
+		return nil, fmt.Errorf("repo.LastFiled: %w", err)
+	}
+
+	filedByCIK, err := collectLastFiled(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repo.LastFiled: %w", err)
+	}
+	return filedByCIK, nil
+}
+
+// LastFiledForCIKs is like LastFiled, but returns last filed dates of ciks
+// only. Companies without any fact units aren't included in returned map.
+func (self *Repo) LastFiledForCIKs(ctx context.Context, ciks []uint32,
+) (map[uint32]time.Time, error) {
+	if len(ciks) == 0 {
+		return map[uint32]time.Time{}, nil
+	}
+
 	rows, err := self.db.Query(ctx, `
 SELECT company_cik, MAX(filed) AS last_filed
-  FROM fact_units GROUP BY company_cik`)
+  FROM fact_units
+ WHERE company_cik = ANY($1::INTEGER[])
+ GROUP BY company_cik`, ciks)
 	if err != nil {
-		return nil, fmt.Errorf("repo.LastFiled: %w", err)
+		return nil, fmt.Errorf("repo.LastFiledForCIKs: %w", err)
 	}
 
+	filedByCIK, err := collectLastFiled(rows)
+	if err != nil {
+		return nil, fmt.Errorf("repo.LastFiledForCIKs: %w", err)
+	}
+	return filedByCIK, nil
+}
+
+func collectLastFiled(rows pgx.Rows) (map[uint32]time.Time, error) {
 	type lastFiled struct {
 		CIK   uint32    `db:"company_cik"`
 		Filed time.Time `db:"last_filed"`
@@ -184,7 +510,7 @@ SELECT company_cik, MAX(filed) AS last_filed
 
 	cikFiled, err := pgx.CollectRows(rows, pgx.RowToStructByName[lastFiled])
 	if err != nil {
-		return nil, fmt.Errorf("repo.LastFiled: %w", err)
+		return nil, err //nolint:wrapcheck // wrapped by caller
 	}
 
 	filedByCIK := make(map[uint32]time.Time, len(cikFiled))
@@ -192,10 +518,11 @@ SELECT company_cik, MAX(filed) AS last_filed
 		item := &cikFiled[i]
 		filedByCIK[item.CIK] = item.Filed
 	}
-
 	return filedByCIK, nil
 }
 
+// FactLabels returns all labels of all facts. See StreamFactLabels, which
+// doesn't keep all of them in memory.
 func (self *Repo) FactLabels(ctx context.Context) ([]FactLabels, error) {
 	rows, err := self.db.Query(ctx, `
 SELECT facts.id AS fact_id, fact_tax, fact_name,
@@ -213,6 +540,104 @@ SELECT facts.id AS fact_id, fact_tax, fact_name,
 	return facts, nil
 }
 
+// StreamFactLabels is like FactLabels, but calls fn for every label, while
+// reading them from db, instead of collecting all of them in memory. It stops
+// and returns error of fn, if fn returns error.
+func (self *Repo) StreamFactLabels(ctx context.Context,
+	fn func(item FactLabels) error,
+) error {
+	rows, err := self.db.Query(ctx, `
+SELECT facts.id AS fact_id, fact_tax, fact_name,
+       fact_labels.id AS label_id, xxhash1, xxhash2
+  FROM facts, fact_labels WHERE facts.id = fact_labels.fact_id`)
+	if err != nil {
+		return fmt.Errorf("repo.StreamFactLabels: %w", err)
+	}
+
+	var item FactLabels
+	_, err = pgx.ForEachRow(rows, []any{
+		&item.FactId, &item.FactTax, &item.FactName,
+		&item.LabelId, &item.LabelHash, &item.DescrHash,
+	}, func() error { return fn(item) })
+	if err != nil {
+		return fmt.Errorf("repo.StreamFactLabels: %w", err)
+	}
+	return nil
+}
+
+// FactLabelsForFact is like FactLabels, but returns labels of fact factId only.
+func (self *Repo) FactLabelsForFact(ctx context.Context, factId uint32,
+) ([]FactLabels, error) {
+	rows, err := self.db.Query(ctx, `
+SELECT facts.id AS fact_id, fact_tax, fact_name,
+       fact_labels.id AS label_id, xxhash1, xxhash2
+  FROM facts, fact_labels
+  WHERE facts.id = fact_labels.fact_id AND facts.id = $1
+  ORDER BY fact_labels.id`, factId)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FactLabelsForFact: %w", err)
+	}
+
+	facts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactLabels])
+	if err != nil {
+		return nil, fmt.Errorf("repo.FactLabelsForFact: %w", err)
+	}
+	return facts, nil
+}
+
+func (self *Repo) LabelText(ctx context.Context, labelId uint32,
+) (label, descr string, err error) {
+	rows, err := self.db.Query(ctx,
+		`SELECT fact_label, descr FROM fact_labels WHERE id = $1`, labelId)
+	if err != nil {
+		err = fmt.Errorf("repo.LabelText: label %v: %w", labelId, err)
+		return
+	}
+
+	type labelText struct {
+		Label string `db:"fact_label"`
+		Descr string `db:"descr"`
+	}
+
+	text, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[labelText])
+	if err != nil {
+		err = fmt.Errorf("repo.LabelText: label %v: %w", labelId, err)
+		return
+	}
+	label, descr = text.Label, text.Descr
+	return
+}
+
+func (self *Repo) Facts(ctx context.Context) ([]FactInfo, error) {
+	rows, err := self.db.Query(ctx, `
+SELECT id, fact_tax, fact_name FROM facts ORDER BY fact_tax, fact_name`)
+	if err != nil {
+		return nil, fmt.Errorf("repo.Facts: %w", err)
+	}
+
+	facts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactInfo])
+	if err != nil {
+		return nil, fmt.Errorf("repo.Facts: %w", err)
+	}
+	return facts, nil
+}
+
+func (self *Repo) FactsByTax(ctx context.Context, taxonomy string,
+) ([]FactInfo, error) {
+	rows, err := self.db.Query(ctx, `
+SELECT id, fact_tax, fact_name FROM facts
+  WHERE fact_tax = $1 ORDER BY fact_name`, taxonomy)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FactsByTax: %w", err)
+	}
+
+	facts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactInfo])
+	if err != nil {
+		return nil, fmt.Errorf("repo.FactsByTax: %w", err)
+	}
+	return facts, nil
+}
+
 func (self *Repo) Units(ctx context.Context) (map[uint32]string, error) {
 	rows, err := self.db.Query(ctx, `SELECT id, unit_name FROM units`)
 	if err != nil {
@@ -236,10 +661,99 @@ func (self *Repo) Units(ctx context.Context) (map[uint32]string, error) {
 	return units, nil
 }
 
+func (self *Repo) UnitsForFact(ctx context.Context, factId uint32,
+) ([]string, error) {
+	rows, err := self.db.Query(ctx, `
+SELECT DISTINCT u.unit_name FROM units u
+  JOIN fact_units fu ON u.id = fu.unit_id
+  WHERE fu.fact_id = $1
+  ORDER BY u.unit_name`, factId)
+	if err != nil {
+		return nil, fmt.Errorf("repo.UnitsForFact: %w", err)
+	}
+
+	units, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("repo.UnitsForFact: %w", err)
+	}
+	return units, nil
+}
+
+// FactUnitsByForm returns facts of company cik with given fact and unit, filed
+// by one of forms, like "10-K" or "10-Q". Empty forms means all forms.
+func (self *Repo) FactUnitsByForm(ctx context.Context, cik, factId,
+	unitId uint32, forms []string,
+) ([]FactUnit, error) {
+	if len(forms) == 0 {
+		forms = nil
+	}
+
+	rows, err := self.db.Query(ctx, `
+SELECT * FROM fact_units
+  WHERE company_cik = $1 AND fact_id = $2 AND unit_id = $3
+    AND ($4::TEXT[] IS NULL OR form = ANY($4))
+  ORDER BY filed, fact_end`, cik, factId, unitId, forms)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FactUnitsByForm: %w", err)
+	}
+
+	facts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactUnit])
+	if err != nil {
+		return nil, fmt.Errorf("repo.FactUnitsByForm: %w", err)
+	}
+	return facts, nil
+}
+
+// FactUnitsByFiscalYear returns facts of company cik with given fact and unit,
+// reported for fiscal year fy and fiscal period fp, like "Q1" or "FY", ordered
+// by filed date. Empty fp means all fiscal periods of fy.
+func (self *Repo) FactUnitsByFiscalYear(ctx context.Context, cik, factId,
+	unitId uint32, fy int, fp string,
+) ([]FactUnit, error) {
+	rows, err := self.db.Query(ctx, `
+SELECT * FROM fact_units
+  WHERE company_cik = $1 AND fact_id = $2 AND unit_id = $3 AND fy = $4
+    AND ($5::TEXT = '' OR fp = $5)
+  ORDER BY filed, fact_end`, cik, factId, unitId, fy, fp)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FactUnitsByFiscalYear: %w", err)
+	}
+
+	facts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactUnit])
+	if err != nil {
+		return nil, fmt.Errorf("repo.FactUnitsByFiscalYear: %w", err)
+	}
+	return facts, nil
+}
+
+// FactUnitsAfter returns fact units of company cik filed strictly after filed,
+// ordered by filed date. It's designed for incremental sync: pass last seen
+// filed date for getting everything newer.
+func (self *Repo) FactUnitsAfter(ctx context.Context, cik uint32,
+	filed time.Time,
+) ([]FactUnit, error) {
+	rows, err := self.db.Query(ctx, `
+SELECT * FROM fact_units
+  WHERE company_cik = $1 AND filed > $2
+  ORDER BY filed`, cik, filed)
+	if err != nil {
+		return nil, fmt.Errorf("repo.FactUnitsAfter: %w", err)
+	}
+
+	facts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactUnit])
+	if err != nil {
+		return nil, fmt.Errorf("repo.FactUnitsAfter: %w", err)
+	}
+	return facts, nil
+}
+
+// FiledCounts returns number of fact units of company cik per filed date.
+// Duplicate fact units are counted once, see factUnitKeyCols, so removing them
+// doesn't change the counts.
 func (self *Repo) FiledCounts(ctx context.Context, cik uint32,
 ) (map[time.Time]uint32, error) {
 	rows, err := self.db.Query(ctx, `
-SELECT filed, COUNT(*) AS facts FROM fact_units
+SELECT filed, COUNT(DISTINCT (`+factUnitKeyCols+`)) AS facts FROM fact_units
   WHERE company_cik = $1
   GROUP BY company_cik, filed`, cik)
 	if err != nil {
@@ -266,20 +780,289 @@ SELECT filed, COUNT(*) AS facts FROM fact_units
 func (self *Repo) ReplaceFactUnits(ctx context.Context, cik uint32,
 	lastFiled time.Time, length int, next func(i int) (FactUnit, error),
 ) error {
+	err := self.replaceFactUnits(ctx, `
+DELETE FROM fact_units WHERE company_cik = $1 AND filed >= $2`, cik, lastFiled,
+		length, next)
+	if err != nil {
+		return fmt.Errorf("repo.ReplaceFactUnits: %w", err)
+	}
+	return nil
+}
+
+// ReplaceFactUnitsForDate is like ReplaceFactUnits, but it deletes fact units
+// of company cik filed exactly at date only, so it reprocesses one filing date
+// without touching adjacent dates.
+func (self *Repo) ReplaceFactUnitsForDate(ctx context.Context, cik uint32,
+	date time.Time, length int, next func(i int) (FactUnit, error),
+) error {
+	err := self.replaceFactUnits(ctx, `
+DELETE FROM fact_units WHERE company_cik = $1 AND filed = $2`, cik, date,
+		length, next)
+	if err != nil {
+		return fmt.Errorf("repo.ReplaceFactUnitsForDate: %w", err)
+	}
+	return nil
+}
+
+// BulkReplaceFactUnits is like ReplaceFactUnits, but it replaces fact units of
+// multiple companies in one transaction, so either all replacements applied or
+// none of them. Every fact unit of replacement must belong to its company.
+func (self *Repo) BulkReplaceFactUnits(ctx context.Context,
+	replacements []FactReplacement,
+) error {
+	for i := range replacements {
+		r := &replacements[i]
+		for j := range r.Facts {
+			if cik := r.Facts[j].CIK; cik != r.CIK {
+				return fmt.Errorf(
+					"repo.BulkReplaceFactUnits: fact unit #%v of CIK=%v has CIK=%v",
+					j, r.CIK, cik)
+			}
+		}
+	}
+
 	err := pgx.BeginFunc(ctx, self.db, func(tx pgx.Tx) error {
-		_, err := tx.Exec(ctx, `
-DELETE FROM fact_units WHERE company_cik = $1 AND filed >= $2`, cik, lastFiled)
-		if err != nil {
-			return err //nolint:wrapcheck // wrap it below
+		// delete everything first, so replacements of the same company don't
+		// delete fact units copied by each other.
+		for i := range replacements {
+			r := &replacements[i]
+			_, err := tx.Exec(ctx, `
+DELETE FROM fact_units WHERE company_cik = $1 AND filed >= $2`,
+				r.CIK, r.LastFiled)
+			if err != nil {
+				return fmt.Errorf("delete fact units of CIK=%v: %w", r.CIK, err)
+			}
+		}
+
+		for i := range replacements {
+			r := &replacements[i]
+			if len(r.Facts) == 0 {
+				continue
+			}
+			err := self.copyFactUnits(ctx, tx, len(r.Facts),
+				func(i int) (FactUnit, error) { return r.Facts[i], nil })
+			if err != nil {
+				return fmt.Errorf("copy fact units of CIK=%v: %w", r.CIK, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("repo.BulkReplaceFactUnits: %w", err)
+	}
+	return nil
+}
+
+func (self *Repo) replaceFactUnits(ctx context.Context, deleteSQL string,
+	cik uint32, filed time.Time, length int, next func(i int) (FactUnit, error),
+) error {
+	//nolint:wrapcheck // wrapped by caller
+	return pgx.BeginFunc(ctx, self.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, deleteSQL, cik, filed); err != nil {
+			return err //nolint:wrapcheck // wrapped by caller
 		}
 		return self.copyFactUnits(ctx, tx, length, next)
 	})
+}
+
+// TruncateAll deletes all companies, facts, labels, units and fact units. It's
+// destructive and mostly useful for tests, so confirm must be equal to
+// [TruncateAllConfirm], or it returns [ErrTruncateNotConfirmed] and leaves data
+// untouched. All tables truncated by one statement, which runs in its own
+// transaction, because postgres refuses to truncate referenced tables
+// separately.
+func (self *Repo) TruncateAll(ctx context.Context, confirm string) error {
+	if confirm != TruncateAllConfirm {
+		return fmt.Errorf("repo.TruncateAll: %w", ErrTruncateNotConfirmed)
+	}
+
+	_, err := self.db.Exec(ctx,
+		`TRUNCATE fact_units, fact_labels, facts, units, companies`)
 	if err != nil {
-		return fmt.Errorf("repo.ReplaceFactUnits: %w", err)
+		return fmt.Errorf("repo.TruncateAll: %w", err)
 	}
 	return nil
 }
 
+// FactUnitsDiff compares stored facts of company cik with incoming facts by
+// (accn, fact_id, unit_id, fact_end). It returns incoming facts, which don't
+// exist in db, as added and stored facts, which don't exist in incoming, as
+// removed. It loads all stored facts of the company into memory for returning
+// them, see FactUnitsDiffSince for limiting it.
+func (self *Repo) FactUnitsDiff(ctx context.Context, cik uint32,
+	incoming []FactUnit,
+) (added, removed []FactUnit, err error) {
+	return self.FactUnitsDiffSince(ctx, cik, time.Time{}, incoming)
+}
+
+// FactUnitsDiffSince is like FactUnitsDiff, but it compares only facts filed
+// since lastFiled, both stored and incoming.
+func (self *Repo) FactUnitsDiffSince(ctx context.Context, cik uint32,
+	lastFiled time.Time, incoming []FactUnit,
+) (added, removed []FactUnit, err error) {
+	rows, err := self.db.Query(ctx, `
+SELECT * FROM fact_units WHERE company_cik = $1 AND filed >= $2`,
+		cik, lastFiled)
+	if err != nil {
+		return nil, nil, fmt.Errorf("repo.FactUnitsDiffSince: %w", err)
+	}
+
+	stored, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactUnit])
+	if err != nil {
+		return nil, nil, fmt.Errorf("repo.FactUnitsDiffSince: %w", err)
+	}
+
+	storedKeys := make(map[factUnitKey]struct{}, len(stored))
+	for i := range stored {
+		storedKeys[stored[i].key()] = struct{}{}
+	}
+
+	incomingKeys := make(map[factUnitKey]struct{}, len(incoming))
+	for i := range incoming {
+		if incoming[i].Filed.Before(lastFiled) {
+			continue
+		}
+		key := incoming[i].key()
+		incomingKeys[key] = struct{}{}
+		if _, ok := storedKeys[key]; !ok {
+			added = append(added, incoming[i])
+		}
+	}
+
+	for i := range stored {
+		if _, ok := incomingKeys[stored[i].key()]; !ok {
+			removed = append(removed, stored[i])
+		}
+	}
+	return
+}
+
+func (self *Repo) CompanyCount(ctx context.Context) (int64, error) {
+	return self.count(ctx, "CompanyCount", `SELECT COUNT(*) FROM companies`)
+}
+
+func (self *Repo) FactUnitCount(ctx context.Context) (int64, error) {
+	return self.count(ctx, "FactUnitCount", `SELECT COUNT(*) FROM fact_units`)
+}
+
+// factUnitKeyCols are columns, which identify duplicate fact units, see
+// db/schema.sql.
+const factUnitKeyCols = "company_cik, fact_id, unit_id, fact_start, fact_end, accn"
+
+// sameFactUnitKey returns SQL condition, which is true, if fact unit of table
+// alias t has the same factUnitKeyCols as fact unit referenced by prefix p,
+// like "r." for another table alias or "@" for named args. fact_start is
+// nullable and NULLs are equal here.
+func sameFactUnitKey(t, p string) string {
+	return fmt.Sprintf(`%[1]s.company_cik = %[2]scompany_cik
+        AND %[1]s.fact_id = %[2]sfact_id AND %[1]s.unit_id = %[2]sunit_id
+        AND %[1]s.fact_start IS NOT DISTINCT FROM %[2]sfact_start
+        AND %[1]s.fact_end = %[2]sfact_end AND %[1]s.accn = %[2]saccn`, t, p)
+}
+
+// duplicateFactUnits selects ctid of every duplicate fact unit, except first of
+// them, see factUnitKeyCols.
+const duplicateFactUnits = `
+SELECT ctid FROM (
+  SELECT ctid, ROW_NUMBER() OVER (PARTITION BY ` + factUnitKeyCols + `)
+    AS rn FROM fact_units
+) AS fact_units_rn WHERE rn > 1`
+
+// CountDuplicateFactUnits returns number of duplicate fact units, which
+// DeleteDuplicateFactUnits would delete.
+func (self *Repo) CountDuplicateFactUnits(ctx context.Context) (int64, error) {
+	return self.count(ctx, "CountDuplicateFactUnits",
+		`SELECT COUNT(*) FROM (`+duplicateFactUnits+`) AS dups`)
+}
+
+// DeleteDuplicateFactUnits deletes duplicate fact units, keeping one of them,
+// and returns number of deleted fact units. See factUnitKeyCols about
+// duplicates.
+func (self *Repo) DeleteDuplicateFactUnits(ctx context.Context) (int64, error) {
+	tag, err := self.db.Exec(ctx,
+		`DELETE FROM fact_units WHERE ctid IN (`+duplicateFactUnits+`)`)
+	if err != nil {
+		return 0, fmt.Errorf("repo.DeleteDuplicateFactUnits: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// CountFactsByTaxonomy returns number of facts per taxonomy, like us-gaap or
+// dei.
+func (self *Repo) CountFactsByTaxonomy(ctx context.Context,
+) (map[string]int64, error) {
+	return self.countByTaxonomy(ctx, "CountFactsByTaxonomy", `
+SELECT fact_tax, COUNT(*) FROM facts GROUP BY fact_tax ORDER BY fact_tax`)
+}
+
+// CountFactUnitsByTaxonomy returns number of fact units per taxonomy of their
+// facts.
+func (self *Repo) CountFactUnitsByTaxonomy(ctx context.Context,
+) (map[string]int64, error) {
+	return self.countByTaxonomy(ctx, "CountFactUnitsByTaxonomy", `
+SELECT facts.fact_tax, COUNT(*) FROM fact_units
+  JOIN facts ON facts.id = fact_units.fact_id
+  GROUP BY facts.fact_tax ORDER BY facts.fact_tax`)
+}
+
+func (self *Repo) countByTaxonomy(ctx context.Context, method, sql string,
+) (map[string]int64, error) {
+	rows, err := self.db.Query(ctx, sql)
+	if err != nil {
+		return nil, fmt.Errorf("repo.%v: %w", method, err)
+	}
+
+	type taxCount struct {
+		Tax string
+		Cnt int64
+	}
+
+	taxCounts, err := pgx.CollectRows(rows, pgx.RowToStructByPos[taxCount])
+	if err != nil {
+		return nil, fmt.Errorf("repo.%v: %w", method, err)
+	}
+
+	counts := make(map[string]int64, len(taxCounts))
+	for _, item := range taxCounts {
+		counts[item.Tax] = item.Cnt
+	}
+	return counts, nil
+}
+
+func (self *Repo) count(ctx context.Context, method, sql string,
+) (int64, error) {
+	rows, err := self.db.Query(ctx, sql)
+	if err != nil {
+		return 0, fmt.Errorf("repo.%v: %w", method, err)
+	}
+
+	cnt, err := pgx.CollectExactlyOneRow(rows, pgx.RowTo[int64])
+	if err != nil {
+		return 0, fmt.Errorf("repo.%v: %w", method, err)
+	}
+	return cnt, nil
+}
+
+// Explain executes query with args using EXPLAIN (ANALYZE, BUFFERS, FORMAT
+// TEXT) and returns its query plan as text, one line per row. It's for
+// debugging performance of queries only and must never be called from hot
+// paths. Because of ANALYZE, query is really executed, including any changes it
+// makes.
+func (self *Repo) Explain(ctx context.Context, query string, args ...any,
+) (string, error) {
+	rows, err := self.db.Query(ctx,
+		"EXPLAIN (ANALYZE, BUFFERS, FORMAT TEXT) "+query, args...)
+	if err != nil {
+		return "", fmt.Errorf("repo.Explain: %w", err)
+	}
+
+	lines, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return "", fmt.Errorf("repo.Explain: %w", err)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
 func (self *Repo) AddLastUpdate(ctx context.Context, at time.Time) error {
 	_, err := self.db.Exec(ctx, `
 INSERT INTO last_updates (updated_at) VALUES($1)
@@ -3,7 +3,7 @@ package repo
 import (
 	"context"
 	"errors"
-	"fmt"
+	"strconv"
 	"testing"
 	"time"
 
@@ -128,12 +128,8 @@ func (self *RepoTestSuite) SetupTest() {
 }
 
 func (self *RepoTestSuite) TearDownTest() {
-	allTables := []string{"companies", "facts", "fact_labels", "units", "fact_units"}
-	for _, tname := range allTables {
-		sql := fmt.Sprintf("TRUNCATE %s CASCADE", tname)
-		_, err := self.db.Exec(context.Background(), sql)
-		self.Require().NoError(err)
-	}
+	self.Require().NoError(
+		self.repo.TruncateAll(context.Background(), TruncateAllConfirm))
 }
 
 // --------------------------------------------------
@@ -165,6 +161,193 @@ func TestRepo_AddCompany_error(t *testing.T) {
 	assert.False(t, added)
 }
 
+func (self *RepoTestSuite) TestRepo_UpdateCompanyName() {
+	ctx := context.Background()
+	updated, err := self.repo.UpdateCompanyName(ctx, appleCIK, appleName)
+	self.Require().NoError(err)
+	self.False(updated)
+
+	self.addTestCompany(ctx)
+	updated, err = self.repo.UpdateCompanyName(ctx, appleCIK, appleName)
+	self.Require().NoError(err)
+	self.False(updated)
+
+	updated, err = self.repo.UpdateCompanyName(ctx, appleCIK, "Apple Computer, Inc.")
+	self.Require().NoError(err)
+	self.True(updated)
+
+	rows, err := self.db.Query(ctx,
+		`SELECT entity_name FROM companies WHERE cik = $1`, appleCIK)
+	self.Require().NoError(err)
+	name, err := pgx.CollectExactlyOneRow(rows, pgx.RowTo[string])
+	self.Require().NoError(err)
+	self.Equal("Apple Computer, Inc.", name)
+}
+
+func TestRepo_UpdateCompanyName_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Exec(ctx, mock.Anything, mock.Anything, mock.Anything).Return(
+		pgconn.CommandTag{}, wantErr)
+
+	updated, err := repo.UpdateCompanyName(ctx, appleCIK, appleName)
+	require.ErrorIs(t, err, wantErr)
+	assert.False(t, updated)
+}
+
+func (self *RepoTestSuite) TestRepo_Company() {
+	ctx := context.Background()
+	_, err := self.repo.Company(ctx, appleCIK)
+	self.Require().ErrorIs(err, pgx.ErrNoRows)
+
+	self.addTestCompany(ctx)
+	company, err := self.repo.Company(ctx, appleCIK)
+	self.Require().NoError(err)
+	self.Equal(Company{CIK: appleCIK, Name: appleName}, company)
+}
+
+func (self *RepoTestSuite) TestRepo_Companies() {
+	ctx := context.Background()
+	companies, err := self.repo.Companies(ctx, 0, 10)
+	self.Require().NoError(err)
+	self.Empty(companies)
+
+	self.addTestCompany(ctx)
+	const otherCIK, otherName = 1895262, "Noble Corporation plc"
+	added, err := self.repo.AddCompany(ctx, otherCIK, otherName)
+	self.Require().NoError(err)
+	self.True(added)
+
+	companies, err = self.repo.Companies(ctx, 0, 10)
+	self.Require().NoError(err)
+	self.Equal([]Company{
+		{CIK: appleCIK, Name: appleName},
+		{CIK: otherCIK, Name: otherName},
+	}, companies)
+
+	companies, err = self.repo.Companies(ctx, 1, 10)
+	self.Require().NoError(err)
+	self.Equal([]Company{{CIK: otherCIK, Name: otherName}}, companies)
+
+	companies, err = self.repo.Companies(ctx, 0, 1)
+	self.Require().NoError(err)
+	self.Equal([]Company{{CIK: appleCIK, Name: appleName}}, companies)
+}
+
+func (self *RepoTestSuite) TestRepo_DeleteCompany() {
+	ctx := context.Background()
+	deleted, err := self.repo.DeleteCompany(ctx, appleCIK)
+	self.Require().NoError(err)
+	self.False(deleted)
+
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+	self.Require().NoError(self.repo.AddFactUnit(ctx, testFactUnit(factId, unitId)))
+
+	deleted, err = self.repo.DeleteCompany(ctx, appleCIK)
+	self.Require().NoError(err)
+	self.True(deleted)
+
+	_, err = self.repo.Company(ctx, appleCIK)
+	self.Require().ErrorIs(err, pgx.ErrNoRows)
+	cnt, err := self.repo.FactUnitCount(ctx)
+	self.Require().NoError(err)
+	self.Zero(cnt)
+}
+
+func TestRepo_Company_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything, uint32(appleCIK)).Return(nil, wantErr)
+	_, err := repo.Company(ctx, appleCIK)
+	require.ErrorIs(t, err, wantErr)
+
+	db.EXPECT().Query(ctx, mock.Anything, 0, 10).Return(nil, wantErr)
+	companies, err := repo.Companies(ctx, 0, 10)
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, companies)
+}
+
+func TestRepo_DeleteCompany_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Begin(ctx).Return(nil, wantErr).Once()
+	deleted, err := repo.DeleteCompany(ctx, appleCIK)
+	require.ErrorIs(t, err, wantErr)
+	assert.False(t, deleted)
+
+	tx := pgxMocks.NewMockTx(t)
+	db.EXPECT().Begin(ctx).Return(tx, nil)
+	tx.EXPECT().Exec(ctx, mock.Anything, uint32(appleCIK)).
+		Return(pgconn.NewCommandTag(""), wantErr)
+	tx.EXPECT().Rollback(ctx).Return(nil)
+	deleted, err = repo.DeleteCompany(ctx, appleCIK)
+	require.ErrorIs(t, err, wantErr)
+	assert.False(t, deleted)
+}
+
+func (self *RepoTestSuite) TestRepo_BulkUpdateCompanyNames() {
+	ctx := context.Background()
+	n, err := self.repo.BulkUpdateCompanyNames(ctx, nil)
+	self.Require().NoError(err)
+	self.Zero(n)
+
+	self.addTestCompany(ctx)
+	const otherCIK, otherName = 1895262, "Noble Corporation plc"
+	added, err := self.repo.AddCompany(ctx, otherCIK, otherName)
+	self.Require().NoError(err)
+	self.True(added)
+
+	n, err = self.repo.BulkUpdateCompanyNames(ctx, map[uint32]string{
+		appleCIK: "Apple Computer, Inc.",
+		otherCIK: "Noble Corp",
+		1:        "Unknown company",
+	})
+	self.Require().NoError(err)
+	self.Equal(int64(2), n)
+
+	rows, err := self.db.Query(ctx,
+		`SELECT cik, entity_name FROM companies ORDER BY cik`)
+	self.Require().NoError(err)
+
+	type company struct {
+		CIK  uint32
+		Name string
+	}
+	companies, err := pgx.CollectRows(rows, pgx.RowToStructByPos[company])
+	self.Require().NoError(err)
+	self.Equal([]company{
+		{CIK: appleCIK, Name: "Apple Computer, Inc."},
+		{CIK: otherCIK, Name: "Noble Corp"},
+	}, companies)
+}
+
+func TestRepo_BulkUpdateCompanyNames_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, wantErr)
+
+	n, err := repo.BulkUpdateCompanyNames(ctx, map[uint32]string{
+		appleCIK: appleName,
+	})
+	require.ErrorIs(t, err, wantErr)
+	assert.Zero(t, n)
+}
+
 func (self *RepoTestSuite) TestRepo_AddFact() {
 	ctx := context.Background()
 	self.addTestFact(ctx)
@@ -194,8 +377,8 @@ func (self *RepoTestSuite) TestRepo_AddFact() {
 			}).Once()
 
 	wantErr := errors.New("test error")
-	m.EXPECT().Query(ctx, mock.Anything, mock.Anything, mock.Anything).
-		Return(nil, wantErr).Once()
+	m.EXPECT().QueryRow(ctx, mock.Anything, mock.Anything, mock.Anything).
+		Return(errRow{err: wantErr}).Once()
 
 	factId, err = self.repo.AddFact(ctx, factTax, factName)
 	self.Require().Error(err)
@@ -207,11 +390,10 @@ func (self *RepoTestSuite) TestRepo_AddFact() {
 				return self.db.Query(ctx, sql, args...)
 			}).Once()
 
-	m.EXPECT().Query(ctx, mock.Anything, mock.Anything, mock.Anything).
+	m.EXPECT().QueryRow(ctx, mock.Anything, mock.Anything, mock.Anything).
 		RunAndReturn(
-			func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
-				rows, err := self.db.Query(ctx, "SELECT 'not SERIAL'")
-				return rows, err
+			func(ctx context.Context, sql string, args ...any) pgx.Row {
+				return self.db.QueryRow(ctx, "SELECT 'not SERIAL'")
 			}).Once()
 
 	factId, err = self.repo.AddFact(ctx, factTax, factName)
@@ -240,6 +422,125 @@ func TestRepo_AddFact_error(t *testing.T) {
 	assert.Zero(t, id)
 }
 
+func (self *RepoTestSuite) TestRepo_WithTx() {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	var factId uint32
+	err := self.repo.WithTx(ctx, func(r *Repo) (err error) {
+		self.NotSame(self.repo, r)
+		factId, err = r.AddFact(ctx, factTax, factName)
+		if err != nil {
+			return
+		}
+		return wantErr
+	})
+	self.Require().ErrorIs(err, wantErr)
+	self.NotZero(factId)
+	self.Zero(self.countFacts(ctx), "fact must be rolled back")
+
+	err = self.repo.WithTx(ctx, func(r *Repo) (err error) {
+		factId, err = r.AddFact(ctx, factTax, factName)
+		return
+	})
+	self.Require().NoError(err)
+	self.Equal(1, self.countFacts(ctx))
+}
+
+func (self *RepoTestSuite) countFacts(ctx context.Context) int {
+	var n int
+	self.Require().NoError(
+		self.db.QueryRow(ctx, `SELECT COUNT(*) FROM facts`).Scan(&n))
+	return n
+}
+
+func TestRepo_WithTx_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Begin(ctx).Return(nil, wantErr).Once()
+	require.ErrorIs(t, repo.WithTx(ctx, func(r *Repo) error {
+		t.Fatal("fn must not be called")
+		return nil
+	}), wantErr)
+
+	tx := pgxMocks.NewMockTx(t)
+	db.EXPECT().Begin(ctx).Return(tx, nil)
+	tx.EXPECT().Rollback(ctx).Return(nil)
+	require.ErrorIs(t, repo.WithTx(ctx, func(r *Repo) error {
+		assert.Same(t, tx, r.db)
+		return wantErr
+	}), wantErr)
+
+	tx.EXPECT().Commit(ctx).Return(wantErr).Once()
+	require.ErrorIs(t, repo.WithTx(ctx, func(r *Repo) error { return nil }),
+		wantErr)
+}
+
+func (self *RepoTestSuite) TestRepo_AddFactWithLabel() {
+	ctx := context.Background()
+	labelHash := xxhash.Sum64String(factLabel)
+	descrHash := xxhash.Sum64String(factDescr)
+
+	factId, err := self.repo.AddFactWithLabel(ctx, factTax, factName, factLabel,
+		factDescr, labelHash, descrHash)
+	self.Require().NoError(err)
+	self.NotZero(factId)
+
+	gotId, err := self.repo.AddFactWithLabel(ctx, factTax, factName, factLabel,
+		factDescr, labelHash, descrHash)
+	self.Require().NoError(err)
+	self.Equal(factId, gotId)
+
+	labels, err := self.repo.FactLabelsForFact(ctx, factId)
+	self.Require().NoError(err)
+	self.Require().Len(labels, 1)
+	self.Equal(factId, labels[0].FactId)
+}
+
+func (self *RepoTestSuite) TestRepo_AddFactWithLabel_rollback() {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	realTx, err := self.db.Begin(ctx)
+	self.Require().NoError(err)
+
+	tx := pgxMocks.NewMockTx(self.T())
+	tx.EXPECT().Query(ctx, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(realTx.Query).Once()
+	tx.EXPECT().Exec(ctx, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything).
+		Return(pgconn.NewCommandTag(""), wantErr).Once()
+	tx.EXPECT().Rollback(ctx).RunAndReturn(realTx.Rollback)
+
+	m := mocks.NewMockPostgreser(self.T())
+	m.EXPECT().Begin(ctx).Return(tx, nil).Once()
+	self.repo.db = m
+	self.T().Cleanup(func() { self.repo.db = self.db })
+
+	factId, err := self.repo.AddFactWithLabel(ctx, factTax, factName, factLabel,
+		factDescr, 1, 2)
+	self.Require().ErrorIs(err, wantErr)
+	self.Zero(factId)
+	self.Zero(self.countFacts(ctx), "fact without label must be rolled back")
+}
+
+func TestRepo_AddFactWithLabel_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Begin(ctx).Return(nil, wantErr)
+
+	id, err := repo.AddFactWithLabel(ctx, factTax, factName, factLabel,
+		factDescr, 1, 2)
+	require.ErrorIs(t, err, wantErr)
+	assert.Zero(t, id)
+}
+
 func (self *RepoTestSuite) TestRepo_AddLabel() {
 	ctx := context.Background()
 	factId := self.addTestFact(ctx)
@@ -274,6 +575,83 @@ func (self *RepoTestSuite) TestRepo_AddLabel() {
 		ctx, 0, factLabel, factDescr, labelHash, descrHash))
 }
 
+func (self *RepoTestSuite) TestRepo_BatchAddFactLabels() {
+	ctx := context.Background()
+	factId := self.addTestFact(ctx)
+	labelHash, descrHash := self.addTestLabel(factId)
+
+	n, err := self.repo.BatchAddFactLabels(ctx, nil)
+	self.Require().NoError(err)
+	self.Zero(n)
+
+	labels := []LabelEntry{
+		{
+			FactId: factId, Label: factLabel, Descr: factDescr,
+			LabelHash: labelHash, DescrHash: descrHash,
+		},
+		{
+			FactId: factId, Label: "Other Label", Descr: factDescr,
+			LabelHash: xxhash.Sum64String("Other Label"), DescrHash: descrHash,
+		},
+		{
+			FactId: factId, Label: "Other Label", Descr: factDescr,
+			LabelHash: xxhash.Sum64String("Other Label"), DescrHash: descrHash,
+		},
+	}
+	n, err = self.repo.BatchAddFactLabels(ctx, labels)
+	self.Require().NoError(err)
+	self.Equal(int64(1), n)
+
+	factLabels, err := self.repo.FactLabelsForFact(ctx, factId)
+	self.Require().NoError(err)
+	self.Require().Len(factLabels, 2)
+	self.Equal(labels[1].LabelHash, factLabels[1].LabelHash)
+
+	n, err = self.repo.BatchAddFactLabels(ctx, labels)
+	self.Require().NoError(err)
+	self.Zero(n)
+
+	_, err = self.repo.BatchAddFactLabels(ctx, []LabelEntry{{FactId: 0}})
+	self.Require().Error(err)
+}
+
+func TestRepo_BatchAddFactLabels_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+	labels := []LabelEntry{{FactId: 1, Label: factLabel, Descr: factDescr}}
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Begin(ctx).Return(nil, wantErr).Once()
+	n, err := repo.BatchAddFactLabels(ctx, labels)
+	require.ErrorIs(t, err, wantErr)
+	assert.Zero(t, n)
+
+	tx := pgxMocks.NewMockTx(t)
+	db.EXPECT().Begin(ctx).Return(tx, nil)
+	tx.EXPECT().Rollback(ctx).Return(nil)
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag(""), wantErr).Once()
+	_, err = repo.BatchAddFactLabels(ctx, labels)
+	require.ErrorIs(t, err, wantErr)
+
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag(""), nil).Once()
+	tx.EXPECT().CopyFrom(ctx, pgx.Identifier{"fact_labels_stage"}, labelCols[:],
+		mock.Anything).Return(0, wantErr).Once()
+	_, err = repo.BatchAddFactLabels(ctx, labels)
+	require.ErrorIs(t, err, wantErr)
+
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag(""), nil).Once()
+	tx.EXPECT().CopyFrom(ctx, pgx.Identifier{"fact_labels_stage"}, labelCols[:],
+		mock.Anything).Return(1, nil).Once()
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag(""), wantErr).Once()
+	_, err = repo.BatchAddFactLabels(ctx, labels)
+	require.ErrorIs(t, err, wantErr)
+}
+
 func (self *RepoTestSuite) addTestLabel(factId uint32) (uint64, uint64) {
 	labelHash := xxhash.Sum64String(factLabel)
 	descrHash := xxhash.Sum64String(factDescr)
@@ -309,7 +687,8 @@ func (self *RepoTestSuite) TestRepo_AddUnit() {
 		}).Once()
 
 	wantErr := errors.New("test error")
-	m.EXPECT().Query(ctx, mock.Anything, mock.Anything).Return(nil, wantErr).Once()
+	m.EXPECT().QueryRow(ctx, mock.Anything, mock.Anything).
+		Return(errRow{err: wantErr}).Once()
 
 	unitId, err = self.repo.AddUnit(ctx, unitName)
 	self.Require().Error(err)
@@ -320,10 +699,9 @@ func (self *RepoTestSuite) TestRepo_AddUnit() {
 			return self.db.Query(ctx, sql, args...)
 		}).Once()
 
-	m.EXPECT().Query(ctx, mock.Anything, mock.Anything).RunAndReturn(
-		func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
-			rows, err := self.db.Query(ctx, "SELECT 'not SERIAL'")
-			return rows, err
+	m.EXPECT().QueryRow(ctx, mock.Anything, mock.Anything).RunAndReturn(
+		func(ctx context.Context, sql string, args ...any) pgx.Row {
+			return self.db.QueryRow(ctx, "SELECT 'not SERIAL'")
 		}).Once()
 
 	unitId, err = self.repo.AddUnit(ctx, unitName)
@@ -338,6 +716,23 @@ func (self *RepoTestSuite) addTestUnit(ctx context.Context) uint32 {
 	return unitId
 }
 
+// testFactUnit returns fact unit of test company for fact factId and unit
+// unitId, like added by addTestFact and addTestUnit.
+func testFactUnit(factId, unitId uint32) FactUnit {
+	return FactUnit{
+		CIK:    appleCIK,
+		FactId: factId,
+		UnitId: unitId,
+		End:    time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC),
+		Val:    5520000000,
+		Accn:   "0001193125-09-153165",
+		FY:     2009,
+		FP:     "Q3",
+		Form:   "10-Q",
+		Filed:  time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC),
+	}
+}
+
 func TestRepo_AddUnit_error(t *testing.T) {
 	ctx := context.Background()
 	wantErr := errors.New("test error")
@@ -357,18 +752,7 @@ func (self *RepoTestSuite) TestRepo_AddFactUnit() {
 	factId := self.addTestFact(ctx)
 	unitId := self.addTestUnit(ctx)
 
-	fullFact := FactUnit{
-		CIK:    appleCIK,
-		FactId: factId,
-		UnitId: unitId,
-		End:    time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC),
-		Val:    5520000000,
-		Accn:   "0001193125-09-153165",
-		FY:     2009,
-		FP:     "Q3",
-		Form:   "10-Q",
-		Filed:  time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC),
-	}
+	fullFact := testFactUnit(factId, unitId)
 	fullFact.WithStart(time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC)).
 		WithFrame("CY2008Q3I")
 
@@ -452,24 +836,140 @@ func (self *RepoTestSuite) TestRepo_AddFactUnit() {
 	}
 }
 
-func (self *RepoTestSuite) TestRepo_CopyFactUnits() {
+func (self *RepoTestSuite) TestRepo_UpsertFactUnit() {
 	ctx := context.Background()
 	self.addTestCompany(ctx)
 	factId := self.addTestFact(ctx)
 	unitId := self.addTestUnit(ctx)
 
-	fullFact := FactUnit{
-		CIK:    appleCIK,
-		FactId: factId,
-		UnitId: unitId,
-		End:    time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC),
-		Val:    5520000000,
-		Accn:   "0001193125-09-153165",
-		FY:     2009,
-		FP:     "Q3",
-		Form:   "10-Q",
-		Filed:  time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC),
-	}
+	fact := testFactUnit(factId, unitId)
+
+	// without unique index ON CONFLICT has nothing to infer
+	self.Require().Error(self.repo.UpsertFactUnit(ctx, fact))
+
+	_, err := self.db.Exec(ctx, `
+CREATE UNIQUE INDEX fact_units_upsert_key
+  ON fact_units (`+factUnitKeyCols+`) NULLS NOT DISTINCT`)
+	self.Require().NoError(err)
+	self.T().Cleanup(func() {
+		_, err := self.db.Exec(context.Background(),
+			`DROP INDEX fact_units_upsert_key`)
+		self.Require().NoError(err)
+	})
+
+	self.Require().NoError(self.repo.UpsertFactUnit(ctx, fact))
+	fact.Val = 5530000000
+	fact.Filed = time.Date(2009, 10, 27, 0, 0, 0, 0, time.UTC)
+	self.Require().NoError(self.repo.UpsertFactUnit(ctx, fact))
+
+	yearToDate := fact
+	yearToDate.WithStart(time.Date(2007, 9, 30, 0, 0, 0, 0, time.UTC))
+	yearToDate.Val = 22010000000
+	self.Require().NoError(self.repo.UpsertFactUnit(ctx, yearToDate))
+
+	rows, err := self.db.Query(ctx, `SELECT * FROM fact_units`)
+	self.Require().NoError(err)
+	gotFacts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactUnit])
+	self.Require().NoError(err)
+	self.ElementsMatch([]FactUnit{fact, yearToDate}, gotFacts)
+}
+
+func TestRepo_UpsertFactUnit_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Exec(ctx, mock.Anything, mock.Anything).Return(
+		pgconn.CommandTag{}, wantErr)
+
+	require.ErrorIs(t, repo.UpsertFactUnit(ctx, FactUnit{}), wantErr)
+}
+
+func (self *RepoTestSuite) TestRepo_AddFactUnitIdempotent() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	fact := testFactUnit(factId, unitId)
+	fact.WithStart(time.Date(2008, 6, 28, 0, 0, 0, 0, time.UTC)).
+		WithFrame("CY2008Q3")
+
+	added, err := self.repo.AddFactUnitIdempotent(ctx, fact)
+	self.Require().NoError(err)
+	self.True(added)
+
+	restated := fact
+	restated.Val = 5530000000
+	added, err = self.repo.AddFactUnitIdempotent(ctx, restated)
+	self.Require().NoError(err)
+	self.False(added, "conflict")
+
+	otherAccn := fact
+	otherAccn.Accn = "0001193125-09-214859"
+	added, err = self.repo.AddFactUnitIdempotent(ctx, otherAccn)
+	self.Require().NoError(err)
+	self.True(added)
+
+	yearToDate := fact
+	yearToDate.WithStart(time.Date(2007, 9, 30, 0, 0, 0, 0, time.UTC))
+	yearToDate.Val = 22010000000
+	added, err = self.repo.AddFactUnitIdempotent(ctx, yearToDate)
+	self.Require().NoError(err)
+	self.True(added, "the same end and accn, but other start")
+
+	rows, err := self.db.Query(ctx, `SELECT * FROM fact_units`)
+	self.Require().NoError(err)
+	gotFacts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactUnit])
+	self.Require().NoError(err)
+	self.ElementsMatch([]FactUnit{fact, otherAccn, yearToDate}, gotFacts)
+
+	_, err = self.db.Exec(ctx, `
+CREATE UNIQUE INDEX fact_units_key ON fact_units (`+factUnitKeyCols+`)`)
+	self.Require().NoError(err)
+	self.T().Cleanup(func() {
+		_, err := self.db.Exec(context.Background(), `DROP INDEX fact_units_key`)
+		self.Require().NoError(err)
+	})
+
+	added, err = self.repo.AddFactUnitIdempotent(ctx, restated)
+	self.Require().NoError(err)
+	self.False(added, "conflict with unique index")
+
+	_, err = self.repo.AddFactUnitIdempotent(ctx, FactUnit{CIK: appleCIK})
+	self.Require().Error(err)
+}
+
+func TestSameFactUnitKey(t *testing.T) {
+	assert.Equal(t, `f.company_cik = r.company_cik
+        AND f.fact_id = r.fact_id AND f.unit_id = r.unit_id
+        AND f.fact_start IS NOT DISTINCT FROM r.fact_start
+        AND f.fact_end = r.fact_end AND f.accn = r.accn`,
+		sameFactUnitKey("f", "r."))
+}
+
+func TestRepo_AddFactUnitIdempotent_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Exec(ctx, mock.Anything, mock.Anything).Return(
+		pgconn.CommandTag{}, wantErr)
+
+	added, err := repo.AddFactUnitIdempotent(ctx, FactUnit{})
+	require.ErrorIs(t, err, wantErr)
+	assert.False(t, added)
+}
+
+func (self *RepoTestSuite) TestRepo_CopyFactUnits() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	fullFact := testFactUnit(factId, unitId)
 	fullFact.WithStart(time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC)).
 		WithFrame("CY2008Q3I")
 
@@ -524,24 +1024,68 @@ func TestRepo_CopyFactUnits_wrongN(t *testing.T) {
 	require.Error(t, err)
 }
 
+func (self *RepoTestSuite) TestRepo_StreamFactUnits() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	_, err := self.repo.AddCompany(ctx, appleCIK+1, appleName)
+	self.Require().NoError(err)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	fact := testFactUnit(factId, unitId)
+	otherFact := fact
+	otherFact.CIK = appleCIK + 1
+	otherFact.WithStart(time.Date(2008, 6, 28, 0, 0, 0, 0, time.UTC)).
+		WithFrame("CY2008Q3")
+	facts := []FactUnit{fact, otherFact}
+	self.Require().NoError(self.repo.CopyFactUnits(ctx, len(facts),
+		func(i int) (FactUnit, error) { return facts[i], nil }))
+
+	var gotFacts []FactUnit
+	self.Require().NoError(self.repo.StreamFactUnits(ctx,
+		func(fact FactUnit) error {
+			gotFacts = append(gotFacts, fact)
+			return nil
+		}))
+	self.ElementsMatch(facts, gotFacts)
+
+	gotFacts = nil
+	self.Require().NoError(self.repo.StreamFactUnitsByCIK(ctx, otherFact.CIK,
+		func(fact FactUnit) error {
+			gotFacts = append(gotFacts, fact)
+			return nil
+		}))
+	self.Equal([]FactUnit{otherFact}, gotFacts)
+
+	wantErr := errors.New("test error")
+	err = self.repo.StreamFactUnits(ctx,
+		func(fact FactUnit) error { return wantErr })
+	self.Require().ErrorIs(err, wantErr)
+}
+
+func TestRepo_StreamFactUnits_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything).Return(nil, wantErr).Once()
+	require.ErrorIs(t, repo.StreamFactUnits(ctx,
+		func(fact FactUnit) error { return nil }), wantErr)
+
+	db.EXPECT().Query(ctx, mock.Anything, uint32(appleCIK)).
+		Return(nil, wantErr).Once()
+	require.ErrorIs(t, repo.StreamFactUnitsByCIK(ctx, appleCIK,
+		func(fact FactUnit) error { return nil }), wantErr)
+}
+
 func (self *RepoTestSuite) TestRepo_LastFiled() {
 	ctx := context.Background()
 	self.addTestCompany(ctx)
 	factId := self.addTestFact(ctx)
 	unitId := self.addTestUnit(ctx)
 
-	fullFact := FactUnit{
-		CIK:    appleCIK,
-		FactId: factId,
-		UnitId: unitId,
-		End:    time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC),
-		Val:    5520000000,
-		Accn:   "0001193125-09-153165",
-		FY:     2009,
-		FP:     "Q3",
-		Form:   "10-Q",
-		Filed:  time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC),
-	}
+	fullFact := testFactUnit(factId, unitId)
 
 	filed := []time.Time{
 		time.Date(2009, 7, 20, 0, 0, 0, 0, time.UTC),
@@ -593,6 +1137,85 @@ func TestRepo_LastFiled_error(t *testing.T) {
 	assert.Nil(t, lastFiled)
 }
 
+func (self *RepoTestSuite) TestRepo_Explain() {
+	ctx := context.Background()
+	plan, err := self.repo.Explain(ctx, lastFiledQuery)
+	self.Require().NoError(err)
+	self.NotEmpty(plan)
+	self.Contains(plan, "Execution Time")
+}
+
+func TestRepo_Explain_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+
+	db.EXPECT().Query(ctx, "EXPLAIN (ANALYZE, BUFFERS, FORMAT TEXT) "+
+		lastFiledQuery).Return(nil, wantErr).Once()
+
+	plan, err := repo.Explain(ctx, lastFiledQuery)
+	require.ErrorIs(t, err, wantErr)
+	assert.Empty(t, plan)
+}
+
+func (self *RepoTestSuite) TestRepo_LastFiledForCIKs() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	const otherCIK, otherName = 1895262, "Noble Corporation plc"
+	added, err := self.repo.AddCompany(ctx, otherCIK, otherName)
+	self.Require().NoError(err)
+	self.True(added)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	fact := testFactUnit(factId, unitId)
+	facts := []FactUnit{fact, fact, fact}
+	facts[0].Filed = time.Date(2009, 7, 20, 0, 0, 0, 0, time.UTC)
+	facts[1].Filed = time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC)
+	facts[2].CIK = otherCIK
+	facts[2].Filed = time.Date(2023, 11, 8, 0, 0, 0, 0, time.UTC)
+	err = self.repo.CopyFactUnits(ctx, len(facts), func(i int) (FactUnit, error) {
+		return facts[i], nil
+	})
+	self.Require().NoError(err)
+
+	lastFiled, err := self.repo.LastFiledForCIKs(ctx, []uint32{appleCIK, 1})
+	self.Require().NoError(err)
+	self.Equal(map[uint32]time.Time{appleCIK: facts[1].Filed}, lastFiled)
+
+	lastFiled, err = self.repo.LastFiledForCIKs(ctx,
+		[]uint32{appleCIK, otherCIK})
+	self.Require().NoError(err)
+	self.Equal(map[uint32]time.Time{
+		appleCIK: facts[1].Filed,
+		otherCIK: facts[2].Filed,
+	}, lastFiled)
+
+	lastFiled, err = self.repo.LastFiledForCIKs(ctx, []uint32{1, 2})
+	self.Require().NoError(err)
+	self.Empty(lastFiled)
+}
+
+func TestRepo_LastFiledForCIKs_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+
+	lastFiled, err := repo.LastFiledForCIKs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, lastFiled)
+
+	ciks := []uint32{appleCIK}
+	db.EXPECT().Query(ctx, mock.Anything, ciks).Return(nil, wantErr).Once()
+	lastFiled, err = repo.LastFiledForCIKs(ctx, ciks)
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, lastFiled)
+}
+
 func (self *RepoTestSuite) TestRepo_FactLabels() {
 	ctx := context.Background()
 	self.addTestCompany(ctx)
@@ -632,6 +1255,98 @@ func (self *RepoTestSuite) TestRepo_FactLabels() {
 	self.Require().Error(err)
 }
 
+func (self *RepoTestSuite) TestRepo_StreamFactLabels() {
+	ctx := context.Background()
+	factId := self.addTestFact(ctx)
+	self.addTestLabel(factId)
+	otherLabel := "Other Label"
+	otherHash := xxhash.Sum64String(otherLabel)
+	self.Require().NoError(self.repo.AddLabel(ctx, factId, otherLabel, factDescr,
+		otherHash, xxhash.Sum64String(factDescr)))
+
+	wantLabels, err := self.repo.FactLabels(ctx)
+	self.Require().NoError(err)
+	self.Require().Len(wantLabels, 2)
+
+	var gotLabels []FactLabels
+	err = self.repo.StreamFactLabels(ctx, func(item FactLabels) error {
+		gotLabels = append(gotLabels, item)
+		return nil
+	})
+	self.Require().NoError(err)
+	self.ElementsMatch(wantLabels, gotLabels)
+
+	wantErr := errors.New("test error")
+	var cnt int
+	err = self.repo.StreamFactLabels(ctx, func(item FactLabels) error {
+		cnt++
+		return wantErr
+	})
+	self.Require().ErrorIs(err, wantErr)
+	self.Equal(1, cnt)
+}
+
+func TestRepo_StreamFactLabels_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything).Return(nil, wantErr)
+
+	err := repo.StreamFactLabels(ctx, func(item FactLabels) error {
+		t.Fatal("fn must not be called")
+		return nil
+	})
+	require.ErrorIs(t, err, wantErr)
+}
+
+func (self *RepoTestSuite) TestRepo_FactLabelsForFact() {
+	ctx := context.Background()
+	factId := self.addTestFact(ctx)
+	labelHash, descrHash := self.addTestLabel(factId)
+
+	const otherName = "AccountsPayableCurrent"
+	const otherLabel = "Accounts Payable, Current"
+	otherId, err := self.repo.AddFact(ctx, factTax, otherName)
+	self.Require().NoError(err)
+	otherHash := xxhash.Sum64String(otherLabel)
+	self.Require().NoError(self.repo.AddLabel(ctx, otherId, otherLabel, "",
+		otherHash, xxhash.Sum64String("")))
+
+	factLabels, err := self.repo.FactLabelsForFact(ctx, factId)
+	self.Require().NoError(err)
+	self.Require().Len(factLabels, 1)
+	self.Equal(factId, factLabels[0].FactId)
+	self.Equal(factName, factLabels[0].FactName)
+	self.Equal(labelHash, factLabels[0].LabelHash)
+	self.Equal(descrHash, factLabels[0].DescrHash)
+
+	factLabels, err = self.repo.FactLabelsForFact(ctx, otherId)
+	self.Require().NoError(err)
+	self.Require().Len(factLabels, 1)
+	self.Equal(otherId, factLabels[0].FactId)
+	self.Equal(otherName, factLabels[0].FactName)
+	self.Equal(otherHash, factLabels[0].LabelHash)
+
+	factLabels, err = self.repo.FactLabelsForFact(ctx, otherId+factId)
+	self.Require().NoError(err)
+	self.Empty(factLabels)
+}
+
+func TestRepo_FactLabelsForFact_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything, mock.Anything).Return(nil, wantErr)
+
+	factLabels, err := repo.FactLabelsForFact(ctx, 1)
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, factLabels)
+}
+
 func TestRepo_FactLabels_error(t *testing.T) {
 	ctx := context.Background()
 	wantErr := errors.New("test error")
@@ -646,17 +1361,76 @@ func TestRepo_FactLabels_error(t *testing.T) {
 	assert.Nil(t, factLabels)
 }
 
-func (self *RepoTestSuite) TestRepo_Units() {
+func (self *RepoTestSuite) TestRepo_LabelText() {
 	ctx := context.Background()
-	unitId := self.addTestUnit(ctx)
+	factId := self.addTestFact(ctx)
+	self.addTestLabel(factId)
 
-	units, err := self.repo.Units(ctx)
+	rows, err := self.db.Query(ctx,
+		`SELECT id FROM fact_labels WHERE fact_id = $1`, factId)
+	self.Require().NoError(err)
+	labelId, err := pgx.CollectExactlyOneRow(rows, pgx.RowTo[uint32])
+	self.Require().NoError(err)
+
+	label, descr, err := self.repo.LabelText(ctx, labelId)
+	self.Require().NoError(err)
+	self.Equal(factLabel, label)
+	self.Equal(factDescr, descr)
+
+	label, descr, err = self.repo.LabelText(ctx, labelId+1)
+	self.Require().ErrorIs(err, pgx.ErrNoRows)
+	self.Empty(label)
+	self.Empty(descr)
+}
+
+func TestRepo_LabelText_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+
+	db.EXPECT().Query(ctx, mock.Anything, mock.Anything).Return(nil, wantErr).Once()
+
+	label, descr, err := repo.LabelText(ctx, 1)
+	require.ErrorIs(t, err, wantErr)
+	assert.Empty(t, label)
+	assert.Empty(t, descr)
+}
+
+func (self *RepoTestSuite) TestRepo_Facts() {
+	ctx := context.Background()
+	facts, err := self.repo.Facts(ctx)
+	self.Require().NoError(err)
+	self.Empty(facts)
+
+	factId := self.addTestFact(ctx)
+	deiId, err := self.repo.AddFact(ctx, "dei", "EntityCommonStockSharesOutstanding")
+	self.Require().NoError(err)
+	otherId, err := self.repo.AddFact(ctx, factTax, "AccountsPayableCurrent")
 	self.Require().NoError(err)
-	self.Len(units, 1)
-	self.Equal(map[uint32]string{unitId: unitName}, units)
+
+	facts, err = self.repo.Facts(ctx)
+	self.Require().NoError(err)
+	self.Equal([]FactInfo{
+		{Id: deiId, Tax: "dei", Name: "EntityCommonStockSharesOutstanding"},
+		{Id: factId, Tax: factTax, Name: factName},
+		{Id: otherId, Tax: factTax, Name: "AccountsPayableCurrent"},
+	}, facts)
+
+	facts, err = self.repo.FactsByTax(ctx, factTax)
+	self.Require().NoError(err)
+	self.Equal([]FactInfo{
+		{Id: factId, Tax: factTax, Name: factName},
+		{Id: otherId, Tax: factTax, Name: "AccountsPayableCurrent"},
+	}, facts)
+
+	facts, err = self.repo.FactsByTax(ctx, "ifrs-full")
+	self.Require().NoError(err)
+	self.Empty(facts)
 
 	m := mocks.NewMockPostgreser(self.T())
-	m.EXPECT().Query(ctx, mock.Anything).RunAndReturn(
+	m.EXPECT().Query(ctx, mock.Anything, mock.Anything).RunAndReturn(
 		func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
 			rows, err := self.db.Query(ctx, "SELECT 'not SERIAL'")
 			return rows, err
@@ -664,12 +1438,12 @@ func (self *RepoTestSuite) TestRepo_Units() {
 	self.repo.db = m
 	self.T().Cleanup(func() { self.repo.db = self.db })
 
-	units, err = self.repo.Units(ctx)
+	facts, err = self.repo.FactsByTax(ctx, factTax)
 	self.Require().Error(err)
-	self.Nil(units)
+	self.Nil(facts)
 }
 
-func TestRepo_Units_error(t *testing.T) {
+func TestRepo_Facts_error(t *testing.T) {
 	ctx := context.Background()
 	wantErr := errors.New("test error")
 
@@ -677,30 +1451,272 @@ func TestRepo_Units_error(t *testing.T) {
 	repo := New(db)
 
 	db.EXPECT().Query(ctx, mock.Anything).Return(nil, wantErr).Once()
+	facts, err := repo.Facts(ctx)
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, facts)
 
-	units, err := repo.Units(ctx)
+	db.EXPECT().Query(ctx, mock.Anything, mock.Anything).Return(nil, wantErr).Once()
+	facts, err = repo.FactsByTax(ctx, factTax)
 	require.ErrorIs(t, err, wantErr)
-	assert.Nil(t, units)
+	assert.Nil(t, facts)
 }
 
-func (self *RepoTestSuite) TestRepo_FiledCounts() {
+func (self *RepoTestSuite) TestRepo_Units() {
+	ctx := context.Background()
+	unitId := self.addTestUnit(ctx)
+
+	units, err := self.repo.Units(ctx)
+	self.Require().NoError(err)
+	self.Len(units, 1)
+	self.Equal(map[uint32]string{unitId: unitName}, units)
+
+	m := mocks.NewMockPostgreser(self.T())
+	m.EXPECT().Query(ctx, mock.Anything).RunAndReturn(
+		func(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+			rows, err := self.db.Query(ctx, "SELECT 'not SERIAL'")
+			return rows, err
+		})
+	self.repo.db = m
+	self.T().Cleanup(func() { self.repo.db = self.db })
+
+	units, err = self.repo.Units(ctx)
+	self.Require().Error(err)
+	self.Nil(units)
+}
+
+func TestRepo_Units_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+
+	db.EXPECT().Query(ctx, mock.Anything).Return(nil, wantErr).Once()
+
+	units, err := repo.Units(ctx)
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, units)
+}
+
+func (self *RepoTestSuite) TestRepo_UnitsForFact() {
 	ctx := context.Background()
 	self.addTestCompany(ctx)
 	factId := self.addTestFact(ctx)
-	unitId := self.addTestUnit(ctx)
+	otherFactId, err := self.repo.AddFact(ctx, factTax, "AccountsPayableCurrent")
+	self.Require().NoError(err)
+
+	units, err := self.repo.UnitsForFact(ctx, factId)
+	self.Require().NoError(err)
+	self.Empty(units)
+
+	unitIds := make(map[string]uint32, 3)
+	for _, name := range []string{"USD", "EUR", "GBP"} {
+		id, err := self.repo.AddUnit(ctx, name)
+		self.Require().NoError(err)
+		unitIds[name] = id
+	}
 
 	fullFact := FactUnit{
-		CIK:    appleCIK,
-		FactId: factId,
-		UnitId: unitId,
-		End:    time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC),
-		Val:    5520000000,
-		Accn:   "0001193125-09-153165",
-		FY:     2009,
-		FP:     "Q3",
-		Form:   "10-Q",
-		Filed:  time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC),
+		CIK:   appleCIK,
+		End:   time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC),
+		Val:   5520000000,
+		Accn:  "0001193125-09-153165",
+		FY:    2009,
+		FP:    "Q3",
+		Form:  "10-Q",
+		Filed: time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC),
+	}
+	facts := []FactUnit{fullFact, fullFact, fullFact, fullFact}
+	facts[0].FactId, facts[0].UnitId = factId, unitIds["USD"]
+	facts[1].FactId, facts[1].UnitId = factId, unitIds["EUR"]
+	facts[2].FactId, facts[2].UnitId = factId, unitIds["USD"]
+	facts[3].FactId, facts[3].UnitId = otherFactId, unitIds["GBP"]
+	self.Require().NoError(self.repo.CopyFactUnits(ctx, len(facts),
+		func(i int) (FactUnit, error) { return facts[i], nil }))
+
+	units, err = self.repo.UnitsForFact(ctx, factId)
+	self.Require().NoError(err)
+	self.Equal([]string{"EUR", "USD"}, units)
+
+	units, err = self.repo.UnitsForFact(ctx, otherFactId)
+	self.Require().NoError(err)
+	self.Equal([]string{"GBP"}, units)
+}
+
+func TestRepo_UnitsForFact_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything, mock.Anything).Return(nil, wantErr)
+
+	units, err := repo.UnitsForFact(ctx, 1)
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, units)
+}
+
+func (self *RepoTestSuite) TestRepo_FactUnitsByForm() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	fullFact := testFactUnit(factId, unitId)
+
+	facts := []FactUnit{fullFact, fullFact, fullFact}
+	facts[1].Form = "10-K"
+	facts[1].FP = "FY"
+	facts[1].Filed = time.Date(2009, 10, 27, 0, 0, 0, 0, time.UTC)
+	facts[2].Form = "8-K"
+	facts[2].Filed = time.Date(2009, 11, 2, 0, 0, 0, 0, time.UTC)
+	self.Require().NoError(self.repo.CopyFactUnits(ctx, len(facts),
+		func(i int) (FactUnit, error) { return facts[i], nil }))
+
+	tests := []struct {
+		name  string
+		forms []string
+		want  []FactUnit
+	}{
+		{
+			name:  "single form",
+			forms: []string{"10-K"},
+			want:  facts[1:2],
+		},
+		{
+			name:  "multiple forms",
+			forms: []string{"10-Q", "10-K"},
+			want:  facts[:2],
+		},
+		{
+			name: "nil forms",
+			want: facts,
+		},
+		{
+			name:  "empty forms",
+			forms: []string{},
+			want:  facts,
+		},
+		{
+			name:  "unknown form",
+			forms: []string{"20-F"},
+		},
+	}
+
+	for _, tt := range tests {
+		self.Run(tt.name, func() {
+			got, err := self.repo.FactUnitsByForm(ctx, appleCIK, factId, unitId,
+				tt.forms)
+			self.Require().NoError(err)
+			if len(tt.want) == 0 {
+				self.Empty(got)
+			} else {
+				self.Equal(tt.want, got)
+			}
+		})
 	}
+}
+
+func TestRepo_FactUnitsByForm_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything).Return(nil, wantErr)
+
+	facts, err := repo.FactUnitsByForm(ctx, appleCIK, 1, 1, []string{"10-K"})
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, facts)
+}
+
+func (self *RepoTestSuite) TestRepo_FactUnitsByFiscalYear() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	fullFact := testFactUnit(factId, unitId)
+
+	facts := []FactUnit{fullFact, fullFact, fullFact}
+	facts[1].Form = "10-K"
+	facts[1].FP = "FY"
+	facts[1].Filed = time.Date(2009, 10, 27, 0, 0, 0, 0, time.UTC)
+	facts[2].FY = 2010
+	facts[2].FP = "Q1"
+	facts[2].Filed = time.Date(2010, 1, 25, 0, 0, 0, 0, time.UTC)
+	self.Require().NoError(self.repo.CopyFactUnits(ctx, len(facts),
+		func(i int) (FactUnit, error) { return facts[i], nil }))
+
+	tests := []struct {
+		name string
+		fy   int
+		fp   string
+		want []FactUnit
+	}{
+		{
+			name: "FY only",
+			fy:   2009,
+			want: facts[:2],
+		},
+		{
+			name: "FY and FP",
+			fy:   2009,
+			fp:   "FY",
+			want: facts[1:2],
+		},
+		{
+			name: "other FY",
+			fy:   2010,
+			want: facts[2:],
+		},
+		{
+			name: "unknown FP",
+			fy:   2010,
+			fp:   "Q3",
+		},
+		{
+			name: "unknown FY",
+			fy:   2008,
+		},
+	}
+
+	for _, tt := range tests {
+		self.Run(tt.name, func() {
+			got, err := self.repo.FactUnitsByFiscalYear(ctx, appleCIK, factId,
+				unitId, tt.fy, tt.fp)
+			self.Require().NoError(err)
+			if len(tt.want) == 0 {
+				self.Empty(got)
+			} else {
+				self.Equal(tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRepo_FactUnitsByFiscalYear_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything).Return(nil, wantErr)
+
+	facts, err := repo.FactUnitsByFiscalYear(ctx, appleCIK, 1, 1, 2009, "FY")
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, facts)
+}
+
+func (self *RepoTestSuite) TestRepo_FiledCounts() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	fullFact := testFactUnit(factId, unitId)
 
 	filed := []time.Time{
 		time.Date(2009, 7, 4, 0, 0, 0, 0, time.UTC),
@@ -712,8 +1728,10 @@ func (self *RepoTestSuite) TestRepo_FiledCounts() {
 	facts := make([]FactUnit, len(filed))
 	for i := range filed {
 		facts[i] = fullFact
+		facts[i].Accn = strconv.Itoa(i)
 		facts[i].Filed = filed[i]
 	}
+	facts = append(facts, facts[2]) // duplicates are counted once
 
 	self.Require().NoError(self.repo.CopyFactUnits(ctx, len(facts),
 		func(i int) (FactUnit, error) { return facts[i], nil }))
@@ -756,6 +1774,60 @@ func TestRepo_FiledCounts_error(t *testing.T) {
 	assert.Nil(t, counts)
 }
 
+func (self *RepoTestSuite) TestRepo_FactUnitsDiff() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	fullFact := testFactUnit(factId, unitId)
+
+	lastFiled := fullFact.Filed
+	added, removed, err := self.repo.FactUnitsDiff(ctx, appleCIK,
+		[]FactUnit{fullFact})
+	self.Require().NoError(err)
+	self.Equal([]FactUnit{fullFact}, added)
+	self.Empty(removed)
+
+	stored := []FactUnit{fullFact, fullFact, fullFact}
+	stored[1].Accn = "0001193125-09-214859"
+	stored[1].End = time.Date(2009, 6, 27, 0, 0, 0, 0, time.UTC)
+	stored[2].Accn = "0001193125-09-100000"
+	stored[2].Filed = lastFiled.AddDate(0, -3, 0) // filed before lastFiled
+	self.Require().NoError(self.repo.CopyFactUnits(ctx, len(stored),
+		func(i int) (FactUnit, error) { return stored[i], nil }))
+
+	incoming := []FactUnit{fullFact, fullFact, stored[2]}
+	incoming[0].Val = 5530000000 // the same key, but changed val
+	incoming[1].Accn = "0001193125-09-214860"
+	incoming[2].Accn = "0001193125-09-100001" // filed before lastFiled
+	added, removed, err = self.repo.FactUnitsDiffSince(ctx, appleCIK, lastFiled,
+		incoming)
+	self.Require().NoError(err)
+	self.Equal([]FactUnit{incoming[1]}, added)
+	self.Equal([]FactUnit{stored[1]}, removed)
+
+	added, removed, err = self.repo.FactUnitsDiff(ctx, appleCIK, incoming)
+	self.Require().NoError(err)
+	self.Equal([]FactUnit{incoming[1], incoming[2]}, added)
+	self.ElementsMatch([]FactUnit{stored[1], stored[2]}, removed)
+}
+
+func TestRepo_FactUnitsDiff_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything, mock.Anything, mock.Anything).Return(
+		nil, wantErr)
+
+	added, removed, err := repo.FactUnitsDiff(ctx, appleCIK, []FactUnit{{}})
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, added)
+	assert.Nil(t, removed)
+}
+
 func (self *RepoTestSuite) TestRepo_ReplaceFactUnits() {
 	ctx := context.Background()
 	self.addTestCompany(ctx)
@@ -763,18 +1835,8 @@ func (self *RepoTestSuite) TestRepo_ReplaceFactUnits() {
 	unitId := self.addTestUnit(ctx)
 
 	lastFiled := time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC)
-	fullFact := FactUnit{
-		CIK:    appleCIK,
-		FactId: factId,
-		UnitId: unitId,
-		End:    time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC),
-		Val:    5520000000,
-		Accn:   "0001193125-09-153165",
-		FY:     2009,
-		FP:     "Q3",
-		Form:   "10-Q",
-		Filed:  lastFiled,
-	}
+	fullFact := testFactUnit(factId, unitId)
+	fullFact.Filed = lastFiled
 	fullFact.WithStart(time.Date(2008, 9, 27, 0, 0, 0, 0, time.UTC)).
 		WithFrame("CY2008Q3I")
 
@@ -834,6 +1896,380 @@ func TestRepo_ReplaceFactUnits_error(t *testing.T) {
 	require.ErrorIs(t, err, wantErr)
 }
 
+func (self *RepoTestSuite) TestRepo_ReplaceFactUnitsForDate() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	filed := time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC)
+	fullFact := testFactUnit(factId, unitId)
+	fullFact.Filed = filed
+
+	facts := []FactUnit{fullFact, fullFact, fullFact}
+	facts[0].Filed = filed.AddDate(0, 0, -1)
+	facts[len(facts)-1].Filed = filed.AddDate(0, 0, 1)
+	err := self.repo.CopyFactUnits(ctx, len(facts), func(i int) (FactUnit, error) {
+		return facts[i], nil
+	})
+	self.Require().NoError(err)
+
+	replaced := fullFact
+	replaced.Val = 5530000000
+	err = self.repo.ReplaceFactUnitsForDate(ctx, appleCIK, filed, 2,
+		func(i int) (FactUnit, error) { return replaced, nil })
+	self.Require().NoError(err)
+
+	rows, err := self.db.Query(ctx, `SELECT * FROM fact_units ORDER BY filed`)
+	self.Require().NoError(err)
+	gotFacts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactUnit])
+	self.Require().NoError(err)
+	self.Equal([]FactUnit{facts[0], replaced, replaced, facts[2]}, gotFacts)
+}
+
+func TestRepo_ReplaceFactUnitsForDate_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Begin(ctx).Return(nil, wantErr).Once()
+
+	filed := time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC)
+	next := func(i int) (FactUnit, error) { return FactUnit{}, nil }
+	err := repo.ReplaceFactUnitsForDate(ctx, appleCIK, filed, 1, next)
+	require.ErrorIs(t, err, wantErr)
+
+	tx := pgxMocks.NewMockTx(t)
+	db.EXPECT().Begin(ctx).Return(tx, nil)
+	tx.EXPECT().Exec(ctx, mock.Anything, uint32(appleCIK), filed).
+		Return(pgconn.NewCommandTag(""), wantErr)
+	tx.EXPECT().Rollback(ctx).Return(nil)
+
+	err = repo.ReplaceFactUnitsForDate(ctx, appleCIK, filed, 1, next)
+	require.ErrorIs(t, err, wantErr)
+}
+
+func (self *RepoTestSuite) TestRepo_BulkReplaceFactUnits() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	const otherCIK = appleCIK + 1
+	_, err := self.repo.AddCompany(ctx, otherCIK, "Other Inc.")
+	self.Require().NoError(err)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	lastFiled := time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC)
+	fact := testFactUnit(factId, unitId)
+	fact.Filed = lastFiled
+	otherFact := fact
+	otherFact.CIK = otherCIK
+
+	facts := []FactUnit{fact, fact, otherFact, otherFact}
+	facts[0].Filed = lastFiled.AddDate(0, 0, -1)
+	facts[2].Filed = lastFiled.AddDate(0, 0, -1)
+	err = self.repo.CopyFactUnits(ctx, len(facts), func(i int) (FactUnit, error) {
+		return facts[i], nil
+	})
+	self.Require().NoError(err)
+
+	replaced, otherReplaced := fact, otherFact
+	replaced.Val = 5530000000
+	otherReplaced.Val = 5540000000
+	err = self.repo.BulkReplaceFactUnits(ctx, []FactReplacement{
+		{CIK: appleCIK, LastFiled: lastFiled, Facts: []FactUnit{replaced, replaced}},
+		{CIK: otherCIK, LastFiled: lastFiled, Facts: []FactUnit{otherReplaced}},
+	})
+	self.Require().NoError(err)
+
+	rows, err := self.db.Query(ctx,
+		`SELECT * FROM fact_units ORDER BY company_cik, filed, val`)
+	self.Require().NoError(err)
+	gotFacts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactUnit])
+	self.Require().NoError(err)
+	self.Equal([]FactUnit{facts[0], replaced, replaced, facts[2], otherReplaced},
+		gotFacts)
+
+	err = self.repo.BulkReplaceFactUnits(ctx, []FactReplacement{
+		{CIK: appleCIK, LastFiled: lastFiled.AddDate(0, 0, -1)},
+		{CIK: otherCIK, LastFiled: lastFiled, Facts: []FactUnit{fact}},
+	})
+	self.Require().ErrorContains(err, "CIK=")
+
+	rows, err = self.db.Query(ctx,
+		`SELECT * FROM fact_units ORDER BY company_cik, filed, val`)
+	self.Require().NoError(err)
+	gotFacts, err = pgx.CollectRows(rows, pgx.RowToStructByName[FactUnit])
+	self.Require().NoError(err)
+	self.Len(gotFacts, 5, "nothing must be deleted on error")
+}
+
+func TestRepo_BulkReplaceFactUnits_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+	lastFiled := time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC)
+	replacements := []FactReplacement{
+		{CIK: appleCIK, LastFiled: lastFiled, Facts: []FactUnit{{CIK: appleCIK}}},
+	}
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	err := repo.BulkReplaceFactUnits(ctx, []FactReplacement{
+		{CIK: appleCIK, Facts: []FactUnit{{CIK: appleCIK + 1}}},
+	})
+	require.ErrorContains(t, err, "has CIK=")
+
+	db.EXPECT().Begin(ctx).Return(nil, wantErr).Once()
+	require.ErrorIs(t, repo.BulkReplaceFactUnits(ctx, replacements), wantErr)
+
+	tx := pgxMocks.NewMockTx(t)
+	db.EXPECT().Begin(ctx).Return(tx, nil)
+	tx.EXPECT().Exec(ctx, mock.Anything, uint32(appleCIK), lastFiled).
+		Return(pgconn.NewCommandTag(""), wantErr).Once()
+	tx.EXPECT().Rollback(ctx).Return(nil)
+	require.ErrorIs(t, repo.BulkReplaceFactUnits(ctx, replacements), wantErr)
+
+	tx.EXPECT().Exec(ctx, mock.Anything, uint32(appleCIK), lastFiled).
+		Return(pgconn.NewCommandTag(""), nil)
+	tx.EXPECT().CopyFrom(ctx, pgx.Identifier{"fact_units"}, factUnitCols[:],
+		mock.Anything).Return(0, wantErr)
+	require.ErrorIs(t, repo.BulkReplaceFactUnits(ctx, replacements), wantErr)
+}
+
+func (self *RepoTestSuite) TestRepo_FactUnitsAfter() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	fact := testFactUnit(factId, unitId)
+	after := time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC)
+	facts := []FactUnit{fact, fact, fact, fact}
+	facts[0].Filed = after.AddDate(0, 0, 2)
+	facts[1].Filed = after.AddDate(0, 0, -1)
+	facts[2].Filed = after
+	facts[3].Filed = after.AddDate(0, 0, 1)
+	err := self.repo.CopyFactUnits(ctx, len(facts), func(i int) (FactUnit, error) {
+		return facts[i], nil
+	})
+	self.Require().NoError(err)
+
+	gotFacts, err := self.repo.FactUnitsAfter(ctx, appleCIK, after)
+	self.Require().NoError(err)
+	self.Equal([]FactUnit{facts[3], facts[0]}, gotFacts,
+		"filed == after must be excluded")
+
+	gotFacts, err = self.repo.FactUnitsAfter(ctx, appleCIK, facts[0].Filed)
+	self.Require().NoError(err)
+	self.Empty(gotFacts)
+
+	gotFacts, err = self.repo.FactUnitsAfter(ctx, 1, time.Time{})
+	self.Require().NoError(err)
+	self.Empty(gotFacts)
+}
+
+func TestRepo_FactUnitsAfter_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	after := time.Date(2009, 7, 22, 0, 0, 0, 0, time.UTC)
+	db.EXPECT().Query(ctx, mock.Anything, uint32(appleCIK), after).
+		Return(nil, wantErr)
+
+	facts, err := repo.FactUnitsAfter(ctx, appleCIK, after)
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, facts)
+}
+
+func (self *RepoTestSuite) TestRepo_TruncateAll() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+	self.Require().NoError(self.repo.AddLabel(ctx, factId, factLabel, factDescr,
+		xxhash.Sum64String(factLabel), xxhash.Sum64String(factDescr)))
+	self.Require().NoError(self.repo.AddFactUnit(ctx, testFactUnit(factId, unitId)))
+
+	self.Require().ErrorIs(self.repo.TruncateAll(ctx, "truncate all data"),
+		ErrTruncateNotConfirmed)
+	self.Require().NoError(self.repo.TruncateAll(ctx, TruncateAllConfirm))
+
+	for _, tname := range []string{
+		"fact_units", "fact_labels", "facts", "units", "companies",
+	} {
+		rows, err := self.db.Query(ctx, "SELECT COUNT(*) FROM "+tname)
+		self.Require().NoError(err)
+		cnt, err := pgx.CollectExactlyOneRow(rows, pgx.RowTo[int])
+		self.Require().NoError(err)
+		self.Zero(cnt, tname)
+	}
+}
+
+func TestRepo_TruncateAll_error(t *testing.T) {
+	ctx := context.Background()
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+
+	require.ErrorIs(t, repo.TruncateAll(ctx, ""), ErrTruncateNotConfirmed)
+
+	wantErr := errors.New("test error")
+	db.EXPECT().Exec(ctx, mock.Anything).Return(pgconn.CommandTag{}, wantErr)
+	require.ErrorIs(t, repo.TruncateAll(ctx, TruncateAllConfirm), wantErr)
+}
+
+func (self *RepoTestSuite) TestRepo_CompanyCount_FactUnitCount() {
+	ctx := context.Background()
+	cnt, err := self.repo.CompanyCount(ctx)
+	self.Require().NoError(err)
+	self.Zero(cnt)
+
+	cnt, err = self.repo.FactUnitCount(ctx)
+	self.Require().NoError(err)
+	self.Zero(cnt)
+
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+	fact := testFactUnit(factId, unitId)
+	facts := []FactUnit{fact, fact, fact}
+	self.Require().NoError(self.repo.CopyFactUnits(ctx, len(facts),
+		func(i int) (FactUnit, error) { return facts[i], nil }))
+
+	cnt, err = self.repo.CompanyCount(ctx)
+	self.Require().NoError(err)
+	self.Equal(int64(1), cnt)
+
+	cnt, err = self.repo.FactUnitCount(ctx)
+	self.Require().NoError(err)
+	self.Equal(int64(len(facts)), cnt)
+}
+
+func TestRepo_CompanyCount_FactUnitCount_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything).Return(nil, wantErr)
+
+	cnt, err := repo.CompanyCount(ctx)
+	require.ErrorIs(t, err, wantErr)
+	assert.Zero(t, cnt)
+
+	cnt, err = repo.FactUnitCount(ctx)
+	require.ErrorIs(t, err, wantErr)
+	assert.Zero(t, cnt)
+}
+
+func (self *RepoTestSuite) TestRepo_DeleteDuplicateFactUnits() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	unitId := self.addTestUnit(ctx)
+
+	fact := testFactUnit(factId, unitId)
+	otherPeriod := fact
+	otherPeriod.WithStart(time.Date(2008, 6, 28, 0, 0, 0, 0, time.UTC))
+	otherAccn := fact
+	otherAccn.Accn = "0001193125-09-153166"
+
+	facts := []FactUnit{fact, fact, fact, otherPeriod, otherPeriod, otherAccn}
+	self.Require().NoError(self.repo.CopyFactUnits(ctx, len(facts),
+		func(i int) (FactUnit, error) { return facts[i], nil }))
+
+	n, err := self.repo.CountDuplicateFactUnits(ctx)
+	self.Require().NoError(err)
+	self.Equal(int64(3), n)
+
+	n, err = self.repo.DeleteDuplicateFactUnits(ctx)
+	self.Require().NoError(err)
+	self.Equal(int64(3), n)
+
+	rows, err := self.db.Query(ctx, `SELECT * FROM fact_units`)
+	self.Require().NoError(err)
+	gotFacts, err := pgx.CollectRows(rows, pgx.RowToStructByName[FactUnit])
+	self.Require().NoError(err)
+	self.ElementsMatch([]FactUnit{fact, otherPeriod, otherAccn}, gotFacts)
+
+	n, err = self.repo.CountDuplicateFactUnits(ctx)
+	self.Require().NoError(err)
+	self.Zero(n)
+
+	n, err = self.repo.DeleteDuplicateFactUnits(ctx)
+	self.Require().NoError(err)
+	self.Zero(n)
+}
+
+func TestRepo_DeleteDuplicateFactUnits_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything).Return(nil, wantErr)
+	db.EXPECT().Exec(ctx, mock.Anything).Return(pgconn.NewCommandTag(""), wantErr)
+
+	n, err := repo.CountDuplicateFactUnits(ctx)
+	require.ErrorIs(t, err, wantErr)
+	assert.Zero(t, n)
+
+	n, err = repo.DeleteDuplicateFactUnits(ctx)
+	require.ErrorIs(t, err, wantErr)
+	assert.Zero(t, n)
+}
+
+func (self *RepoTestSuite) TestRepo_CountByTaxonomy() {
+	ctx := context.Background()
+	counts, err := self.repo.CountFactsByTaxonomy(ctx)
+	self.Require().NoError(err)
+	self.Empty(counts)
+
+	counts, err = self.repo.CountFactUnitsByTaxonomy(ctx)
+	self.Require().NoError(err)
+	self.Empty(counts)
+
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	deiId, err := self.repo.AddFact(ctx, "dei", "EntityCommonStockSharesOutstanding")
+	self.Require().NoError(err)
+	_, err = self.repo.AddFact(ctx, "dei", "EntityPublicFloat")
+	self.Require().NoError(err)
+	unitId := self.addTestUnit(ctx)
+
+	fact := testFactUnit(factId, unitId)
+	facts := []FactUnit{fact, fact, fact}
+	facts[2].FactId = deiId
+	self.Require().NoError(self.repo.CopyFactUnits(ctx, len(facts),
+		func(i int) (FactUnit, error) { return facts[i], nil }))
+
+	counts, err = self.repo.CountFactsByTaxonomy(ctx)
+	self.Require().NoError(err)
+	self.Equal(map[string]int64{factTax: 1, "dei": 2}, counts)
+
+	counts, err = self.repo.CountFactUnitsByTaxonomy(ctx)
+	self.Require().NoError(err)
+	self.Equal(map[string]int64{factTax: 2, "dei": 1}, counts)
+}
+
+func TestRepo_CountByTaxonomy_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything).Return(nil, wantErr)
+
+	counts, err := repo.CountFactsByTaxonomy(ctx)
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, counts)
+
+	counts, err = repo.CountFactUnitsByTaxonomy(ctx)
+	require.ErrorIs(t, err, wantErr)
+	assert.Nil(t, counts)
+}
+
 func (self *RepoTestSuite) TestRepo_AddLastUpdate_LastUpdated() {
 	ctx := context.Background()
 	lastUpdated, err := self.repo.LastUpdated(ctx)
@@ -877,3 +2313,59 @@ func (self *RepoTestSuite) TestRepo_AddLastUpdate_LastUpdated() {
 	_, err = self.repo.LastUpdated(ctx)
 	self.Require().Error(err)
 }
+
+func (self *RepoTestSuite) TestRepo_LastUpdated_max() {
+	ctx := context.Background()
+	dates := []time.Time{
+		time.Date(2024, time.January, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.January, 11, 0, 0, 0, 0, time.UTC),
+	}
+	for _, at := range dates {
+		self.Require().NoError(self.repo.AddLastUpdate(ctx, at))
+	}
+
+	lastUpdated, err := self.repo.LastUpdated(ctx)
+	self.Require().NoError(err)
+	self.Equal(dates[1], lastUpdated)
+}
+
+func (self *RepoTestSuite) TestRepo_AddLastUpdate_zero() {
+	ctx := context.Background()
+	self.Require().NoError(self.repo.AddLastUpdate(ctx, time.Time{}))
+
+	lastUpdated, err := self.repo.LastUpdated(ctx)
+	self.Require().NoError(err)
+	self.True(lastUpdated.IsZero())
+}
+
+func TestRepo_AddLastUpdate_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	at := time.Date(2024, time.January, 12, 0, 0, 0, 0, time.UTC)
+	db.EXPECT().Exec(ctx, mock.Anything, at).Return(pgconn.NewCommandTag(""),
+		wantErr).Once()
+	require.ErrorIs(t, repo.AddLastUpdate(ctx, at), wantErr)
+}
+
+func TestRepo_LastUpdated_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything).Return(nil, wantErr).Once()
+
+	lastUpdated, err := repo.LastUpdated(ctx)
+	require.ErrorIs(t, err, wantErr)
+	assert.True(t, lastUpdated.IsZero())
+}
+
+type errRow struct {
+	err error
+}
+
+func (self errRow) Scan(dest ...any) error { return self.err }
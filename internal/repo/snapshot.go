@@ -0,0 +1,218 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// StreamAllCompanies calls fn for every stored company, ordered by CIK. It
+// stops and returns error of fn, if fn returns error.
+func (self *Repo) StreamAllCompanies(ctx context.Context,
+	fn func(item Company) error,
+) error {
+	return streamAll(ctx, self.db, "StreamAllCompanies",
+		`SELECT cik, entity_name FROM companies ORDER BY cik`, fn)
+}
+
+// StreamAllFacts is like StreamAllCompanies, but for facts, ordered by id.
+func (self *Repo) StreamAllFacts(ctx context.Context,
+	fn func(item FactInfo) error,
+) error {
+	return streamAll(ctx, self.db, "StreamAllFacts",
+		`SELECT id, fact_tax, fact_name FROM facts ORDER BY id`, fn)
+}
+
+// StreamAllFactLabels is like StreamAllCompanies, but for labels of facts,
+// ordered by id.
+func (self *Repo) StreamAllFactLabels(ctx context.Context,
+	fn func(item Label) error,
+) error {
+	return streamAll(ctx, self.db, "StreamAllFactLabels", `
+SELECT id, fact_id, fact_label, descr, xxhash1, xxhash2
+  FROM fact_labels ORDER BY id`, fn)
+}
+
+// StreamAllUnits is like StreamAllCompanies, but for units, ordered by id.
+func (self *Repo) StreamAllUnits(ctx context.Context,
+	fn func(item Unit) error,
+) error {
+	return streamAll(ctx, self.db, "StreamAllUnits",
+		`SELECT id, unit_name FROM units ORDER BY id`, fn)
+}
+
+func streamAll[T any](ctx context.Context, db Postgreser, method, sql string,
+	fn func(item T) error,
+) error {
+	rows, err := db.Query(ctx, sql)
+	if err != nil {
+		return fmt.Errorf("repo.%v: %w", method, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := pgx.RowToStructByName[T](rows)
+		if err != nil {
+			return fmt.Errorf("repo.%v: %w", method, err)
+		} else if err := fn(item); err != nil {
+			return fmt.Errorf("repo.%v: %w", method, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("repo.%v: %w", method, err)
+	}
+	return nil
+}
+
+// RestoreCompanies adds companies, keeping already existing companies as is,
+// and returns number of actually added companies. Restore* methods are designed
+// for restoring rows returned by StreamAll* methods into empty db, and safe to
+// call again with the same rows.
+func (self *Repo) RestoreCompanies(ctx context.Context, items []Company,
+) (int64, error) {
+	return self.restore(ctx, restoreTable{
+		method: "RestoreCompanies",
+		table:  "companies",
+		cols:   []string{"cik", "entity_name"},
+	}, len(items), func(i int) []any {
+		return []any{items[i].CIK, items[i].Name}
+	})
+}
+
+// RestoreFacts is like RestoreCompanies, but for facts. It keeps id of every
+// fact.
+func (self *Repo) RestoreFacts(ctx context.Context, items []FactInfo,
+) (int64, error) {
+	return self.restore(ctx, restoreTable{
+		method: "RestoreFacts",
+		table:  "facts",
+		cols:   []string{"id", "fact_tax", "fact_name"},
+		serial: "id",
+	}, len(items), func(i int) []any {
+		fact := &items[i]
+		return []any{fact.Id, fact.Tax, fact.Name}
+	})
+}
+
+// RestoreFactLabels is like RestoreCompanies, but for labels of facts. It keeps
+// id of every label.
+func (self *Repo) RestoreFactLabels(ctx context.Context, items []Label,
+) (int64, error) {
+	return self.restore(ctx, restoreTable{
+		method: "RestoreFactLabels",
+		table:  "fact_labels",
+		cols:   append([]string{"id"}, labelCols[:]...),
+		serial: "id",
+	}, len(items), func(i int) []any {
+		l := &items[i]
+		return []any{l.Id, l.FactId, l.Label, l.Descr, l.LabelHash, l.DescrHash}
+	})
+}
+
+// RestoreUnits is like RestoreCompanies, but for units. It keeps id of every
+// unit.
+func (self *Repo) RestoreUnits(ctx context.Context, items []Unit,
+) (int64, error) {
+	return self.restore(ctx, restoreTable{
+		method: "RestoreUnits",
+		table:  "units",
+		cols:   []string{"id", "unit_name"},
+		serial: "id",
+	}, len(items), func(i int) []any {
+		return []any{items[i].Id, items[i].Name}
+	})
+}
+
+// RestoreFactUnits is like RestoreCompanies, but for fact units. fact_units
+// table has no unique constraints, so it skips duplicates of already existing
+// fact units and restores only one of duplicates in items, see
+// factUnitKeyCols.
+func (self *Repo) RestoreFactUnits(ctx context.Context, items []FactUnit,
+) (int64, error) {
+	return self.restore(ctx, restoreTable{
+		method:   "RestoreFactUnits",
+		table:    "fact_units",
+		cols:     factUnitCols[:],
+		distinct: factUnitKeyCols,
+		where: `
+  WHERE NOT EXISTS (
+    SELECT FROM fact_units AS f WHERE ` + sameFactUnitKey("f", "r.") + `)`,
+	}, len(items), func(i int) []any {
+		fact := &items[i]
+		return []any{
+			fact.CIK, fact.FactId, fact.UnitId, fact.Start, fact.End, fact.Val,
+			fact.Accn, fact.FY, fact.FP, fact.Form, fact.Filed, fact.Frame,
+		}
+	})
+}
+
+// restoreTable describes table for [Repo.restore].
+type restoreTable struct {
+	method   string
+	table    string
+	cols     []string
+	serial   string // serial column, which sequence must be updated
+	distinct string // if not empty, insert one staged row per these columns
+	where    string // additional filter of staged rows "r"
+}
+
+// restore copies length rows, returned by next, into temporary copy of table
+// and inserts them from there, ignoring conflicting rows. If serial isn't
+// empty, it sets sequence of this column to max restored value, because rows
+// are inserted with their ids and the sequence doesn't advance itself.
+func (self *Repo) restore(ctx context.Context, t restoreTable, length int,
+	next func(i int) []any,
+) (n int64, err error) {
+	if length == 0 {
+		return 0, nil
+	}
+
+	stage := t.table + "_restore"
+	cols := strings.Join(t.cols, ", ")
+	var distinct string
+	if t.distinct != "" {
+		distinct = "DISTINCT ON (" + t.distinct + ") "
+	}
+	err = pgx.BeginFunc(ctx, self.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, fmt.Sprintf(
+			`CREATE TEMP TABLE %v (LIKE %v) ON COMMIT DROP`, stage, t.table))
+		if err != nil {
+			return fmt.Errorf("create staging table: %w", err)
+		}
+
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{stage}, t.cols,
+			pgx.CopyFromSlice(length, func(i int) ([]any, error) {
+				return next(i), nil
+			}))
+		if err != nil {
+			return fmt.Errorf("copy %v rows into %v: %w", length, stage, err)
+		}
+
+		tag, err := tx.Exec(ctx, fmt.Sprintf(`
+INSERT INTO %v (%v)
+  SELECT %v%v FROM %v AS r%v
+  ON CONFLICT DO NOTHING`, t.table, cols, distinct, cols, stage, t.where))
+		if err != nil {
+			return fmt.Errorf("insert staged rows into %v: %w", t.table, err)
+		}
+		n = tag.RowsAffected()
+
+		if t.serial != "" && n > 0 {
+			_, err := tx.Exec(ctx, fmt.Sprintf(
+				`SELECT setval(pg_get_serial_sequence('%v', '%v'), MAX(%v)) FROM %v`,
+				t.table, t.serial, t.serial, t.table))
+			if err != nil {
+				return fmt.Errorf("update sequence of %v.%v: %w",
+					t.table, t.serial, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("repo.%v: %w", t.method, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,190 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pgxMocks "github.com/dsh2dsh/edgar/internal/mocks/pgx"
+	mocks "github.com/dsh2dsh/edgar/internal/mocks/repo"
+)
+
+type testSnapshot struct {
+	companies []Company
+	facts     []FactInfo
+	labels    []Label
+	units     []Unit
+	factUnits []FactUnit
+}
+
+func (self *RepoTestSuite) snapshot(ctx context.Context) (s testSnapshot) {
+	self.Require().NoError(self.repo.StreamAllCompanies(ctx,
+		func(item Company) error {
+			s.companies = append(s.companies, item)
+			return nil
+		}))
+	self.Require().NoError(self.repo.StreamAllFacts(ctx,
+		func(item FactInfo) error {
+			s.facts = append(s.facts, item)
+			return nil
+		}))
+	self.Require().NoError(self.repo.StreamAllFactLabels(ctx,
+		func(item Label) error {
+			s.labels = append(s.labels, item)
+			return nil
+		}))
+	self.Require().NoError(self.repo.StreamAllUnits(ctx,
+		func(item Unit) error {
+			s.units = append(s.units, item)
+			return nil
+		}))
+	self.Require().NoError(self.repo.StreamFactUnits(ctx,
+		func(item FactUnit) error {
+			s.factUnits = append(s.factUnits, item)
+			return nil
+		}))
+	return
+}
+
+func (self *RepoTestSuite) restoreSnapshot(ctx context.Context, s testSnapshot,
+) (n int64) {
+	for _, fn := range [...]func() (int64, error){
+		func() (int64, error) { return self.repo.RestoreCompanies(ctx, s.companies) },
+		func() (int64, error) { return self.repo.RestoreFacts(ctx, s.facts) },
+		func() (int64, error) { return self.repo.RestoreFactLabels(ctx, s.labels) },
+		func() (int64, error) { return self.repo.RestoreUnits(ctx, s.units) },
+		func() (int64, error) { return self.repo.RestoreFactUnits(ctx, s.factUnits) },
+	} {
+		cnt, err := fn()
+		self.Require().NoError(err)
+		n += cnt
+	}
+	return
+}
+
+func (self *RepoTestSuite) TestRepo_SnapshotRestore() {
+	ctx := context.Background()
+	self.addTestCompany(ctx)
+	factId := self.addTestFact(ctx)
+	self.addTestLabel(factId)
+	unitId := self.addTestUnit(ctx)
+
+	fact := testFactUnit(factId, unitId)
+	withStart := fact
+	withStart.WithStart(time.Date(2008, 6, 28, 0, 0, 0, 0, time.UTC)).
+		WithFrame("CY2008Q3I")
+	self.Require().NoError(self.repo.AddFactUnit(ctx, fact))
+	self.Require().NoError(self.repo.AddFactUnit(ctx, withStart))
+
+	want := self.snapshot(ctx)
+	self.Len(want.companies, 1)
+	self.Len(want.facts, 1)
+	self.Len(want.labels, 1)
+	self.Len(want.units, 1)
+	self.Len(want.factUnits, 2)
+
+	self.Require().NoError(self.repo.TruncateAll(ctx, TruncateAllConfirm))
+	self.Equal(int64(6), self.restoreSnapshot(ctx, want))
+	got := self.snapshot(ctx)
+	self.Equal(want.companies, got.companies)
+	self.Equal(want.facts, got.facts)
+	self.Equal(want.labels, got.labels)
+	self.Equal(want.units, got.units)
+	self.ElementsMatch(want.factUnits, got.factUnits)
+
+	self.Zero(self.restoreSnapshot(ctx, want))
+	n, err := self.repo.FactUnitCount(ctx)
+	self.Require().NoError(err)
+	self.Equal(int64(2), n)
+
+	// duplicates inside one batch are restored once, like across batches
+	self.Require().NoError(self.repo.TruncateAll(ctx, TruncateAllConfirm))
+	withDups := want
+	withDups.factUnits = append(slices.Clone(want.factUnits), want.factUnits...)
+	self.Equal(int64(6), self.restoreSnapshot(ctx, withDups))
+	got = self.snapshot(ctx)
+	self.ElementsMatch(want.factUnits, got.factUnits)
+
+	newFactId, err := self.repo.AddFact(ctx, factTax, "AccountsReceivable")
+	self.Require().NoError(err)
+	self.Greater(newFactId, factId)
+}
+
+func TestRepo_StreamAll_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	db.EXPECT().Query(ctx, mock.Anything).Return(nil, wantErr)
+
+	require.ErrorIs(t, repo.StreamAllCompanies(ctx,
+		func(Company) error { return nil }), wantErr)
+	require.ErrorIs(t, repo.StreamAllFacts(ctx,
+		func(FactInfo) error { return nil }), wantErr)
+	require.ErrorIs(t, repo.StreamAllFactLabels(ctx,
+		func(Label) error { return nil }), wantErr)
+	require.ErrorIs(t, repo.StreamAllUnits(ctx,
+		func(Unit) error { return nil }), wantErr)
+	require.ErrorIs(t, repo.StreamFactUnits(ctx,
+		func(FactUnit) error { return nil }), wantErr)
+}
+
+func TestRepo_Restore_error(t *testing.T) {
+	ctx := context.Background()
+	wantErr := errors.New("test error")
+	units := []Unit{{Id: 1, Name: unitName}}
+
+	db := mocks.NewMockPostgreser(t)
+	repo := New(db)
+	n, err := repo.RestoreUnits(ctx, nil)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	db.EXPECT().Begin(ctx).Return(nil, wantErr).Once()
+	_, err = repo.RestoreUnits(ctx, units)
+	require.ErrorIs(t, err, wantErr)
+
+	tx := pgxMocks.NewMockTx(t)
+	db.EXPECT().Begin(ctx).Return(tx, nil)
+	tx.EXPECT().Rollback(ctx).Return(nil)
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag(""), wantErr).Once()
+	_, err = repo.RestoreUnits(ctx, units)
+	require.ErrorIs(t, err, wantErr)
+
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag(""), nil).Once()
+	tx.EXPECT().CopyFrom(ctx, pgx.Identifier{"units_restore"},
+		[]string{"id", "unit_name"}, mock.Anything).Return(0, wantErr).Once()
+	_, err = repo.RestoreUnits(ctx, units)
+	require.ErrorIs(t, err, wantErr)
+
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag(""), nil).Once()
+	tx.EXPECT().CopyFrom(ctx, pgx.Identifier{"units_restore"},
+		[]string{"id", "unit_name"}, mock.Anything).Return(1, nil).Once()
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag(""), wantErr).Once()
+	_, err = repo.RestoreUnits(ctx, units)
+	require.ErrorIs(t, err, wantErr)
+
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag(""), nil).Once()
+	tx.EXPECT().CopyFrom(ctx, pgx.Identifier{"units_restore"},
+		[]string{"id", "unit_name"}, mock.Anything).Return(1, nil).Once()
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag("INSERT 0 1"), nil).Once()
+	tx.EXPECT().Exec(ctx, mock.Anything).
+		Return(pgconn.NewCommandTag(""), wantErr).Once()
+	_, err = repo.RestoreUnits(ctx, units)
+	require.ErrorIs(t, err, wantErr)
+}